@@ -0,0 +1,13 @@
+package documenting_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestDocumenting(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Documenting Suite")
+}
@@ -0,0 +1,64 @@
+package documenting
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/gotgo/gokn/handling"
+	"github.com/gotgo/gokn/rest"
+)
+
+// YamlContentType is the content type of OpenApiYamlEndpoint
+const YamlContentType = "application/yaml"
+
+var (
+	// OpenApiJsonEndpoint replies with the document as json
+	OpenApiJsonEndpoint = documentEndpoint("/openapi.json", rest.ContentTypeJson)
+	// OpenApiYamlEndpoint replies with the document as yaml
+	OpenApiYamlEndpoint = documentEndpoint("/openapi.yaml", YamlContentType)
+)
+
+func documentEndpoint(resourceT, contentType string) rest.ServerResource {
+	def := &rest.ResourceDef{
+		ResourceT:    resourceT,
+		Verb:         "GET",
+		ResponseBody: reflect.TypeOf(Document{}),
+	}
+	return rest.NewServerResource(def, []string{rest.ContentTypeJson}, []string{contentType})
+}
+
+// Handler replies with the document, encoded once
+type Handler struct {
+	json []byte
+	yaml []byte
+	err  error
+}
+
+func NewHandler(doc *Document) *Handler {
+	h := new(Handler)
+	if h.json, h.err = doc.Json(); h.err == nil {
+		h.yaml, h.err = JsonToYaml(h.json)
+	}
+	return h
+}
+
+func (h *Handler) Get(req *rest.Request, resp rest.Responder) {
+	if h.err != nil {
+		resp.SetStatus(http.StatusInternalServerError, "Internal Server Error", h.err)
+		return
+	}
+	if req.Definition.ResourceT() == OpenApiYamlEndpoint.ResourceT() {
+		resp.SetContentType(YamlContentType)
+		resp.SetBody(h.yaml)
+	} else {
+		resp.SetContentType(rest.ContentTypeJson)
+		resp.SetBody(h.json)
+	}
+}
+
+// Bind binds /openapi.json and /openapi.yaml using the RootHandler Binder
+func Bind(root *handling.RootHandler, router handling.SimpleRouter, doc *Document, resourceRoot string) {
+	h := NewHandler(doc)
+	root.Bind(router, OpenApiJsonEndpoint, h, resourceRoot)
+	root.Bind(router, OpenApiYamlEndpoint, h, resourceRoot)
+}
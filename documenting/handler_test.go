@@ -0,0 +1,49 @@
+package documenting_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/gotgo/gokn/documenting"
+	"github.com/gotgo/gokn/handling"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// testRouter keeps the bound handlers by verb and path template
+type testRouter map[string]func(http.ResponseWriter, *http.Request)
+
+func (tr testRouter) RegisterRoute(verb, path string, f func(http.ResponseWriter, *http.Request)) {
+	tr[verb+" "+path] = f
+}
+
+var _ = Describe("Handler", func() {
+	var router testRouter
+
+	BeforeEach(func() {
+		router = make(testRouter)
+		doc := NewDocument(&Info{Title: "Orders", Version: "1.0"}, newOrderApi(), "/v1")
+		Bind(handling.NewRootHandler(), router, doc, "/v1")
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router["GET "+path](w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	It("should serve the document as json", func() {
+		w := get("/v1/openapi.json")
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("Content-Type")).To(HavePrefix("application/json"))
+		Expect(w.Body.String()).To(ContainSubstring(`"openapi": "3.1.0"`))
+	})
+
+	It("should serve the document as yaml", func() {
+		w := get("/v1/openapi.yaml")
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("Content-Type")).To(HavePrefix("application/yaml"))
+		Expect(w.Body.String()).To(HavePrefix("openapi: 3.1.0\n"))
+	})
+})
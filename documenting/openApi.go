@@ -0,0 +1,318 @@
+package documenting
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gotgo/gokn/rest"
+	"github.com/oleiade/reflections"
+)
+
+// OpenApiVersion is the version of the OpenAPI specification the documents follow
+const OpenApiVersion = "3.1.0"
+
+// Document is an OpenAPI document, https://spec.openapis.org/oas/v3.1.0
+type Document struct {
+	OpenApi    string                `json:"openapi"`
+	Info       *Info                 `json:"info"`
+	Servers    []*Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem  `json:"paths"`
+	Components *Components           `json:"components,omitempty"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	Url         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem has the operation of each verb of a resource
+type PathItem struct {
+	Get        *Operation   `json:"get,omitempty"`
+	Put        *Operation   `json:"put,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty"`
+	Head       *Operation   `json:"head,omitempty"`
+	Parameters []*Parameter `json:"parameters,omitempty"`
+}
+
+type Operation struct {
+	OperationId string               `json:"operationId,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	// Security is nil for the document default, and empty for anonymous operations
+	Security *[]SecurityRequirement `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema  *Schema     `json:"schema,omitempty"`
+	Example interface{} `json:"example,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how a scheme named in ResourceDef.Security authorizes
+//
+//	Example:
+//
+//		doc.Components.SecuritySchemes["bearer"] = &documenting.SecurityScheme{Type: "http", Scheme: "bearer"}
+//		doc.Components.SecuritySchemes["apiKey"] = &documenting.SecurityScheme{Type: "apiKey", In: "header", Name: "X-Api-Key"}
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// SecurityRequirement maps scheme names to the scopes they need
+type SecurityRequirement map[string][]string
+
+// NewDocument documents every endpoint of an api spec, the struct of *rest.ResourceSpec
+// fields FullApiWithHandlers takes.  Operations are named by verb and field, so the Order
+// field's GET is getOrder.  Paths are joined to the resourceRoot, as RootHandler.Bind does.
+//
+//	Example:
+//
+//		doc := documenting.NewDocument(&documenting.Info{Title: "Orders", Version: "1.0"}, &api.Spec{}, "/v1")
+//		doc.Components.SecuritySchemes["bearer"] = &documenting.SecurityScheme{Type: "http", Scheme: "bearer"}
+//		doc.Security = []documenting.SecurityRequirement{{"bearer": {}}}
+func NewDocument(info *Info, apiSpec interface{}, resourceRoot string) *Document {
+	doc := &Document{
+		OpenApi: OpenApiVersion,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+		Components: &Components{
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: make(map[string]*SecurityScheme),
+		},
+	}
+	schemas := newSchemas(doc.Components.Schemas)
+
+	fields, err := reflections.Fields(apiSpec)
+	if err != nil {
+		panic(err)
+	}
+	for _, name := range fields {
+		v, err := reflections.GetField(apiSpec, name)
+		if err != nil {
+			continue //unexported
+		}
+		spec, ok := v.(*rest.ResourceSpec)
+		if !ok || spec == nil {
+			continue
+		}
+		endpoints, _ := spec.ServeAll()
+		for _, endpoint := range endpoints {
+			doc.add(schemas, name, endpoint, resourceRoot)
+		}
+	}
+	return doc
+}
+
+func (doc *Document) add(schemas *schemas, name string, endpoint rest.ServerResource, resourceRoot string) {
+	resourceT, patterns := openApiPath(path.Join(resourceRoot, endpoint.ResourceT()))
+	item := doc.Paths[resourceT]
+	if item == nil {
+		item = new(PathItem)
+		doc.Paths[resourceT] = item
+	}
+
+	verb := strings.ToUpper(endpoint.Verb())
+	op := &Operation{
+		OperationId: strings.ToLower(verb) + name,
+		Parameters:  parameters(schemas, endpoint, patterns),
+		Responses:   make(map[string]*Response),
+	}
+	def := rest.DefinitionOf(endpoint)
+
+	if body := endpoint.RequestBody(); body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  content(schemas, reflect.TypeOf(body), endpoint.RequestContentTypes(), example(def, true)),
+		}
+	}
+
+	ok := &Response{Description: http.StatusText(http.StatusOK)}
+	if body := endpoint.ResponseBody(); body != nil && verb != "HEAD" {
+		ok.Content = content(schemas, reflect.TypeOf(body), endpoint.ResponseContentTypes(), example(def, false))
+	}
+	op.Responses["200"] = ok
+	op.Responses["default"] = &Response{Description: "Error"}
+
+	if def != nil && def.Security != nil {
+		requirements := []SecurityRequirement{}
+		for _, scheme := range def.Security {
+			requirements = append(requirements, SecurityRequirement{scheme: []string{}})
+		}
+		op.Security = &requirements
+	}
+
+	switch verb {
+	case "GET":
+		item.Get = op
+	case "PUT":
+		item.Put = op
+	case "POST":
+		item.Post = op
+	case "DELETE":
+		item.Delete = op
+	case "PATCH":
+		item.Patch = op
+	case "HEAD":
+		item.Head = op
+	}
+}
+
+func example(def *rest.ResourceDef, request bool) interface{} {
+	if def == nil {
+		return nil
+	} else if request {
+		return def.RequestExample
+	}
+	return def.ResponseExample
+}
+
+func content(schemas *schemas, t reflect.Type, contentTypes []string, example interface{}) map[string]*MediaType {
+	if len(contentTypes) == 0 {
+		contentTypes = []string{rest.ContentTypeJson}
+	}
+	media := make(map[string]*MediaType)
+	for _, ct := range contentTypes {
+		media[ct] = &MediaType{Schema: schemas.schema(t), Example: example}
+	}
+	return media
+}
+
+var pathVariable = regexp.MustCompile(`\{([^}:]+)(?::([^}]*))?\}`)
+
+// openApiPath drops the patterns of gorilla mux variables, /a/{b:[0-9]+} is /a/{b}, and
+// returns the patterns by variable
+func openApiPath(resourceT string) (string, map[string]string) {
+	patterns := make(map[string]string)
+	for _, m := range pathVariable.FindAllStringSubmatch(resourceT, -1) {
+		patterns[m[1]] = m[2]
+	}
+	return pathVariable.ReplaceAllString(resourceT, "{$1}"), patterns
+}
+
+// parameters are the path variables, the declared headers and the remaining ResourceArgs
+// fields, as query parameters
+func parameters(schemas *schemas, endpoint rest.ServerResource, patterns map[string]string) []*Parameter {
+	params := []*Parameter{}
+	args := map[string]reflect.Type{}
+	argNames := []string{}
+	if v := endpoint.ResourceArgs(); v != nil {
+		for _, f := range argFields(reflect.TypeOf(v)) {
+			args[f.name] = f.t
+			argNames = append(argNames, f.name)
+		}
+	}
+
+	inPath := make(map[string]bool)
+	for _, m := range pathVariable.FindAllStringSubmatch(endpoint.ResourceT(), -1) {
+		variable := m[1]
+		schema := &Schema{Type: "string"}
+		for _, name := range argNames {
+			if strings.EqualFold(name, variable) {
+				schema = schemas.schema(args[name])
+				inPath[name] = true
+			}
+		}
+		if pattern := patterns[variable]; pattern != "" {
+			schema.Pattern = "^" + pattern + "$"
+		}
+		params = append(params, &Parameter{Name: variable, In: "path", Required: true, Schema: schema})
+	}
+
+	for _, name := range argNames {
+		if !inPath[name] {
+			params = append(params, &Parameter{Name: name, In: "query", Schema: schemas.schema(args[name])})
+		}
+	}
+
+	for _, header := range endpoint.Headers() {
+		params = append(params, &Parameter{Name: header, In: "header", Schema: &Schema{Type: "string"}})
+	}
+	return params
+}
+
+type argField struct {
+	name string
+	t    reflect.Type
+}
+
+// argFields are the exported fields of the args, named by their url or json tag
+func argFields(t reflect.Type) []argField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	fields := []argField{}
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, argFields(f.Type)...)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		name := tagName(f, "url", "json")
+		if name == "-" {
+			continue
+		}
+		fields = append(fields, argField{name: name, t: f.Type})
+	}
+	return fields
+}
+
+// tagName is the name from the first of the tags that has one, or the field name
+func tagName(f reflect.StructField, tags ...string) string {
+	for _, tag := range tags {
+		if name := strings.Split(f.Tag.Get(tag), ",")[0]; name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// Json is the document as indented json
+func (doc *Document) Json() ([]byte, error) {
+	return json.MarshalIndent(doc, "", "  ")
+}
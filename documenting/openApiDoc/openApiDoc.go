@@ -0,0 +1,93 @@
+// openApiDoc saves the OpenAPI document a service serves from documenting.Bind, as json or
+// yaml by the extension of the output, or checks that a saved document hasn't drifted
+//
+//	openApiDoc -url http://localhost:8080/v1/openapi.json -o docs/openapi.yaml
+//	openApiDoc -url http://localhost:8080/v1/openapi.json -check docs/openapi.yaml
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/gotgo/gokn/documenting"
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	url := flag.String("url", "", "url of the service's openapi.json, or a file with -in")
+	in := flag.String("in", "", "read the document from a json file rather than the url")
+	out := flag.String("o", "", "file to write, .yaml or .yml for yaml, otherwise json. stdout when empty")
+	check := flag.String("check", "", "exit 1 when this saved document differs from the served one")
+	flag.Parse()
+
+	if (*url == "") == (*in == "") {
+		fmt.Fprintln(os.Stderr, "one of -url or -in is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	served, err := read(*url, *in)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *check != "" {
+		saved, err := ioutil.ReadFile(*check)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if same, err := equivalent(served, saved); err != nil {
+			log.Fatal(err)
+		} else if !same {
+			fmt.Fprintf(os.Stderr, "%s is out of date with the api\n", *check)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if ext := strings.ToLower(filepath.Ext(*out)); ext == ".yaml" || ext == ".yml" {
+		if served, err = documenting.JsonToYaml(served); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *out == "" {
+		os.Stdout.Write(served)
+	} else if err := ioutil.WriteFile(*out, served, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func read(url, in string) ([]byte, error) {
+	if in != "" {
+		return ioutil.ReadFile(in)
+	}
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s replied %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// equivalent compares documents by value, json or yaml, whatever the formatting
+func equivalent(a, b []byte) (bool, error) {
+	var av, bv interface{}
+	if err := yaml.NewDecoder(bytes.NewReader(a)).Decode(&av); err != nil {
+		return false, err
+	}
+	if err := yaml.NewDecoder(bytes.NewReader(b)).Decode(&bv); err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(av, bv), nil
+}
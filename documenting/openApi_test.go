@@ -0,0 +1,187 @@
+package documenting_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	. "github.com/gotgo/gokn/documenting"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type Audit struct {
+	Created time.Time `json:"created"`
+}
+
+type Line struct {
+	Sku      string `json:"sku" example:"sku_1"`
+	Quantity int    `json:"quantity" example:"3"`
+}
+
+type Order struct {
+	Audit
+	Id       string            `json:"id"`
+	Total    float64           `json:"total,omitempty"`
+	Lines    []*Line           `json:"lines"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Card     string            `json:"card,omitempty" sensitive:"true"`
+	Parent   *Order            `json:"parent"`
+	Internal string            `json:"-"`
+}
+
+type OrderArgs struct {
+	Id    int64  `json:"id"`
+	Since string `url:"since"`
+}
+
+type ListArgs struct {
+	Page  int      `url:"page"`
+	Sizes []string `json:"sizes"`
+}
+
+type OrderApi struct {
+	Orders *rest.ResourceSpec
+	Order  *rest.ResourceSpec
+	Health *rest.ResourceSpec
+}
+
+func newOrderApi() *OrderApi {
+	return &OrderApi{
+		Orders: rest.NewResourceSpec(rest.ContentTypeJson).
+			Use(&rest.ResourceDef{
+				ResourceT:    "/orders",
+				Verb:         "GET",
+				ResourceArgs: reflect.TypeOf(ListArgs{}),
+				ResponseBody: reflect.TypeOf([]*Order{}),
+			}).
+			Use(&rest.ResourceDef{
+				ResourceT:      "/orders",
+				Verb:           "POST",
+				Headers:        []string{"Idempotency-Key"},
+				RequestBody:    reflect.TypeOf(Order{}),
+				ResponseBody:   reflect.TypeOf(Order{}),
+				RequestExample: &Order{Id: "ord_1"},
+			}),
+		Order: rest.NewResourceSpec(rest.ContentTypeJson).
+			Use(&rest.ResourceDef{
+				ResourceT:    "/orders/{id:[0-9]+}",
+				Verb:         "GET",
+				ResourceArgs: reflect.TypeOf(OrderArgs{}),
+				ResponseBody: reflect.TypeOf(Order{}),
+				Security:     []string{"bearer", "apiKey"},
+			}).
+			Use(&rest.ResourceDef{
+				ResourceT:    "/orders/{id}",
+				Verb:         "HEAD",
+				ResponseBody: reflect.TypeOf(Order{}),
+			}),
+		Health: rest.NewResourceSpec(rest.ContentTypeJson).
+			Use(&rest.ResourceDef{
+				ResourceT: "/health",
+				Verb:      "GET",
+				Security:  rest.Anonymous,
+			}),
+	}
+}
+
+// asMap is the document as generic json, as a reader of it sees it
+func asMap(doc *Document) map[string]interface{} {
+	bts, err := doc.Json()
+	Expect(err).To(BeNil())
+	m := make(map[string]interface{})
+	Expect(json.Unmarshal(bts, &m)).To(Succeed())
+	return m
+}
+
+var _ = Describe("NewDocument", func() {
+	var doc *Document
+
+	BeforeEach(func() {
+		doc = NewDocument(&Info{Title: "Orders", Version: "1.0"}, newOrderApi(), "/v1")
+	})
+
+	It("should have a path per resource, joined to the root", func() {
+		Expect(doc.OpenApi).To(Equal("3.1.0"))
+		Expect(doc.Paths).To(HaveLen(3))
+		Expect(doc.Paths).To(HaveKey("/v1/orders"))
+		Expect(doc.Paths).To(HaveKey("/v1/orders/{id}"))
+		Expect(doc.Paths["/v1/orders"].Get).ToNot(BeNil())
+		Expect(doc.Paths["/v1/orders"].Post).ToNot(BeNil())
+	})
+
+	It("should name operations by verb and field", func() {
+		Expect(doc.Paths["/v1/orders"].Get.OperationId).To(Equal("getOrders"))
+		Expect(doc.Paths["/v1/orders/{id}"].Head.OperationId).To(Equal("headOrder"))
+	})
+
+	It("should document path parameters from the args and template", func() {
+		params := doc.Paths["/v1/orders/{id}"].Get.Parameters
+		Expect(params).To(HaveLen(2))
+		Expect(*params[0]).To(Equal(Parameter{Name: "id", In: "path", Required: true,
+			Schema: &Schema{Type: "integer", Format: "int64", Pattern: "^[0-9]+$"}}))
+		Expect(*params[1]).To(Equal(Parameter{Name: "since", In: "query", Schema: &Schema{Type: "string"}}))
+
+		head := doc.Paths["/v1/orders/{id}"].Head.Parameters
+		Expect(*head[0]).To(Equal(Parameter{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}}))
+	})
+
+	It("should document query parameters and headers", func() {
+		params := doc.Paths["/v1/orders"].Get.Parameters
+		Expect(params).To(HaveLen(2))
+		Expect(params[0].Name).To(Equal("page"))
+		Expect(params[1].Name).To(Equal("sizes"))
+		Expect(params[1].Schema).To(Equal(&Schema{Type: "array", Items: &Schema{Type: "string"}}))
+
+		post := doc.Paths["/v1/orders"].Post.Parameters
+		Expect(*post[0]).To(Equal(Parameter{Name: "Idempotency-Key", In: "header", Schema: &Schema{Type: "string"}}))
+	})
+
+	It("should document the bodies by content type", func() {
+		post := doc.Paths["/v1/orders"].Post
+		Expect(post.RequestBody.Content).To(HaveKey("application/json"))
+		Expect(post.RequestBody.Content["application/json"].Schema.Ref).To(Equal("#/components/schemas/Order"))
+		Expect(post.RequestBody.Content["application/json"].Example).To(Equal(&Order{Id: "ord_1"}))
+		Expect(post.Responses["200"].Content["application/json"].Schema.Ref).To(Equal("#/components/schemas/Order"))
+
+		list := doc.Paths["/v1/orders"].Get.Responses["200"].Content["application/json"].Schema
+		Expect(list.Type).To(Equal("array"))
+		Expect(list.Items.Ref).To(Equal("#/components/schemas/Order"))
+
+		Expect(doc.Paths["/v1/orders/{id}"].Head.Responses["200"].Content).To(BeEmpty())
+	})
+
+	It("should document the schemas of the named structs", func() {
+		order := doc.Components.Schemas["Order"]
+		Expect(order.Type).To(Equal("object"))
+		Expect(order.Properties).To(HaveKey("created"))
+		Expect(order.Properties).ToNot(HaveKey("Internal"))
+		Expect(order.Properties["created"]).To(Equal(&Schema{Type: "string", Format: "date-time"}))
+		Expect(order.Properties["labels"].AdditionalProperties).To(Equal(&Schema{Type: "string"}))
+		Expect(order.Properties["card"].WriteOnly).To(BeTrue())
+		Expect(order.Properties["parent"].Ref).To(Equal("#/components/schemas/Order"))
+		Expect(order.Required).To(Equal([]string{"created", "id", "lines"}))
+
+		line := doc.Components.Schemas["Line"]
+		Expect(line.Properties["sku"].Examples).To(Equal([]interface{}{"sku_1"}))
+		Expect(line.Properties["quantity"].Examples).To(Equal([]interface{}{float64(3)}))
+	})
+
+	It("should document the security of each operation", func() {
+		m := asMap(doc)
+		paths := m["paths"].(map[string]interface{})
+		get := paths["/v1/orders/{id}"].(map[string]interface{})["get"].(map[string]interface{})
+		Expect(get["security"]).To(Equal([]interface{}{
+			map[string]interface{}{"bearer": []interface{}{}},
+			map[string]interface{}{"apiKey": []interface{}{}},
+		}))
+
+		health := paths["/v1/health"].(map[string]interface{})["get"].(map[string]interface{})
+		Expect(health).To(HaveKeyWithValue("security", []interface{}{}))
+
+		list := paths["/v1/orders"].(map[string]interface{})["get"].(map[string]interface{})
+		Expect(list).ToNot(HaveKey("security"))
+	})
+})
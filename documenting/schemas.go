@@ -0,0 +1,156 @@
+package documenting
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gotgo/gokn/mocking"
+	"github.com/gotgo/gokn/rest"
+)
+
+// Schema is a json schema, as OpenAPI 3.1 uses them
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	WriteOnly            bool               `json:"writeOnly,omitempty"`
+	Examples             []interface{}      `json:"examples,omitempty"`
+}
+
+// RefPrefix is where the $ref of a named struct points
+const RefPrefix = "#/components/schemas/"
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	durationType  = reflect.TypeOf(time.Duration(0))
+	rawType       = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemas generates the schemas of go types, named structs go to the components and are
+// referenced
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas(components map[string]*Schema) *schemas {
+	return &schemas{
+		components: components,
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+func (s *schemas) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == durationType:
+		return &Schema{Type: "integer", Format: "int64"}
+	case t == rawType:
+		return &Schema{}
+	case t.Kind() != reflect.Struct && t.Implements(marshalerType):
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return &Schema{Ref: RefPrefix + s.component(t)}
+	}
+	return &Schema{}
+}
+
+// component adds the schema of a named struct once, types of the same name from
+// different packages are qualified by package
+func (s *schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := s.components[name]; taken {
+		name = strings.Replace(t.PkgPath(), "/", "_", -1) + "_" + name
+	}
+	s.names[t] = name
+	s.components[name] = &Schema{} //recursive types refer to it while it's made
+	*s.components[name] = *s.object(t)
+	return name
+}
+
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.properties(t, schema)
+	return schema
+}
+
+// properties follow encoding/json, embedded structs are flattened and omitempty fields
+// are optional
+func (s *schemas) properties(t reflect.Type, schema *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct && tag == "" {
+			s.properties(ft, schema)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := tagName(f, "json")
+		property := s.schema(f.Type)
+		if tag := f.Tag.Get(rest.SensitiveTag); tag == "true" || tag == "1" {
+			property.WriteOnly = true
+		}
+		if example, ok := f.Tag.Lookup(mocking.ExampleTag); ok {
+			var v interface{}
+			if err := json.Unmarshal([]byte(example), &v); err != nil || ft.Kind() == reflect.String {
+				v = example
+			}
+			property.Examples = []interface{}{v}
+		}
+		schema.Properties[name] = property
+		if !strings.Contains(tag, ",omitempty") && f.Type.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
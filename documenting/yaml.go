@@ -0,0 +1,46 @@
+package documenting
+
+import (
+	"bytes"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Yaml is the document as yaml, in the same order as the json
+func (doc *Document) Yaml() ([]byte, error) {
+	bts, err := doc.Json()
+	if err != nil {
+		return nil, err
+	}
+	return JsonToYaml(bts)
+}
+
+// JsonToYaml converts json to block style yaml, keeping the order of the keys
+func JsonToYaml(bts []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(bts, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	encoder.Close()
+	return buf.Bytes(), nil
+}
+
+// blockStyle drops the flow style and quotes json parses with, empty maps and lists stay
+// as {} and []
+func blockStyle(node *yaml.Node) {
+	if (node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode) && len(node.Content) == 0 {
+		return
+	}
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
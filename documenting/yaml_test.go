@@ -0,0 +1,33 @@
+package documenting_test
+
+import (
+	. "github.com/gotgo/gokn/documenting"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Yaml", func() {
+
+	It("should convert json to block yaml in the same order", func() {
+		yaml, err := JsonToYaml([]byte(`{"b":{"200":"ok","list":[1,"two"]},"a":"{x}","empty":{},"none":[]}`))
+		Expect(err).To(BeNil())
+		Expect(string(yaml)).To(Equal(`b:
+  "200": ok
+  list:
+    - 1
+    - two
+a: '{x}'
+empty: {}
+none: []
+`))
+	})
+
+	It("should write the document", func() {
+		doc := NewDocument(&Info{Title: "Orders", Version: "1.0"}, newOrderApi(), "")
+		yaml, err := doc.Yaml()
+		Expect(err).To(BeNil())
+		Expect(string(yaml)).To(HavePrefix("openapi: 3.1.0\ninfo:\n  title: Orders\n"))
+		Expect(string(yaml)).To(ContainSubstring("  /orders/{id}:\n"))
+	})
+})
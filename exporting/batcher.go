@@ -0,0 +1,169 @@
+package exporting
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gotgo/fw/tracing"
+)
+
+const (
+	defaultQueueSize     = 2048
+	defaultBatchSize     = 256
+	defaultFlushInterval = 5 * time.Second
+)
+
+// Exporter ships finished traces.  Handle is meant to be the RootHandler.TraceHandler
+//
+//	Example:
+//
+//		exporter := exporting.NewZipkinExporter("http://zipkin:9411/api/v2/spans", "orders", nil)
+//		root.TraceHandler = exporter.Handle
+//		defer exporter.Shutdown(ctx)
+type Exporter interface {
+	Handle(trace *tracing.TraceMessage)
+	// Shutdown flushes the queued traces and stops, waiting no longer than ctx allows
+	Shutdown(ctx context.Context) error
+}
+
+// BatchOptions bound the memory and latency of an exporter.  Zero values use the defaults.
+type BatchOptions struct {
+	// QueueSize is the most traces held, traces handled while the queue is full are dropped
+	QueueSize int
+	// BatchSize is the most traces sent in one flush
+	BatchSize int
+	// FlushInterval is the longest a trace waits in the queue
+	FlushInterval time.Duration
+}
+
+// batcher queues traces and flushes them from a background goroutine
+type batcher struct {
+	queue    chan *tracing.TraceMessage
+	size     int
+	interval time.Duration
+	flush    func([]*tracing.TraceMessage) error
+	dropped  uint64
+	failed   uint64
+	stop     chan struct{}
+	stopped  chan struct{}
+	// closed is set under the write lock, so no trace is queued once the queue is drained
+	closed bool
+	lock   sync.RWMutex
+}
+
+func newBatcher(options *BatchOptions, flush func([]*tracing.TraceMessage) error) *batcher {
+	o := BatchOptions{}
+	if options != nil {
+		o = *options
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = defaultQueueSize
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = defaultBatchSize
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = defaultFlushInterval
+	}
+
+	b := &batcher{
+		queue:    make(chan *tracing.TraceMessage, o.QueueSize),
+		size:     o.BatchSize,
+		interval: o.FlushInterval,
+		flush:    flush,
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Handle queues the trace without blocking, it is dropped if the queue is full or the
+// exporter has been shut down
+func (b *batcher) Handle(trace *tracing.TraceMessage) {
+	if trace == nil {
+		return
+	}
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	if b.closed {
+		atomic.AddUint64(&b.dropped, 1)
+		return
+	}
+
+	select {
+	case b.queue <- trace:
+	default:
+		atomic.AddUint64(&b.dropped, 1)
+	}
+}
+
+// Dropped is the number of traces discarded because the queue was full or closed
+func (b *batcher) Dropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+// Failed is the number of traces in batches that could not be sent
+func (b *batcher) Failed() uint64 {
+	return atomic.LoadUint64(&b.failed)
+}
+
+func (b *batcher) Shutdown(ctx context.Context) error {
+	b.lock.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.stop)
+	}
+	b.lock.Unlock()
+	select {
+	case <-b.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *batcher) run() {
+	defer close(b.stopped)
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	batch := make([]*tracing.TraceMessage, 0, b.size)
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := b.flush(batch); err != nil {
+			atomic.AddUint64(&b.failed, uint64(len(batch)))
+		}
+		batch = make([]*tracing.TraceMessage, 0, b.size)
+	}
+
+	for {
+		select {
+		case trace := <-b.queue:
+			batch = append(batch, trace)
+			if len(batch) >= b.size {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case <-b.stop:
+			//drain what was queued before the shutdown
+			for {
+				select {
+				case trace := <-b.queue:
+					batch = append(batch, trace)
+					if len(batch) >= b.size {
+						send()
+					}
+				default:
+					send()
+					return
+				}
+			}
+		}
+	}
+}
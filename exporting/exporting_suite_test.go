@@ -0,0 +1,13 @@
+package exporting_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestExporting(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Exporting Suite")
+}
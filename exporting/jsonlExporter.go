@@ -0,0 +1,46 @@
+package exporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/gotgo/fw/tracing"
+)
+
+// JsonlExporter writes each trace as a line of json, usually to a RotatingFile
+type JsonlExporter struct {
+	*batcher
+	writer io.Writer
+}
+
+func NewJsonlExporter(writer io.Writer, options *BatchOptions) *JsonlExporter {
+	je := &JsonlExporter{writer: writer}
+	je.batcher = newBatcher(options, je.write)
+	return je
+}
+
+// write sends the whole batch in one Write, so a RotatingFile doesn't split it mid line
+func (je *JsonlExporter) write(batch []*tracing.TraceMessage) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, trace := range batch {
+		if err := encoder.Encode(trace); err != nil {
+			return err
+		}
+	}
+	_, err := je.writer.Write(buf.Bytes())
+	return err
+}
+
+// Shutdown flushes the queue and closes the writer if it is an io.Closer
+func (je *JsonlExporter) Shutdown(ctx context.Context) error {
+	if err := je.batcher.Shutdown(ctx); err != nil {
+		return err
+	}
+	if closer, ok := je.writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
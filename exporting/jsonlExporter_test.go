@@ -0,0 +1,108 @@
+package exporting_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gotgo/fw/tracing"
+	. "github.com/gotgo/gokn/exporting"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// lockedBuffer is written by the exporter's goroutine and read by the test
+type lockedBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (lb *lockedBuffer) Write(p []byte) (int, error) {
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+	return lb.buf.Write(p)
+}
+
+func (lb *lockedBuffer) Lines() []string {
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+	lines := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(lb.buf.Bytes()))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+func newTrace(name string) *tracing.TraceMessage {
+	trace := tracing.NewReceiveTrace("trace-"+name, "parent-"+name)
+	trace.ReceivedRequest(name, nil, nil)
+	trace.Annotate(tracing.FromRequestData, "body", "{}")
+	trace.RequestCompleted()
+	return trace
+}
+
+var _ = Describe("JsonlExporter", func() {
+
+	var out *lockedBuffer
+
+	BeforeEach(func() {
+		out = new(lockedBuffer)
+	})
+
+	It("should write a line per trace on shutdown", func() {
+		exporter := NewJsonlExporter(out, &BatchOptions{FlushInterval: time.Hour})
+		exporter.Handle(newTrace("a"))
+		exporter.Handle(newTrace("b"))
+		Expect(exporter.Shutdown(context.Background())).To(BeNil())
+
+		lines := out.Lines()
+		Expect(lines).To(HaveLen(2))
+		var decoded map[string]interface{}
+		Expect(json.Unmarshal([]byte(lines[0]), &decoded)).To(BeNil())
+	})
+
+	It("should flush in the background", func() {
+		exporter := NewJsonlExporter(out, &BatchOptions{FlushInterval: 10 * time.Millisecond})
+		defer exporter.Shutdown(context.Background())
+		exporter.Handle(newTrace("a"))
+		Eventually(out.Lines).Should(HaveLen(1))
+	})
+
+	It("should flush when a batch is full", func() {
+		exporter := NewJsonlExporter(out, &BatchOptions{BatchSize: 2, FlushInterval: time.Hour})
+		defer exporter.Shutdown(context.Background())
+		exporter.Handle(newTrace("a"))
+		exporter.Handle(newTrace("b"))
+		Eventually(out.Lines).Should(HaveLen(2))
+	})
+
+	It("should drop traces after shutdown", func() {
+		exporter := NewJsonlExporter(out, nil)
+		Expect(exporter.Shutdown(context.Background())).To(BeNil())
+		exporter.Handle(newTrace("late"))
+		Expect(exporter.Dropped()).To(Equal(uint64(1)))
+		Expect(out.Lines()).To(BeEmpty())
+	})
+
+	It("should write or drop every trace handled during shutdown", func() {
+		exporter := NewJsonlExporter(out, nil)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					exporter.Handle(newTrace("racing"))
+				}
+			}()
+		}
+		Expect(exporter.Shutdown(context.Background())).To(BeNil())
+		wg.Wait()
+		Expect(len(out.Lines()) + int(exporter.Dropped())).To(Equal(500))
+	})
+})
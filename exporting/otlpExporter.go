@@ -0,0 +1,152 @@
+package exporting
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gotgo/fw/tracing"
+)
+
+const (
+	otlpSpanKindServer  = 2
+	otlpStatusCodeError = 2
+)
+
+// OtlpExporter posts batches of spans with the OTLP/HTTP json encoding, typically to
+// http://collector:4318/v1/traces
+type OtlpExporter struct {
+	*batcher
+	url         string
+	serviceName string
+	// Client defaults to the http.DefaultClient
+	Client  *http.Client
+	Headers map[string]string
+}
+
+func NewOtlpExporter(url, serviceName string, options *BatchOptions) *OtlpExporter {
+	oe := &OtlpExporter{
+		url:         url,
+		serviceName: serviceName,
+	}
+	oe.batcher = newBatcher(options, oe.send)
+	return oe
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string     `json:"key"`
+	Value *otlpValue `json:"value"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string           `json:"timeUnixNano"`
+	Name         string           `json:"name"`
+	Attributes   []*otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code int `json:"code,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceId           string           `json:"traceId"`
+	SpanId            string           `json:"spanId"`
+	ParentSpanId      string           `json:"parentSpanId,omitempty"`
+	Name              string           `json:"name"`
+	Kind              int              `json:"kind"`
+	StartTimeUnixNano string           `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string           `json:"endTimeUnixNano"`
+	Attributes        []*otlpAttribute `json:"attributes,omitempty"`
+	Events            []*otlpEvent     `json:"events,omitempty"`
+	Status            *otlpStatus      `json:"status"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpScopeSpans struct {
+	Scope *otlpScope  `json:"scope"`
+	Spans []*otlpSpan `json:"spans"`
+}
+
+type otlpResource struct {
+	Attributes []*otlpAttribute `json:"attributes"`
+}
+
+type otlpResourceSpans struct {
+	Resource   *otlpResource     `json:"resource"`
+	ScopeSpans []*otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []*otlpResourceSpans `json:"resourceSpans"`
+}
+
+func attribute(key, value string) *otlpAttribute {
+	return &otlpAttribute{Key: key, Value: &otlpValue{StringValue: value}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func toOtlp(trace *tracing.TraceMessage) *otlpSpan {
+	s := toSpan(trace)
+	out := &otlpSpan{
+		TraceId:           s.traceId,
+		SpanId:            s.spanId,
+		ParentSpanId:      s.parentId,
+		Name:              s.name,
+		Kind:              otlpSpanKindServer,
+		StartTimeUnixNano: unixNano(s.start),
+		EndTimeUnixNano:   unixNano(s.end),
+		Status:            &otlpStatus{},
+	}
+	if s.failed {
+		out.Status.Code = otlpStatusCodeError
+	}
+
+	keys := make([]string, 0, len(s.tags))
+	for k := range s.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		out.Attributes = append(out.Attributes, attribute(k, s.tags[k]))
+	}
+
+	for _, e := range s.events {
+		out.Events = append(out.Events, &otlpEvent{
+			TimeUnixNano: unixNano(e.time),
+			Name:         e.name,
+			Attributes:   []*otlpAttribute{attribute("value", e.value)},
+		})
+	}
+	return out
+}
+
+func (oe *OtlpExporter) send(batch []*tracing.TraceMessage) error {
+	spans := make([]*otlpSpan, len(batch))
+	for i, trace := range batch {
+		spans[i] = toOtlp(trace)
+	}
+
+	request := &otlpRequest{
+		ResourceSpans: []*otlpResourceSpans{{
+			Resource: &otlpResource{
+				Attributes: []*otlpAttribute{attribute("service.name", oe.serviceName)},
+			},
+			ScopeSpans: []*otlpScopeSpans{{
+				Scope: &otlpScope{Name: "github.com/gotgo/gokn"},
+				Spans: spans,
+			}},
+		}},
+	}
+	return postJson(oe.Client, oe.url, oe.Headers, request)
+}
@@ -0,0 +1,81 @@
+package exporting_test
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gotgo/fw/tracing"
+	. "github.com/gotgo/gokn/exporting"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type otlpRequest struct {
+	ResourceSpans []struct {
+		Resource struct {
+			Attributes []struct {
+				Key   string `json:"key"`
+				Value struct {
+					StringValue string `json:"stringValue"`
+				} `json:"value"`
+			} `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans []struct {
+			Spans []struct {
+				TraceId           string `json:"traceId"`
+				SpanId            string `json:"spanId"`
+				ParentSpanId      string `json:"parentSpanId"`
+				Name              string `json:"name"`
+				Kind              int    `json:"kind"`
+				StartTimeUnixNano string `json:"startTimeUnixNano"`
+				Status            struct {
+					Code int `json:"code"`
+				} `json:"status"`
+			} `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+var _ = Describe("OtlpExporter", func() {
+
+	var c *collector
+
+	BeforeEach(func() {
+		c = newCollector()
+	})
+
+	AfterEach(func() {
+		c.server.Close()
+	})
+
+	It("should post resource spans with the service name", func() {
+		exporter := NewOtlpExporter(c.server.URL+"/v1/traces", "orders", &BatchOptions{FlushInterval: time.Hour})
+		exporter.Headers = map[string]string{"Authorization": "Bearer x"}
+		exporter.Handle(newTrace("a"))
+		failed := newTrace("b")
+		failed.Annotate(tracing.FromPanic, "stack", "...")
+		exporter.Handle(failed)
+		Expect(exporter.Shutdown(context.Background())).To(BeNil())
+
+		Expect(c.Bodies()).To(HaveLen(1))
+		request := &otlpRequest{}
+		Expect(json.Unmarshal(c.Bodies()[0], request)).To(BeNil())
+
+		rs := request.ResourceSpans[0]
+		Expect(rs.Resource.Attributes[0].Key).To(Equal("service.name"))
+		Expect(rs.Resource.Attributes[0].Value.StringValue).To(Equal("orders"))
+
+		spans := rs.ScopeSpans[0].Spans
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].TraceId).To(Equal(rest.HexTraceId("trace-a")))
+		Expect(spans[0].TraceId).To(HaveLen(32))
+		Expect(spans[0].SpanId).To(HaveLen(16))
+		Expect(spans[0].Kind).To(Equal(2))
+		Expect(spans[0].StartTimeUnixNano).ToNot(BeEmpty())
+		Expect(spans[0].Status.Code).To(Equal(0))
+		Expect(spans[1].Status.Code).To(Equal(2))
+	})
+})
@@ -0,0 +1,113 @@
+package exporting
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile is an append only file that is rotated once it would grow past MaxBytes.
+// Rotated files are renamed path.1, path.2 ... and only MaxBackups of them are kept.
+type RotatingFile struct {
+	Path       string
+	MaxBytes   int64
+	MaxBackups int
+	lock       sync.Mutex
+	file       *os.File
+	size       int64
+}
+
+func NewRotatingFile(path string, maxBytes int64, maxBackups int) (*RotatingFile, error) {
+	rf := &RotatingFile{
+		Path:       path,
+		MaxBytes:   maxBytes,
+		MaxBackups: maxBackups,
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) open() error {
+	file, size, err := openAppend(rf.Path)
+	if err != nil {
+		return err
+	}
+	rf.file, rf.size = file, size
+	return nil
+}
+
+func openAppend(path string) (*os.File, int64, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
+// Write never splits p across files, so a line written in one call stays whole
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.lock.Lock()
+	defer rf.lock.Unlock()
+
+	if rf.file == nil {
+		return 0, os.ErrClosed
+	}
+	if rf.MaxBytes > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.MaxBytes {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// rotate keeps the old file open until the new one is, so a failed rotation leaves a file
+// to write to and is tried again on the next write
+func (rf *RotatingFile) rotate() error {
+	if rf.MaxBackups <= 0 {
+		if err := os.Remove(rf.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		os.Remove(backupName(rf.Path, rf.MaxBackups))
+		for i := rf.MaxBackups - 1; i >= 1; i-- {
+			if err := os.Rename(backupName(rf.Path, i), backupName(rf.Path, i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(rf.Path, backupName(rf.Path, 1)); err != nil {
+			return err
+		}
+	}
+
+	file, size, err := openAppend(rf.Path)
+	if err != nil {
+		return err
+	}
+	old := rf.file
+	rf.file, rf.size = file, size
+	return old.Close()
+}
+
+func backupName(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+func (rf *RotatingFile) Close() error {
+	rf.lock.Lock()
+	defer rf.lock.Unlock()
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
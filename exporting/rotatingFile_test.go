@@ -0,0 +1,89 @@
+package exporting_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/gotgo/gokn/exporting"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RotatingFile", func() {
+
+	var (
+		dir  string
+		path string
+	)
+
+	var read = func(name string) string {
+		bts, err := ioutil.ReadFile(name)
+		Expect(err).To(BeNil())
+		return string(bts)
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "rotating")
+		Expect(err).To(BeNil())
+		path = filepath.Join(dir, "traces.jsonl")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should rotate before a write would pass MaxBytes", func() {
+		rf, err := NewRotatingFile(path, 10, 2)
+		Expect(err).To(BeNil())
+		defer rf.Close()
+
+		for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddd\n", "eeee\n", "ffff\n", "gggg\n"} {
+			_, err := rf.Write([]byte(line))
+			Expect(err).To(BeNil())
+		}
+
+		Expect(read(path)).To(Equal("gggg\n"))
+		Expect(read(path + ".1")).To(Equal("eeee\nffff\n"))
+		Expect(read(path + ".2")).To(Equal("cccc\ndddd\n"))
+		_, err = os.Stat(path + ".3")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("should keep writing to the old file when a rotation fails", func() {
+		rf, err := NewRotatingFile(path, 10, 1)
+		Expect(err).To(BeNil())
+		defer rf.Close()
+		//a directory can't be replaced by the rotated file
+		Expect(os.MkdirAll(filepath.Join(path+".1", "taken"), 0755)).To(Succeed())
+
+		_, err = rf.Write([]byte("aaaa\nbbbb\n"))
+		Expect(err).To(BeNil())
+		_, err = rf.Write([]byte("cccc\n"))
+		Expect(err).ToNot(BeNil())
+
+		Expect(os.RemoveAll(path + ".1")).To(Succeed())
+		_, err = rf.Write([]byte("cccc\n"))
+		Expect(err).To(BeNil())
+		Expect(read(path)).To(Equal("cccc\n"))
+		Expect(read(path + ".1")).To(Equal("aaaa\nbbbb\n"))
+	})
+
+	It("should append to an existing file", func() {
+		Expect(ioutil.WriteFile(path, []byte("old\n"), 0644)).To(BeNil())
+		rf, err := NewRotatingFile(path, 0, 0)
+		Expect(err).To(BeNil())
+		rf.Write([]byte("new\n"))
+		rf.Close()
+		Expect(read(path)).To(Equal("old\nnew\n"))
+	})
+
+	It("should fail writes after Close", func() {
+		rf, _ := NewRotatingFile(path, 0, 0)
+		rf.Close()
+		_, err := rf.Write([]byte("x"))
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,104 @@
+package exporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gotgo/fw/tracing"
+	"github.com/gotgo/gokn/rest"
+)
+
+const defaultSendTimeout = 10 * time.Second
+
+// span is the common view of a TraceMessage the Zipkin and OTLP formats are built from
+type span struct {
+	traceId  string
+	spanId   string
+	parentId string
+	name     string
+	start    time.Time
+	end      time.Time
+	failed   bool
+	tags     map[string]string
+	events   []*spanEvent
+}
+
+type spanEvent struct {
+	time  time.Time
+	name  string
+	value string
+}
+
+func toSpan(trace *tracing.TraceMessage) *span {
+	s := &span{
+		traceId: rest.HexTraceId(trace.TraceUid),
+		spanId:  rest.HexSpanId(trace.SpanUid),
+		name:    trace.Name,
+		start:   trace.Start,
+		end:     trace.End,
+		failed:  trace.Failed,
+		tags:    make(map[string]string),
+	}
+	if trace.ParentSpanUid != "" {
+		s.parentId = rest.HexSpanId(trace.ParentSpanUid)
+	}
+	if s.end.Before(s.start) {
+		s.end = s.start
+	}
+
+	for _, a := range trace.Annotations {
+		key := fmt.Sprintf("%s.%s", a.From, a.Key)
+		value := fmt.Sprintf("%v", a.Value)
+		switch a.From {
+		case tracing.FromError, tracing.FromPanic:
+			s.failed = true
+			s.tags["error"] = value
+			s.events = append(s.events, &spanEvent{time: a.Time, name: key, value: value})
+		default:
+			s.tags[key] = value
+		}
+	}
+	return s
+}
+
+// postJson sends v, any status other than 2xx is an error
+func postJson(client *http.Client, url string, headers map[string]string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultSendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", rest.ContentTypeJson)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("trace collector %s replied %s", url, resp.Status)
+	}
+	return nil
+}
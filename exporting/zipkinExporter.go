@@ -0,0 +1,84 @@
+package exporting
+
+import (
+	"net/http"
+
+	"github.com/gotgo/fw/tracing"
+)
+
+// ZipkinExporter posts batches of spans in the Zipkin v2 json format, typically to
+// http://zipkin:9411/api/v2/spans
+type ZipkinExporter struct {
+	*batcher
+	url         string
+	serviceName string
+	// Client defaults to the http.DefaultClient
+	Client  *http.Client
+	Headers map[string]string
+}
+
+func NewZipkinExporter(url, serviceName string, options *BatchOptions) *ZipkinExporter {
+	ze := &ZipkinExporter{
+		url:         url,
+		serviceName: serviceName,
+	}
+	ze.batcher = newBatcher(options, ze.send)
+	return ze
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+type zipkinSpan struct {
+	TraceId       string              `json:"traceId"`
+	Id            string              `json:"id"`
+	ParentId      string              `json:"parentId,omitempty"`
+	Name          string              `json:"name"`
+	Kind          string              `json:"kind"`
+	Timestamp     int64               `json:"timestamp"`
+	Duration      int64               `json:"duration"`
+	LocalEndpoint *zipkinEndpoint     `json:"localEndpoint"`
+	Tags          map[string]string   `json:"tags,omitempty"`
+	Annotations   []*zipkinAnnotation `json:"annotations,omitempty"`
+}
+
+func (ze *ZipkinExporter) toZipkin(trace *tracing.TraceMessage) *zipkinSpan {
+	s := toSpan(trace)
+	duration := s.end.Sub(s.start).Nanoseconds() / 1000
+	if duration < 1 {
+		duration = 1 //zipkin treats 0 as unknown
+	}
+
+	zs := &zipkinSpan{
+		TraceId:       s.traceId,
+		Id:            s.spanId,
+		ParentId:      s.parentId,
+		Name:          s.name,
+		Kind:          "SERVER",
+		Timestamp:     s.start.UnixNano() / 1000,
+		Duration:      duration,
+		LocalEndpoint: &zipkinEndpoint{ServiceName: ze.serviceName},
+		Tags:          s.tags,
+	}
+	for _, e := range s.events {
+		zs.Annotations = append(zs.Annotations, &zipkinAnnotation{
+			Timestamp: e.time.UnixNano() / 1000,
+			Value:     e.name + ": " + e.value,
+		})
+	}
+	return zs
+}
+
+func (ze *ZipkinExporter) send(batch []*tracing.TraceMessage) error {
+	spans := make([]*zipkinSpan, len(batch))
+	for i, trace := range batch {
+		spans[i] = ze.toZipkin(trace)
+	}
+	return postJson(ze.Client, ze.url, ze.Headers, spans)
+}
@@ -0,0 +1,107 @@
+package exporting_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/gotgo/fw/tracing"
+	. "github.com/gotgo/gokn/exporting"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// collector is a local trace collector that keeps every request body
+type collector struct {
+	lock   sync.Mutex
+	status int
+	bodies [][]byte
+	server *httptest.Server
+}
+
+func newCollector() *collector {
+	c := &collector{status: http.StatusAccepted}
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bts, _ := ioutil.ReadAll(r.Body)
+		c.lock.Lock()
+		c.bodies = append(c.bodies, bts)
+		status := c.status
+		c.lock.Unlock()
+		w.WriteHeader(status)
+	}))
+	return c
+}
+
+func (c *collector) Bodies() [][]byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.bodies
+}
+
+var _ = Describe("ZipkinExporter", func() {
+
+	var c *collector
+
+	BeforeEach(func() {
+		c = newCollector()
+	})
+
+	AfterEach(func() {
+		c.server.Close()
+	})
+
+	It("should post a batch of v2 spans", func() {
+		exporter := NewZipkinExporter(c.server.URL+"/api/v2/spans", "orders", &BatchOptions{FlushInterval: time.Hour})
+		exporter.Handle(newTrace("a"))
+		failed := newTrace("b")
+		failed.Annotate(tracing.FromError, "request failed", "boom")
+		exporter.Handle(failed)
+		Expect(exporter.Shutdown(context.Background())).To(BeNil())
+
+		Expect(c.Bodies()).To(HaveLen(1))
+		var spans []map[string]interface{}
+		Expect(json.Unmarshal(c.Bodies()[0], &spans)).To(BeNil())
+		Expect(spans).To(HaveLen(2))
+
+		span := spans[0]
+		Expect(span["traceId"]).To(Equal(rest.HexTraceId("trace-a")))
+		Expect(span["parentId"]).To(Equal(rest.HexSpanId("parent-a")))
+		Expect(span["name"]).To(Equal("a"))
+		Expect(span["kind"]).To(Equal("SERVER"))
+		Expect(span["duration"]).To(BeNumerically(">=", 1))
+		Expect(span["localEndpoint"]).To(Equal(map[string]interface{}{"serviceName": "orders"}))
+
+		Expect(spans[1]["tags"]).To(HaveKeyWithValue("error", "boom"))
+		Expect(spans[1]["annotations"]).To(HaveLen(1))
+	})
+
+	It("should count the traces of a rejected batch", func() {
+		c.status = http.StatusInternalServerError
+		exporter := NewZipkinExporter(c.server.URL, "orders", nil)
+		exporter.Handle(newTrace("a"))
+		Expect(exporter.Shutdown(context.Background())).To(BeNil())
+		Expect(exporter.Failed()).To(Equal(uint64(1)))
+	})
+
+	It("should drop traces when the queue is full", func() {
+		block := make(chan struct{})
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-block
+		}))
+		defer slow.Close()
+
+		exporter := NewZipkinExporter(slow.URL, "orders", &BatchOptions{QueueSize: 1, BatchSize: 1})
+		for i := 0; i < 10; i++ {
+			exporter.Handle(newTrace("a"))
+		}
+		Expect(exporter.Dropped()).To(BeNumerically(">=", 8))
+		close(block)
+		Expect(exporter.Shutdown(context.Background())).To(BeNil())
+	})
+})
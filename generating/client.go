@@ -0,0 +1,145 @@
+package generating
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"strings"
+)
+
+// ClientOptions configure GenerateClient
+type ClientOptions struct {
+	// Package of the client, the spec's package then client when empty
+	Package string
+}
+
+// GenerateClient generates a typed client of a spec struct, with a method per endpoint that
+// takes the endpoint's Args and Body types and returns its ResponseBody type, on a
+// rest.Client.  Replies other than its SuccessStatuses are returned as a *rest.APIError.
+// Methods are named by their ResourceDef var without the Def suffix, or by verb and resource.
+//
+//	Example:
+//
+//		orders := ordersclient.NewClient(rest.NewClient(), api.NewApi())
+//		order, err := orders.GetOrder(ctx, &api.GetOrderArgs{Id: 42})
+//		if errors.Is(err, rest.NotFound) {
+//			...
+//		}
+func GenerateClient(api *SourceApi, options *ClientOptions) ([]byte, error) {
+	pkg := options.Package
+	if pkg == "" {
+		pkg = strings.ToLower(api.Package) + "client"
+	}
+	if api.ImportPath == "" {
+		return nil, fmt.Errorf("the import path of package %s is unknown", api.Package)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by goknGen from %s.%s. DO NOT EDIT.\n\n", api.Package, api.Name)
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	out.WriteString(importBlock([]string{"github.com/gotgo/gokn/rest", api.ImportPath}))
+	fmt.Fprintf(&out, clientPreamble, api.Name, api.Package, api.Name, api.Package, api.Name)
+
+	methods := make(names)
+	methods["call"] = true
+	for _, resource := range api.Resources {
+		for _, op := range resource.Operations {
+			verb := verbMethod(op.Verb)
+			if verb == "" {
+				continue
+			}
+			name := strings.TrimSuffix(op.Def, "Def")
+			if name == "" || !ast.IsExported(name) {
+				name = verb + resource.Name
+			}
+			writeClientMethod(&out, api.Package, methods.unique(name), resource.Name, verb, op)
+		}
+	}
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return out.Bytes(), fmt.Errorf("generated code doesn't compile: %s", err)
+	}
+	return src, nil
+}
+
+const clientPreamble = `// Client calls the endpoints of the %s with typed requests
+type Client struct {
+	Client *rest.Client
+	Api    *%s.%s
+}
+
+func NewClient(client *rest.Client, api *%s.%s) *Client {
+	return &Client{Client: client, Api: api}
+}
+
+// call sends the request and decodes a reply that succeeded into v, when v isn't nil
+func (c *Client) call(ctx *rest.RequestContext, r *rest.ClientRequest, v interface{}) error {
+	if ctx == nil {
+		ctx = rest.NewRequestContext()
+	}
+	_, err := c.Client.Fetch(r, ctx, v)
+	return err
+}
+
+`
+
+// byValue is true of the types passed as they are, slices, maps and pointers, others are
+// passed as pointers
+func byValue(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.ArrayType, *ast.MapType, *ast.StarExpr:
+		return true
+	}
+	return false
+}
+
+func writeClientMethod(out *bytes.Buffer, pkg, name, resource, verb string, op *SourceOperation) {
+	params := []string{"ctx *rest.RequestContext"}
+	args, body := "nil", "nil"
+	var guard bytes.Buffer
+
+	if op.Args != nil {
+		t := exprString(qualify(op.Args, pkg))
+		params = append(params, "args *"+t)
+		args = "args"
+		fmt.Fprintf(&guard, "\tif args == nil {\n\t\targs = new(%s)\n\t}\n", t)
+	}
+	if op.Request != nil {
+		t := exprString(qualify(op.Request, pkg))
+		if !byValue(op.Request) {
+			t = "*" + t
+		}
+		params = append(params, "body "+t)
+		body = "body"
+	}
+
+	request := fmt.Sprintf("c.Api.%s.%s(%s)", resource, verb, args)
+	switch verb {
+	case "Post", "Put", "Patch":
+		request = fmt.Sprintf("c.Api.%s.%s(%s, %s)", resource, verb, args, body)
+	}
+
+	fmt.Fprintf(out, "// %s sends %s to the %s resource\n", name, op.Verb, resource)
+	if op.Response == nil || op.Verb == "HEAD" {
+		fmt.Fprintf(out, "func (c *Client) %s(%s) error {\n", name, strings.Join(params, ", "))
+		out.Write(guard.Bytes())
+		fmt.Fprintf(out, "\treturn c.call(ctx, %s, nil)\n}\n\n", request)
+		return
+	}
+
+	t := exprString(qualify(op.Response, pkg))
+	if byValue(op.Response) {
+		fmt.Fprintf(out, "func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(params, ", "), t)
+		out.Write(guard.Bytes())
+		fmt.Fprintf(out, "\tvar result %s\n", t)
+		fmt.Fprintf(out, "\tif err := c.call(ctx, %s, &result); err != nil {\n\t\treturn nil, err\n\t}\n", request)
+	} else {
+		fmt.Fprintf(out, "func (c *Client) %s(%s) (*%s, error) {\n", name, strings.Join(params, ", "), t)
+		out.Write(guard.Bytes())
+		fmt.Fprintf(out, "\tresult := new(%s)\n", t)
+		fmt.Fprintf(out, "\tif err := c.call(ctx, %s, result); err != nil {\n\t\treturn nil, err\n\t}\n", request)
+	}
+	out.WriteString("\treturn result, nil\n}\n\n")
+}
@@ -0,0 +1,72 @@
+package generating_test
+
+import (
+	"go/parser"
+	"go/token"
+
+	. "github.com/gotgo/gokn/generating"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GenerateClient", func() {
+	var api *SourceApi
+
+	BeforeEach(func() {
+		var err error
+		api, err = ReadSourceApi("testdata/shop", "ShopApi")
+		Expect(err).To(BeNil())
+		api.ImportPath = "example.com/shop"
+	})
+
+	generate := func(options *ClientOptions) string {
+		src, err := GenerateClient(api, options)
+		Expect(err).To(BeNil())
+		_, err = parser.ParseFile(token.NewFileSet(), "client.go", src, 0)
+		Expect(err).To(BeNil())
+		return string(src)
+	}
+
+	It("should name the package after the spec's", func() {
+		src := generate(&ClientOptions{})
+		Expect(src).To(ContainSubstring("package shopclient\n"))
+		Expect(src).To(ContainSubstring("\t\"example.com/shop\"\n\t\"github.com/gotgo/gokn/rest\"\n"))
+		Expect(generate(&ClientOptions{Package: "carts"})).To(ContainSubstring("package carts\n"))
+	})
+
+	It("should take the args and return the response", func() {
+		src := generate(&ClientOptions{})
+		Expect(src).To(ContainSubstring(`func (c *Client) GetCart(ctx *rest.RequestContext, args *shop.CartArgs) (*shop.Cart, error) {
+	if args == nil {
+		args = new(shop.CartArgs)
+	}
+	result := new(shop.Cart)
+	if err := c.call(ctx, c.Api.Cart.Get(args), result); err != nil {`))
+	})
+
+	It("should send the body, a pointer unless a slice, map or pointer", func() {
+		src := generate(&ClientOptions{})
+		Expect(src).To(ContainSubstring("func (c *Client) PutCart(ctx *rest.RequestContext, args *shop.CartArgs, body *shop.Cart) error {"))
+		Expect(src).To(ContainSubstring("return c.call(ctx, c.Api.Cart.Put(args, body), nil)"))
+		Expect(src).To(ContainSubstring("func (c *Client) PostCarts(ctx *rest.RequestContext, body []*shop.Cart) error {"))
+		Expect(src).To(ContainSubstring("c.Api.Carts.Post(nil, body)"))
+	})
+
+	It("should decode through the rest.Client, with its codecs", func() {
+		src := generate(&ClientOptions{})
+		Expect(src).To(ContainSubstring("_, err := c.Client.Fetch(r, ctx, v)"))
+		Expect(src).ToNot(ContainSubstring("encoding/json"))
+	})
+
+	It("should only return an error of HEAD", func() {
+		src := generate(&ClientOptions{})
+		Expect(src).To(ContainSubstring("func (c *Client) HeadHealth(ctx *rest.RequestContext) error {"))
+	})
+
+	It("should need the import path of the spec", func() {
+		api.ImportPath = ""
+		_, err := GenerateClient(api, &ClientOptions{})
+		Expect(err).ToNot(BeNil())
+	})
+})
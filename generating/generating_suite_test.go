@@ -0,0 +1,13 @@
+package generating_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenerating(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Generating Suite")
+}
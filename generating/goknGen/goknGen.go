@@ -0,0 +1,141 @@
+// goknGen generates gokn code from an OpenAPI document
+//
+//	goknGen spec -package partner -o partner/api.go partner.yaml
+//	goknGen handlers -api Api -o partner/handlers partner
+//	goknGen client -api Api -o partner/client/client.go partner
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gotgo/gokn/generating"
+)
+
+var commands = map[string]func(args []string){
+	"spec":     spec,
+	"handlers": handlers,
+	"client":   client,
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		fmt.Fprintln(os.Stderr, "usage: goknGen spec [flags] openapi.yaml")
+		fmt.Fprintln(os.Stderr, "       goknGen handlers [flags] specPackageDir")
+		fmt.Fprintln(os.Stderr, "       goknGen client [flags] specPackageDir")
+		os.Exit(2)
+	}
+	commands[os.Args[1]](os.Args[2:])
+}
+
+// spec generates the types, ResourceDefs and spec struct of a document
+func spec(args []string) {
+	flags := flag.NewFlagSet("spec", flag.ExitOnError)
+	pkg := flags.String("package", "api", "package of the generated file")
+	api := flags.String("api", "Api", "name of the spec struct")
+	out := flags.String("o", "", "file to write, stdout when empty")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: goknGen spec [flags] openapi.yaml")
+		flags.PrintDefaults()
+		os.Exit(2)
+	}
+
+	doc, err := generating.LoadOpenApi(flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := generating.GenerateSpec(doc, &generating.SpecOptions{Package: *pkg, Api: *api})
+	if err != nil {
+		log.Fatal(err)
+	}
+	write(*out, src)
+}
+
+// handlers scaffolds a handler per resource of a spec struct, keeping the handlers already
+// written
+func handlers(args []string) {
+	flags := flag.NewFlagSet("handlers", flag.ExitOnError)
+	api := flags.String("api", "Api", "name of the spec struct")
+	pkg := flags.String("package", "handlers", "package of the handlers")
+	out := flags.String("o", "handlers", "directory of the handlers")
+	importPath := flags.String("import", "", "import path of the spec's package, from go.mod when empty")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: goknGen handlers [flags] specPackageDir")
+		flags.PrintDefaults()
+		os.Exit(2)
+	}
+
+	source, err := generating.ReadSourceApi(flags.Arg(0), *api)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *importPath != "" {
+		source.ImportPath = *importPath
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		log.Fatal(err)
+	}
+	files, err := generating.GenerateHandlers(source, &generating.HandlerOptions{Package: *pkg, Dir: *out})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		write(filepath.Join(*out, name), files[name])
+		log.Printf("wrote %s", filepath.Join(*out, name))
+	}
+}
+
+// client generates a typed client of a spec struct
+func client(args []string) {
+	flags := flag.NewFlagSet("client", flag.ExitOnError)
+	api := flags.String("api", "Api", "name of the spec struct")
+	pkg := flags.String("package", "", "package of the client, the spec's package then client when empty")
+	out := flags.String("o", "", "file to write, stdout when empty")
+	importPath := flags.String("import", "", "import path of the spec's package, from go.mod when empty")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: goknGen client [flags] specPackageDir")
+		flags.PrintDefaults()
+		os.Exit(2)
+	}
+
+	source, err := generating.ReadSourceApi(flags.Arg(0), *api)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *importPath != "" {
+		source.ImportPath = *importPath
+	}
+	src, err := generating.GenerateClient(source, &generating.ClientOptions{Package: *pkg})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *out != "" {
+		if err := os.MkdirAll(filepath.Dir(*out), 0755); err != nil {
+			log.Fatal(err)
+		}
+	}
+	write(*out, src)
+}
+
+func write(path string, src []byte) {
+	if path == "" {
+		os.Stdout.Write(src)
+	} else if err := ioutil.WriteFile(path, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
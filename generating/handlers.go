@@ -0,0 +1,351 @@
+package generating
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// HandlerOptions configure GenerateHandlers
+type HandlerOptions struct {
+	// Package of the handlers, handlers when empty
+	Package string
+	// Dir has the handlers generated before, their files are added to rather than replaced
+	Dir string
+}
+
+const (
+	restImport = "github.com/gotgo/gokn/rest"
+	httpImport = "net/http"
+)
+
+// GenerateHandlers scaffolds a handler type per resource of the spec, with a method per
+// verb that reads the typed Args and Body and replies 501 Not Implemented, and a _test.go
+// skeleton of pending specs.  Files already in the Dir keep their code, the missing types
+// and methods are appended.  It returns the new and changed files by name.
+//
+//	Example:
+//
+//		api, err := generating.ReadSourceApi("orders", "Api")
+//		files, err := generating.GenerateHandlers(api, &generating.HandlerOptions{Dir: "orders/handlers"})
+func GenerateHandlers(api *SourceApi, options *HandlerOptions) (map[string][]byte, error) {
+	pkg := options.Package
+	if pkg == "" {
+		pkg = "handlers"
+	}
+	h := &handlerGenerator{api: api, pkg: pkg, dir: options.Dir}
+	if api.ImportPath == "" {
+		return nil, fmt.Errorf("the import path of package %s is unknown", api.Package)
+	}
+
+	files := make(map[string][]byte)
+	for _, resource := range api.Resources {
+		name := lowerFirst(resource.Name) + "Handler"
+		src, err := h.handler(name+".go", resource)
+		if err != nil {
+			return nil, err
+		}
+		if src != nil {
+			files[name+".go"] = src
+		}
+		if !h.exists(name + "_test.go") {
+			if files[name+"_test.go"], err = h.test(resource); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if suite := pkg + "_suite_test.go"; !h.exists(suite) && len(api.Resources) > 0 {
+		files[suite] = h.suite()
+	}
+	return files, nil
+}
+
+type handlerGenerator struct {
+	api *SourceApi
+	pkg string
+	dir string
+}
+
+func (h *handlerGenerator) exists(name string) bool {
+	if h.dir == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(h.dir, name))
+	return err == nil
+}
+
+// verbMethod is the handler method of a verb, GET is Get
+func verbMethod(verb string) string {
+	verb = strings.ToLower(verb)
+	if verb == "" {
+		return ""
+	}
+	return string(unicode.ToUpper(rune(verb[0]))) + verb[1:]
+}
+
+func handlerType(resource *SourceResource) string {
+	return resource.Name + "Handler"
+}
+
+// handler is the source of the handler file, or nil when nothing is missing from it
+func (h *handlerGenerator) handler(file string, resource *SourceResource) ([]byte, error) {
+	typeName := handlerType(resource)
+	hasType, methods, err := declared(h.dir, typeName)
+	if err != nil {
+		return nil, err
+	}
+	var existing []byte
+	if h.exists(file) {
+		if existing, err = ioutil.ReadFile(filepath.Join(h.dir, file)); err != nil {
+			return nil, err
+		}
+	}
+
+	var code bytes.Buffer
+	if !hasType {
+		fmt.Fprintf(&code, "// %s handles the %s resource of the %s\n", typeName, resource.Name, h.api.Name)
+		fmt.Fprintf(&code, "type %s struct {\n}\n\n", typeName)
+	}
+	usesSpec := false
+	for _, op := range resource.Operations {
+		method := verbMethod(op.Verb)
+		if method == "" || methods[method] {
+			continue
+		}
+		methods[method] = true
+		h.method(&code, typeName, method, op)
+		usesSpec = usesSpec || op.Args != nil || op.Request != nil || op.Response != nil && op.Verb != "HEAD"
+	}
+	if code.Len() == 0 {
+		return nil, nil
+	}
+
+	imports := []string{httpImport, restImport}
+	if usesSpec {
+		imports = append(imports, h.api.ImportPath)
+	}
+	var src []byte
+	if existing == nil {
+		src = []byte(fmt.Sprintf("package %s\n\n%s", h.pkg, importBlock(imports)))
+	} else {
+		src = withImports(existing, imports...)
+		src = append(bytes.TrimRight(src, "\n"), '\n', '\n')
+	}
+	src = append(src, code.Bytes()...)
+	formatted, err := format.Source(src)
+	if err != nil {
+		return src, fmt.Errorf("%s: %s", file, err)
+	}
+	return formatted, nil
+}
+
+func (h *handlerGenerator) method(code *bytes.Buffer, typeName, method string, op *SourceOperation) {
+	fmt.Fprintf(code, "func (h *%s) %s(req *rest.Request, resp rest.Responder) {\n", typeName, method)
+	used := []string{}
+	if op.Args != nil {
+		fmt.Fprintf(code, "\targs, _ := req.Args.(*%s)\n", h.qualified(op.Args))
+		used = append(used, "args")
+	}
+	if op.Request != nil {
+		fmt.Fprintf(code, "\tbody, _ := req.Body.(*%s)\n", h.qualified(op.Request))
+		used = append(used, "body")
+	}
+	if op.Response != nil && op.Verb != "HEAD" {
+		fmt.Fprintf(code, "\t// TODO: resp.SetBody(&%s{})\n", h.qualified(op.Response))
+	} else {
+		code.WriteString("\t// TODO\n")
+	}
+	if len(used) > 0 {
+		fmt.Fprintf(code, "\t%s = %s\n", strings.TrimSuffix(strings.Repeat("_, ", len(used)), ", "), strings.Join(used, ", "))
+	}
+	code.WriteString("\tresp.SetStatus(http.StatusNotImplemented, \"Not Implemented\", nil)\n}\n\n")
+}
+
+// qualified prints a type of the spec's package from the handlers' package
+func (h *handlerGenerator) qualified(expr ast.Expr) string {
+	return exprString(qualify(expr, h.api.Package))
+}
+
+// qualify prefixes the exported identifiers of a type expression with the package
+func qualify(expr ast.Expr, pkg string) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if ast.IsExported(e.Name) {
+			return &ast.SelectorExpr{X: ast.NewIdent(pkg), Sel: ast.NewIdent(e.Name)}
+		}
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: qualify(e.X, pkg)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: e.Len, Elt: qualify(e.Elt, pkg)}
+	case *ast.MapType:
+		return &ast.MapType{Key: qualify(e.Key, pkg), Value: qualify(e.Value, pkg)}
+	}
+	return expr
+}
+
+// declared reports whether a file of the dir declares the type, and which of its methods,
+// the tests aside
+func declared(dir, typeName string) (bool, map[string]bool, error) {
+	hasType, methods := false, make(map[string]bool)
+	if dir == "" {
+		return hasType, methods, nil
+	}
+	notTest := func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, notTest, 0)
+	if os.IsNotExist(err) {
+		return hasType, methods, nil
+	} else if err != nil {
+		return false, nil, err
+	}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			declaredIn(file, typeName, &hasType, methods)
+		}
+	}
+	return hasType, methods, nil
+}
+
+func declaredIn(file *ast.File, typeName string, hasType *bool, methods map[string]bool) {
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == typeName {
+					*hasType = true
+				}
+			}
+		case *ast.FuncDecl:
+			if d.Recv == nil || len(d.Recv.List) == 0 {
+				continue
+			}
+			recv := d.Recv.List[0].Type
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+			if ident, ok := recv.(*ast.Ident); ok && ident.Name == typeName {
+				methods[d.Name.Name] = true
+			}
+		}
+	}
+}
+
+// withImports adds the missing imports to the source of a file
+func withImports(src []byte, paths ...string) []byte {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly)
+	if err != nil {
+		return src
+	}
+	imported := make(map[string]bool)
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		imported[path] = true
+	}
+
+	var missing bytes.Buffer
+	for _, path := range paths {
+		if !imported[path] {
+			fmt.Fprintf(&missing, "\t%q\n", path)
+		}
+	}
+	if missing.Len() == 0 {
+		return src
+	}
+
+	text := string(src)
+	if i := strings.Index(text, "\nimport (\n"); i >= 0 {
+		at := i + len("\nimport (\n")
+		return []byte(text[:at] + missing.String() + text[at:])
+	}
+	// after the package clause
+	at := strings.Index(text[file.Name.End()-1:], "\n") + int(file.Name.End()-1)
+	return []byte(text[:at] + "\n\nimport (\n" + missing.String() + ")\n" + text[at:])
+}
+
+// test is the _test.go skeleton of a handler, a pending spec per method
+func (h *handlerGenerator) test(resource *SourceResource) ([]byte, error) {
+	typeName := handlerType(resource)
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "package %s_test\n\nimport (\n", h.pkg)
+	fmt.Fprintf(&src, "\t%q\n\n\t%q\n\t%q\n\t. %q\n\n", httpImport, restImport, h.api.ImportPath, h.handlersImport())
+	src.WriteString("\t. \"github.com/onsi/ginkgo\"\n\t. \"github.com/onsi/gomega\"\n)\n\n")
+
+	fmt.Fprintf(&src, "var _ = Describe(%q, func() {\n", typeName)
+	fmt.Fprintf(&src, "\tvar handler *%s\n\n", typeName)
+	fmt.Fprintf(&src, "\tBeforeEach(func() {\n\t\thandler = new(%s)\n\t})\n", typeName)
+
+	usesSpec := false
+	seen := make(map[string]bool)
+	for _, op := range resource.Operations {
+		method := verbMethod(op.Verb)
+		if method == "" || seen[method] {
+			continue
+		}
+		seen[method] = true
+
+		body := ""
+		if op.Request != nil {
+			body = fmt.Sprintf(", Body: &%s{}", h.qualified(op.Request))
+			usesSpec = true
+		}
+		fmt.Fprintf(&src, "\n\tPIt(\"should %s\", func() {\n", strings.ToLower(method))
+		fmt.Fprintf(&src, "\t\treq, resp := rest.LocalRequest(&rest.ClientRequest{Verb: %q, Resource: \"/\"%s})\n", op.Verb, body)
+		if op.Args != nil {
+			fmt.Fprintf(&src, "\t\treq.Args = &%s{}\n", h.qualified(op.Args))
+			usesSpec = true
+		}
+		fmt.Fprintf(&src, "\t\thandler.%s(req, resp)\n", method)
+		src.WriteString("\t\tExpect(resp.Status).To(Equal(http.StatusOK))\n\t})\n")
+	}
+	src.WriteString("})\n")
+
+	out := src.Bytes()
+	if !usesSpec {
+		out = bytes.Replace(out, []byte(fmt.Sprintf("\t%q\n", h.api.ImportPath)), nil, 1)
+	}
+	formatted, err := format.Source(out)
+	if err != nil {
+		return out, fmt.Errorf("%s test: %s", typeName, err)
+	}
+	return formatted, nil
+}
+
+// handlersImport is the import path of the handlers, beside the spec's package when the
+// Dir isn't in a module
+func (h *handlerGenerator) handlersImport() string {
+	if h.dir != "" {
+		if path, err := ImportPath(h.dir); err == nil {
+			return path
+		}
+	}
+	return h.api.ImportPath + "/" + h.pkg
+}
+
+func (h *handlerGenerator) suite() []byte {
+	title := strings.ToUpper(h.pkg[:1]) + h.pkg[1:]
+	return []byte(fmt.Sprintf(`package %s_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func Test%s(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "%s Suite")
+}
+`, h.pkg, GoName(title), title))
+}
@@ -0,0 +1,122 @@
+package generating_test
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/gotgo/gokn/generating"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GenerateHandlers", func() {
+	var (
+		api *SourceApi
+		dir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		api, err = ReadSourceApi("testdata/shop", "ShopApi")
+		Expect(err).To(BeNil())
+		api.ImportPath = "example.com/shop"
+		dir, err = ioutil.TempDir("", "handlers")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	generate := func() map[string][]byte {
+		files, err := GenerateHandlers(api, &HandlerOptions{Dir: dir})
+		Expect(err).To(BeNil())
+		for name, src := range files {
+			_, err := parser.ParseFile(token.NewFileSet(), name, src, 0)
+			Expect(err).To(BeNil())
+			Expect(ioutil.WriteFile(filepath.Join(dir, name), src, 0644)).To(Succeed())
+		}
+		return files
+	}
+
+	It("should scaffold a handler, a test per resource and a suite", func() {
+		files := generate()
+		Expect(files).To(HaveLen(7))
+		Expect(files).To(HaveKey("cartHandler.go"))
+		Expect(files).To(HaveKey("cartHandler_test.go"))
+		Expect(files).To(HaveKey("handlers_suite_test.go"))
+
+		cart := string(files["cartHandler.go"])
+		Expect(cart).To(HavePrefix("package handlers\n\nimport (\n\t\"net/http\"\n\n\t\"example.com/shop\"\n\t\"github.com/gotgo/gokn/rest\"\n)\n"))
+		Expect(cart).To(ContainSubstring("type CartHandler struct {\n}\n"))
+		Expect(cart).To(ContainSubstring(`func (h *CartHandler) Get(req *rest.Request, resp rest.Responder) {
+	args, _ := req.Args.(*shop.CartArgs)
+	// TODO: resp.SetBody(&shop.Cart{})
+	_ = args
+	resp.SetStatus(http.StatusNotImplemented, "Not Implemented", nil)
+}
+`))
+		Expect(cart).To(ContainSubstring("\tbody, _ := req.Body.(**shop.Cart)\n\t// TODO\n\t_, _ = args, body\n"))
+		Expect(string(files["cartsHandler.go"])).To(ContainSubstring("body, _ := req.Body.(*[]*shop.Cart)"))
+
+		health := string(files["healthHandler.go"])
+		Expect(health).ToNot(ContainSubstring("example.com/shop"))
+		Expect(health).To(ContainSubstring("func (h *HealthHandler) Head(req *rest.Request, resp rest.Responder) {\n\t// TODO\n"))
+
+		test := string(files["cartHandler_test.go"])
+		Expect(test).To(ContainSubstring("\t. \"example.com/shop/handlers\"\n"))
+		Expect(test).To(ContainSubstring("\tPIt(\"should put\", func() {\n"))
+		Expect(test).To(ContainSubstring("\t\treq.Args = &shop.CartArgs{}\n"))
+	})
+
+	It("should keep the handlers written and add the missing methods", func() {
+		generate()
+		path := filepath.Join(dir, "cartHandler.go")
+		src, err := ioutil.ReadFile(path)
+		Expect(err).To(BeNil())
+		// hand written Get, and no Put yet
+		written := strings.Replace(string(src), "// TODO: resp.SetBody(&shop.Cart{})", "resp.SetBody(&shop.Cart{Items: []string{\"kept\"}})", 1)
+		written = written[:strings.Index(written, "func (h *CartHandler) Put")]
+		Expect(ioutil.WriteFile(path, []byte(written), 0644)).To(Succeed())
+
+		files := generate()
+		Expect(files).To(HaveLen(1))
+		cart := string(files["cartHandler.go"])
+		Expect(cart).To(ContainSubstring("resp.SetBody(&shop.Cart{Items: []string{\"kept\"}})"))
+		Expect(strings.Count(cart, "func (h *CartHandler) Get")).To(Equal(1))
+		Expect(cart).To(ContainSubstring("func (h *CartHandler) Put"))
+
+		Expect(generate()).To(BeEmpty())
+	})
+
+	It("should find the types and methods declared in the other files of the package", func() {
+		written := "package handlers\n\nimport \"github.com/gotgo/gokn/rest\"\n\ntype HealthHandler struct{}\n\n" +
+			"func (h *HealthHandler) Head(req *rest.Request, resp rest.Responder) {}\n\n" +
+			"type CartHandler struct{}\n\nfunc (h *CartHandler) Get(req *rest.Request, resp rest.Responder) {}\n"
+		Expect(ioutil.WriteFile(filepath.Join(dir, "all.go"), []byte(written), 0644)).To(Succeed())
+		//tests aren't part of the package's declarations
+		Expect(ioutil.WriteFile(filepath.Join(dir, "helpers_test.go"), []byte("package handlers\n\ntype CartsHandler struct{}\n"), 0644)).To(Succeed())
+
+		files := generate()
+		Expect(files).ToNot(HaveKey("healthHandler.go"))
+		cart := string(files["cartHandler.go"])
+		Expect(cart).ToNot(ContainSubstring("type CartHandler struct"))
+		Expect(cart).ToNot(ContainSubstring("func (h *CartHandler) Get"))
+		Expect(cart).To(ContainSubstring("func (h *CartHandler) Put"))
+		Expect(string(files["cartsHandler.go"])).To(ContainSubstring("type CartsHandler struct"))
+	})
+
+	It("should add the imports a file is missing", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "healthHandler.go"), []byte("package handlers\n\n// HealthHandler is hand written\ntype HealthHandler struct{}\n"), 0644)).To(Succeed())
+		files := generate()
+		health := string(files["healthHandler.go"])
+		Expect(health).To(ContainSubstring("// HealthHandler is hand written\ntype HealthHandler struct{}\n"))
+		Expect(health).To(ContainSubstring("\t\"github.com/gotgo/gokn/rest\"\n"))
+		Expect(health).To(ContainSubstring("func (h *HealthHandler) Head("))
+	})
+})
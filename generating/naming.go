@@ -0,0 +1,70 @@
+package generating
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GoName converts a name from a document to an exported go name, order_id and orderId
+// are both OrderId
+func GoName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	goName := b.String()
+	if goName == "" || unicode.IsDigit(rune(goName[0])) {
+		goName = "X" + goName
+	}
+	return goName
+}
+
+// lowerFirst makes an exported name unexported
+func lowerFirst(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// ResourceName names the resource of a path, /orders/{id}/lines is OrdersByIdLines
+func ResourceName(path string) string {
+	var b strings.Builder
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			variable := strings.SplitN(segment[1:len(segment)-1], ":", 2)[0]
+			b.WriteString("By" + GoName(variable))
+		} else {
+			b.WriteString(GoName(segment))
+		}
+	}
+	if b.Len() == 0 {
+		return "Root"
+	}
+	return b.String()
+}
+
+// names hands out unique go names
+type names map[string]bool
+
+func (n names) unique(name string) string {
+	candidate := name
+	for i := 2; n[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+	n[candidate] = true
+	return candidate
+}
@@ -0,0 +1,25 @@
+package generating_test
+
+import (
+	. "github.com/gotgo/gokn/generating"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Naming", func() {
+
+	It("should make exported go names", func() {
+		Expect(GoName("order_id")).To(Equal("OrderId"))
+		Expect(GoName("orderId")).To(Equal("OrderId"))
+		Expect(GoName("X-Request-ID")).To(Equal("XRequestID"))
+		Expect(GoName("2fa")).To(Equal("X2fa"))
+		Expect(GoName("")).To(Equal("X"))
+	})
+
+	It("should name resources by path", func() {
+		Expect(ResourceName("/orders/{id}/lines")).To(Equal("OrdersByIdLines"))
+		Expect(ResourceName("/orders/{id:[0-9]+}")).To(Equal("OrdersById"))
+		Expect(ResourceName("/")).To(Equal("Root"))
+	})
+})
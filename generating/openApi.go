@@ -0,0 +1,243 @@
+package generating
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenApi is the part of an OpenAPI 3.0 or 3.1 document, json or yaml, the generators read
+type OpenApi struct {
+	OpenApi    string               `yaml:"openapi"`
+	Info       Info                 `yaml:"info"`
+	Paths      map[string]*PathItem `yaml:"paths"`
+	Components Components           `yaml:"components"`
+}
+
+type Info struct {
+	Title       string `yaml:"title"`
+	Version     string `yaml:"version"`
+	Description string `yaml:"description"`
+}
+
+type Components struct {
+	Schemas       map[string]*Schema      `yaml:"schemas"`
+	Parameters    map[string]*Parameter   `yaml:"parameters"`
+	RequestBodies map[string]*RequestBody `yaml:"requestBodies"`
+	Responses     map[string]*Response    `yaml:"responses"`
+}
+
+type PathItem struct {
+	Parameters []*Parameter `yaml:"parameters"`
+	Get        *Operation   `yaml:"get"`
+	Put        *Operation   `yaml:"put"`
+	Post       *Operation   `yaml:"post"`
+	Delete     *Operation   `yaml:"delete"`
+	Patch      *Operation   `yaml:"patch"`
+	Head       *Operation   `yaml:"head"`
+}
+
+// Operations by verb, in the order they are generated
+func (pi *PathItem) Operations() []*VerbOperation {
+	ops := []*VerbOperation{}
+	for _, op := range []*VerbOperation{
+		{"GET", pi.Get}, {"POST", pi.Post}, {"PUT", pi.Put},
+		{"PATCH", pi.Patch}, {"DELETE", pi.Delete}, {"HEAD", pi.Head},
+	} {
+		if op.Operation != nil {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+type VerbOperation struct {
+	Verb string
+	*Operation
+}
+
+type Operation struct {
+	OperationId string               `yaml:"operationId"`
+	Summary     string               `yaml:"summary"`
+	Description string               `yaml:"description"`
+	Parameters  []*Parameter         `yaml:"parameters"`
+	RequestBody *RequestBody         `yaml:"requestBody"`
+	Responses   map[string]*Response `yaml:"responses"`
+}
+
+type Parameter struct {
+	Ref         string  `yaml:"$ref"`
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"`
+	Required    bool    `yaml:"required"`
+	Description string  `yaml:"description"`
+	Schema      *Schema `yaml:"schema"`
+}
+
+type RequestBody struct {
+	Ref      string                `yaml:"$ref"`
+	Required bool                  `yaml:"required"`
+	Content  map[string]*MediaType `yaml:"content"`
+}
+
+type Response struct {
+	Ref         string                `yaml:"$ref"`
+	Description string                `yaml:"description"`
+	Content     map[string]*MediaType `yaml:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+// Schema is a json schema.  Type is a list because 3.1 allows ["string", "null"]
+type Schema struct {
+	Ref                  string             `yaml:"$ref"`
+	Type                 SchemaType         `yaml:"type"`
+	Format               string             `yaml:"format"`
+	Description          string             `yaml:"description"`
+	Properties           map[string]*Schema `yaml:"properties"`
+	Required             []string           `yaml:"required"`
+	Items                *Schema            `yaml:"items"`
+	AdditionalProperties *Schema            `yaml:"additionalProperties"`
+	AllOf                []*Schema          `yaml:"allOf"`
+	OneOf                []*Schema          `yaml:"oneOf"`
+	AnyOf                []*Schema          `yaml:"anyOf"`
+	Enum                 []interface{}      `yaml:"enum"`
+	Example              interface{}        `yaml:"example"`
+	Examples             []interface{}      `yaml:"examples"`
+	WriteOnly            bool               `yaml:"writeOnly"`
+	// PropertyOrder is the order of the Properties in the document
+	PropertyOrder []string `yaml:"-"`
+}
+
+// UnmarshalYAML keeps the order of the properties, and reads additionalProperties: true
+// as a schema of any value
+func (s *Schema) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*s = Schema{}
+		return nil
+	}
+	type plain Schema
+	if err := value.Decode((*plain)(s)); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		if value.Content[i].Value == "properties" {
+			properties := value.Content[i+1]
+			for j := 0; j+1 < len(properties.Content); j += 2 {
+				s.PropertyOrder = append(s.PropertyOrder, properties.Content[j].Value)
+			}
+		}
+	}
+	return nil
+}
+
+// SchemaType is the type of a schema, a single type or a list of them
+type SchemaType []string
+
+func (st *SchemaType) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*st = SchemaType{value.Value}
+		return nil
+	}
+	var types []string
+	if err := value.Decode(&types); err != nil {
+		return err
+	}
+	*st = types
+	return nil
+}
+
+// Is reports whether the type, ignoring null, is t
+func (st SchemaType) Is(t string) bool {
+	for _, v := range st {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Name is the first type that isn't null
+func (st SchemaType) Name() string {
+	for _, v := range st {
+		if v != "null" {
+			return v
+		}
+	}
+	return ""
+}
+
+// ReadOpenApi reads an OpenAPI document, json or yaml
+func ReadOpenApi(reader io.Reader) (*OpenApi, error) {
+	bts, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	doc := new(OpenApi)
+	if err := yaml.Unmarshal(bts, doc); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(doc.OpenApi, "3.") {
+		return nil, fmt.Errorf("openapi version %q is not supported, 3.x is", doc.OpenApi)
+	}
+	return doc, nil
+}
+
+// LoadOpenApi reads the OpenAPI document of a file
+func LoadOpenApi(path string) (*OpenApi, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	doc, err := ReadOpenApi(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return doc, nil
+}
+
+// refName is the name a local $ref points to, #/components/schemas/Order is Order
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+func (doc *OpenApi) parameter(p *Parameter) *Parameter {
+	if p.Ref != "" {
+		if resolved := doc.Components.Parameters[refName(p.Ref)]; resolved != nil {
+			return resolved
+		}
+	}
+	return p
+}
+
+func (doc *OpenApi) requestBody(rb *RequestBody) *RequestBody {
+	if rb != nil && rb.Ref != "" {
+		if resolved := doc.Components.RequestBodies[refName(rb.Ref)]; resolved != nil {
+			return resolved
+		}
+	}
+	return rb
+}
+
+func (doc *OpenApi) response(r *Response) *Response {
+	if r != nil && r.Ref != "" {
+		if resolved := doc.Components.Responses[refName(r.Ref)]; resolved != nil {
+			return resolved
+		}
+	}
+	return r
+}
+
+func (doc *OpenApi) schema(s *Schema) *Schema {
+	for i := 0; s != nil && s.Ref != "" && i < 32; i++ {
+		s = doc.Components.Schemas[refName(s.Ref)]
+	}
+	return s
+}
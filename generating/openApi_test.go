@@ -0,0 +1,44 @@
+package generating_test
+
+import (
+	"strings"
+
+	. "github.com/gotgo/gokn/generating"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OpenApi", func() {
+
+	It("should load a yaml document", func() {
+		doc, err := LoadOpenApi("testdata/orders.yaml")
+		Expect(err).To(BeNil())
+		Expect(doc.Info.Title).To(Equal("Orders"))
+		Expect(doc.Paths).To(HaveLen(2))
+		Expect(doc.Paths["/orders/{order_id}"].Operations()).To(HaveLen(2))
+		Expect(doc.Paths["/orders/{order_id}"].Operations()[0].Verb).To(Equal("GET"))
+	})
+
+	It("should keep the order of the properties", func() {
+		doc, err := LoadOpenApi("testdata/orders.yaml")
+		Expect(err).To(BeNil())
+		order := doc.Components.Schemas["Order"].AllOf[1]
+		Expect(order.PropertyOrder).To(Equal([]string{"id", "status", "total", "card", "lines", "labels", "parent"}))
+	})
+
+	It("should read json, type lists and additionalProperties: true", func() {
+		doc, err := ReadOpenApi(strings.NewReader(`{"openapi": "3.1.0", "info": {"title": "x"},
+			"components": {"schemas": {"Any": {"type": ["object", "null"], "additionalProperties": true}}}}`))
+		Expect(err).To(BeNil())
+		schema := doc.Components.Schemas["Any"]
+		Expect(schema.Type.Name()).To(Equal("object"))
+		Expect(schema.Type.Is("null")).To(BeTrue())
+		Expect(schema.AdditionalProperties).ToNot(BeNil())
+	})
+
+	It("should reject swagger 2 documents", func() {
+		_, err := ReadOpenApi(strings.NewReader(`swagger: "2.0"`))
+		Expect(err).ToNot(BeNil())
+	})
+})
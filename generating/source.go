@@ -0,0 +1,313 @@
+package generating
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SourceApi is an api spec struct as read from go source, a command can't load the types
+// of another package to reflect on them
+type SourceApi struct {
+	// Name of the spec struct
+	Name string
+	// Package name and ImportPath of the spec's package
+	Package    string
+	ImportPath string
+	Resources  []*SourceResource
+}
+
+// SourceResource is a *rest.ResourceSpec field of the spec struct
+type SourceResource struct {
+	Name       string
+	Operations []*SourceOperation
+}
+
+// SourceOperation is a ResourceDef Used by a resource.  The types are go type expressions
+// in the spec's package, nil when the ResourceDef has none.
+type SourceOperation struct {
+	Verb     string
+	Def      string
+	Args     ast.Expr
+	Request  ast.Expr
+	Response ast.Expr
+}
+
+// ReadSourceApi reads the spec struct named api from the go package in dir.  The resources
+// are the struct's *rest.ResourceSpec fields, and their operations the ResourceDefs the
+// struct's composite literal Uses, either package vars or inline.
+//
+//	Example:
+//
+//		var Api = &ApiSpec{
+//			Order: rest.NewResourceSpec(rest.ContentTypeJson).
+//				Use(GetOrderDef).
+//				Use(&rest.ResourceDef{ResourceT: "/orders/{id}", Verb: "DELETE"}),
+//		}
+func ReadSourceApi(dir, api string) (*SourceApi, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, pkg := range pkgs {
+		source := &SourceApi{Name: api, Package: name}
+		defs := make(map[string]*ast.CompositeLit)
+		var spec *ast.StructType
+		var literal *ast.CompositeLit
+
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.TypeSpec:
+					if st, ok := n.Type.(*ast.StructType); ok && n.Name.Name == api {
+						spec = st
+					}
+				case *ast.ValueSpec:
+					for i, value := range n.Values {
+						if def := resourceDef(value); def != nil && i < len(n.Names) {
+							defs[n.Names[i].Name] = def
+						}
+					}
+				case *ast.AssignStmt:
+					for i, value := range n.Rhs {
+						if def := resourceDef(value); def != nil && i < len(n.Lhs) {
+							if ident, ok := n.Lhs[i].(*ast.Ident); ok {
+								defs[ident.Name] = def
+							}
+						}
+					}
+				case *ast.CompositeLit:
+					if ident, ok := n.Type.(*ast.Ident); ok && ident.Name == api && literal == nil {
+						literal = n
+					}
+				}
+				return true
+			})
+		}
+		if spec == nil {
+			continue
+		}
+		if literal == nil {
+			return nil, fmt.Errorf("%s: no %s{...} literal Uses the ResourceDefs", dir, api)
+		}
+
+		uses := make(map[string][]ast.Expr)
+		for _, elt := range literal.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if key, ok := kv.Key.(*ast.Ident); ok {
+					uses[key.Name] = used(kv.Value)
+				}
+			}
+		}
+
+		for _, field := range spec.Fields.List {
+			if !isResourceSpec(field.Type) {
+				continue
+			}
+			for _, fieldName := range field.Names {
+				resource := &SourceResource{Name: fieldName.Name}
+				for _, use := range uses[fieldName.Name] {
+					op := &SourceOperation{}
+					def := resourceDef(use)
+					if ident, ok := use.(*ast.Ident); ok {
+						op.Def = ident.Name
+						def = defs[ident.Name]
+					}
+					if def == nil {
+						return nil, fmt.Errorf("%s: %s Uses %s, which isn't a ResourceDef of the package", dir, fieldName.Name, exprString(use))
+					}
+					readDef(op, def)
+					resource.Operations = append(resource.Operations, op)
+				}
+				source.Resources = append(source.Resources, resource)
+			}
+		}
+		source.ImportPath, _ = ImportPath(dir)
+		return source, nil
+	}
+	return nil, fmt.Errorf("%s: no spec struct named %s", dir, api)
+}
+
+func isResourceSpec(t ast.Expr) bool {
+	star, ok := t.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	if sel, ok := star.X.(*ast.SelectorExpr); ok {
+		return sel.Sel.Name == "ResourceSpec"
+	}
+	ident, ok := star.X.(*ast.Ident)
+	return ok && ident.Name == "ResourceSpec"
+}
+
+// resourceDef is the composite literal of &rest.ResourceDef{...}, or nil
+func resourceDef(expr ast.Expr) *ast.CompositeLit {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	switch t := lit.Type.(type) {
+	case *ast.SelectorExpr:
+		if t.Sel.Name == "ResourceDef" {
+			return lit
+		}
+	case *ast.Ident:
+		if t.Name == "ResourceDef" {
+			return lit
+		}
+	}
+	return nil
+}
+
+// used are the arguments of the .Use(def) calls chained on a ResourceSpec, in call order
+func used(expr ast.Expr) []ast.Expr {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	uses := used(sel.X)
+	if sel.Sel.Name == "Use" && len(call.Args) == 1 {
+		uses = append(uses, call.Args[0])
+	}
+	return uses
+}
+
+func readDef(op *SourceOperation, def *ast.CompositeLit) {
+	for _, elt := range def.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, _ := kv.Key.(*ast.Ident)
+		if key == nil {
+			continue
+		}
+		switch key.Name {
+		case "Verb":
+			if lit, ok := kv.Value.(*ast.BasicLit); ok {
+				op.Verb, _ = strconv.Unquote(lit.Value)
+				op.Verb = strings.ToUpper(op.Verb)
+			}
+		case "ResourceArgs":
+			op.Args = reflectedType(kv.Value)
+		case "RequestBody":
+			op.Request = reflectedType(kv.Value)
+		case "ResponseBody":
+			op.Response = reflectedType(kv.Value)
+		}
+	}
+}
+
+// reflectedType is T of reflect.TypeOf(T{}), reflect.TypeOf(&T{}) and
+// reflect.TypeOf((*T)(nil)).Elem(), or nil for anything else
+func reflectedType(expr ast.Expr) ast.Expr {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+
+	if sel.Sel.Name == "Elem" && len(call.Args) == 0 {
+		if inner, ok := sel.X.(*ast.CallExpr); ok && isTypeOf(inner) && len(inner.Args) == 1 {
+			if conversion, ok := inner.Args[0].(*ast.CallExpr); ok {
+				if paren, ok := conversion.Fun.(*ast.ParenExpr); ok {
+					if star, ok := paren.X.(*ast.StarExpr); ok {
+						return star.X
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	if !isTypeOf(call) || len(call.Args) != 1 {
+		return nil
+	}
+	switch arg := call.Args[0].(type) {
+	case *ast.CompositeLit:
+		return arg.Type
+	case *ast.UnaryExpr:
+		if lit, ok := arg.X.(*ast.CompositeLit); ok && arg.Op == token.AND {
+			return &ast.StarExpr{X: lit.Type}
+		}
+	}
+	return nil
+}
+
+func isTypeOf(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "TypeOf"
+}
+
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
+
+// ImportPath is the import path of the package in dir, from the module of the nearest go.mod,
+// or without one, from its place under a $GOPATH/src
+func ImportPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for root := abs; ; root = filepath.Dir(root) {
+		if mod, err := ioutil.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+			for _, line := range strings.Split(string(mod), "\n") {
+				fields := strings.Fields(line)
+				if len(fields) == 2 && fields[0] == "module" {
+					rel, err := filepath.Rel(root, abs)
+					if err != nil {
+						return "", err
+					}
+					module := strings.Trim(fields[1], `"`)
+					if rel == "." {
+						return module, nil
+					}
+					return module + "/" + filepath.ToSlash(rel), nil
+				}
+			}
+		}
+		if filepath.Dir(root) == root {
+			break
+		}
+	}
+	for _, gopath := range filepath.SplitList(gopath()) {
+		rel, err := filepath.Rel(filepath.Join(gopath, "src"), abs)
+		if err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel), nil
+		}
+	}
+	return "", fmt.Errorf("%s isn't in a module or a GOPATH", dir)
+}
+
+func gopath() string {
+	if gopath := os.Getenv("GOPATH"); gopath != "" {
+		return gopath
+	}
+	return build.Default.GOPATH
+}
@@ -0,0 +1,85 @@
+package generating_test
+
+import (
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/gotgo/gokn/generating"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func printed(expr ast.Expr) string {
+	if expr == nil {
+		return ""
+	}
+	var b strings.Builder
+	printer.Fprint(&b, token.NewFileSet(), expr)
+	return b.String()
+}
+
+var _ = Describe("ReadSourceApi", func() {
+
+	It("should read the resources and their ResourceDefs", func() {
+		api, err := ReadSourceApi("testdata/shop", "ShopApi")
+		Expect(err).To(BeNil())
+		Expect(api.Package).To(Equal("shop"))
+		Expect(api.Resources).To(HaveLen(3))
+
+		cart := api.Resources[0]
+		Expect(cart.Name).To(Equal("Cart"))
+		Expect(cart.Operations).To(HaveLen(2))
+		Expect(cart.Operations[0].Verb).To(Equal("GET"))
+		Expect(cart.Operations[0].Def).To(Equal("GetCartDef"))
+		Expect(printed(cart.Operations[0].Args)).To(Equal("CartArgs"))
+		Expect(printed(cart.Operations[0].Response)).To(Equal("Cart"))
+		Expect(cart.Operations[1].Verb).To(Equal("PUT"))
+		Expect(printed(cart.Operations[1].Request)).To(Equal("*Cart"))
+
+		carts := api.Resources[1]
+		Expect(carts.Operations[0].Verb).To(Equal("POST"))
+		Expect(printed(carts.Operations[0].Request)).To(Equal("[]*Cart"))
+
+		health := api.Resources[2]
+		Expect(health.Operations[0].Args).To(BeNil())
+	})
+
+	It("should fail without the spec struct", func() {
+		_, err := ReadSourceApi("testdata/shop", "Missing")
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("should find the import path from go.mod", func() {
+		dir, err := ioutil.TempDir("", "generating")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		Expect(ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/shop\n\ngo 1.18\n"), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "api", "v1"), 0755)).To(Succeed())
+
+		path, err := ImportPath(filepath.Join(dir, "api", "v1"))
+		Expect(err).To(BeNil())
+		Expect(path).To(Equal("example.com/shop/api/v1"))
+	})
+
+	It("should find the import path under the GOPATH without a go.mod", func() {
+		dir, err := ioutil.TempDir("", "gopath")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		defer os.Setenv("GOPATH", os.Getenv("GOPATH"))
+		Expect(os.Setenv("GOPATH", "/nowhere"+string(filepath.ListSeparator)+dir)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "src", "example.com", "shop"), 0755)).To(Succeed())
+
+		path, err := ImportPath(filepath.Join(dir, "src", "example.com", "shop"))
+		Expect(err).To(BeNil())
+		Expect(path).To(Equal("example.com/shop"))
+
+		_, err = ImportPath(dir)
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,602 @@
+package generating
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gotgo/gokn/rest"
+)
+
+// SpecOptions configure GenerateSpec
+type SpecOptions struct {
+	// Package of the generated file
+	Package string
+	// Api names the spec struct, Api when empty
+	Api string
+}
+
+// GenerateSpec generates a go file from an OpenAPI document with the schemas as structs, an
+// Args struct per operation for its path and query parameters, a rest.ResourceDef per
+// operation and a spec struct of a *rest.ResourceSpec per path.  Path resources are named
+// by their operationIds when a documenting.NewDocument made them, getOrder is Order,
+// otherwise by their path, /orders/{id} is OrdersById.
+//
+//	Example:
+//
+//		doc, err := generating.LoadOpenApi("partner.yaml")
+//		src, err := generating.GenerateSpec(doc, &generating.SpecOptions{Package: "partner"})
+//		err = ioutil.WriteFile("partner/api.go", src, 0644)
+func GenerateSpec(doc *OpenApi, options *SpecOptions) ([]byte, error) {
+	g := newSpecGenerator(doc)
+	api := options.Api
+	if api == "" {
+		api = "Api"
+	}
+	g.names[api] = true
+	g.names["New"+api] = true
+
+	g.components()
+	resources := g.resources()
+
+	//the defs are written first, they add the imports they use
+	var defs bytes.Buffer
+	for _, resource := range resources {
+		for _, op := range resource.operations {
+			g.writeDef(&defs, op)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by goknGen from %s. DO NOT EDIT.\n\n", documentName(doc))
+	fmt.Fprintf(&out, "package %s\n\n", options.Package)
+	out.WriteString(importBlock(g.importList()))
+	out.Write(g.decls.Bytes())
+
+	out.WriteString("var (\n")
+	out.Write(defs.Bytes())
+	out.WriteString(")\n\n")
+
+	fmt.Fprintf(&out, "// %s has a resource per path of %s\n", api, documentName(doc))
+	fmt.Fprintf(&out, "type %s struct {\n", api)
+	for _, resource := range resources {
+		fmt.Fprintf(&out, "\t%s *rest.ResourceSpec\n", resource.name)
+	}
+	out.WriteString("}\n\n")
+
+	fmt.Fprintf(&out, "func New%s() *%s {\n\treturn &%s{\n", api, api, api)
+	for _, resource := range resources {
+		fmt.Fprintf(&out, "\t\t%s: rest.NewResourceSpec(%q)", resource.name, resource.contentType)
+		for _, op := range resource.operations {
+			fmt.Fprintf(&out, ".\n\t\t\tUse(%s)", op.def)
+		}
+		out.WriteString(",\n")
+	}
+	out.WriteString("\t}\n}\n")
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return out.Bytes(), fmt.Errorf("generated code doesn't compile: %s", err)
+	}
+	return src, nil
+}
+
+func documentName(doc *OpenApi) string {
+	name := strings.TrimSpace(doc.Info.Title + " " + doc.Info.Version)
+	if name == "" {
+		return "an OpenAPI document"
+	}
+	return name
+}
+
+type specGenerator struct {
+	doc     *OpenApi
+	names   names
+	types   map[string]string //component name to go type name
+	structs map[string]bool
+	imports map[string]bool
+	decls   bytes.Buffer
+}
+
+type resourceModel struct {
+	name        string
+	path        string
+	contentType string
+	operations  []*operationModel
+}
+
+type operationModel struct {
+	name                 string
+	def                  string
+	verb                 string
+	path                 string
+	summary              string
+	args                 string
+	request              string
+	response             string
+	headers              []string
+	requestContentTypes  []string
+	responseContentTypes []string
+}
+
+func newSpecGenerator(doc *OpenApi) *specGenerator {
+	return &specGenerator{
+		doc:     doc,
+		names:   make(names),
+		types:   make(map[string]string),
+		structs: make(map[string]bool),
+		imports: map[string]bool{"github.com/gotgo/gokn/rest": true},
+	}
+}
+
+func (g *specGenerator) importList() []string {
+	list := []string{}
+	for pkg := range g.imports {
+		list = append(list, pkg)
+	}
+	return list
+}
+
+// importBlock imports the standard library, then the other packages
+func importBlock(paths []string) string {
+	standard, others := []string{}, []string{}
+	for _, path := range paths {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			others = append(others, path)
+		} else {
+			standard = append(standard, path)
+		}
+	}
+	sort.Strings(standard)
+	sort.Strings(others)
+
+	var b strings.Builder
+	b.WriteString("import (\n")
+	for _, path := range standard {
+		fmt.Fprintf(&b, "\t%q\n", path)
+	}
+	if len(standard) > 0 && len(others) > 0 {
+		b.WriteString("\n")
+	}
+	for _, path := range others {
+		fmt.Fprintf(&b, "\t%q\n", path)
+	}
+	b.WriteString(")\n\n")
+	return b.String()
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	switch m := m.(type) {
+	case map[string]*Schema:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]*PathItem:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]*MediaType:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]*Response:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// components names every component schema before any is written, so they can refer to
+// each other in any order
+func (g *specGenerator) components() {
+	keys := sortedKeys(g.doc.Components.Schemas)
+	for _, key := range keys {
+		g.types[key] = g.names.unique(GoName(key))
+	}
+	for _, key := range keys {
+		schema := g.doc.Components.Schemas[key]
+		name := g.types[key]
+		if g.isStruct(schema) {
+			g.writeStruct(name, schema)
+		} else {
+			g.comment(name, schema.Description)
+			fmt.Fprintf(&g.decls, "type %s %s\n\n", name, g.goType(schema, name))
+		}
+	}
+}
+
+func (g *specGenerator) isStruct(s *Schema) bool {
+	if s == nil {
+		return false
+	} else if s.Ref != "" {
+		return g.isStruct(g.doc.schema(s))
+	}
+	return len(s.Properties) > 0 || len(s.AllOf) > 0
+}
+
+// goType is the go type of a schema, inline objects are written as structs named context
+func (g *specGenerator) goType(s *Schema, context string) string {
+	if s == nil {
+		return "interface{}"
+	}
+	if s.Ref != "" {
+		if name, ok := g.types[refName(s.Ref)]; ok {
+			return name
+		}
+		return "interface{}"
+	}
+	if len(s.AllOf) == 1 && s.AllOf[0].Ref != "" {
+		return g.goType(s.AllOf[0], context)
+	}
+	if g.isStruct(s) {
+		name := g.names.unique(context)
+		g.writeStruct(name, s)
+		return name
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		return "interface{}"
+	}
+
+	switch s.Type.Name() {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			g.imports["time"] = true
+			return "time.Time"
+		case "byte", "binary":
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		switch s.Format {
+		case "int32":
+			return "int32"
+		case "int64":
+			return "int64"
+		}
+		return "int"
+	case "number":
+		if s.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.fieldType(s.Items, context+"Item")
+	case "object":
+		if s.AdditionalProperties != nil {
+			return "map[string]" + g.fieldType(s.AdditionalProperties, context+"Value")
+		}
+		return "map[string]interface{}"
+	}
+	return "interface{}"
+}
+
+// fieldType is the goType, with structs as pointers
+func (g *specGenerator) fieldType(s *Schema, context string) string {
+	t := g.goType(s, context)
+	if g.isStruct(s) {
+		return "*" + t
+	}
+	return t
+}
+
+func (g *specGenerator) comment(name, description string) {
+	if line := firstLine(description); line != "" {
+		fmt.Fprintf(&g.decls, "// %s %s\n", name, line)
+	}
+}
+
+func firstLine(s string) string {
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(s), "\n", 2)[0])
+}
+
+// writeStruct writes a struct of the properties, allOf references are embedded and inline
+// allOf schemas are merged
+func (g *specGenerator) writeStruct(name string, s *Schema) {
+	var fields bytes.Buffer
+	fieldNames := make(names)
+	g.writeFields(&fields, fieldNames, name, s)
+	g.structs[name] = true
+	g.comment(name, s.Description)
+	fmt.Fprintf(&g.decls, "type %s struct {\n%s}\n\n", name, fields.String())
+}
+
+func (g *specGenerator) writeFields(fields *bytes.Buffer, fieldNames names, structName string, s *Schema) {
+	for _, member := range s.AllOf {
+		if member.Ref != "" && g.isStruct(member) {
+			embedded := g.goType(member, structName)
+			fieldNames[embedded] = true
+			fmt.Fprintf(fields, "\t%s\n", embedded)
+		} else if resolved := g.doc.schema(member); resolved != nil {
+			g.writeFields(fields, fieldNames, structName, resolved)
+		}
+	}
+
+	required := make(map[string]bool)
+	for _, r := range s.Required {
+		required[r] = true
+	}
+	order := s.PropertyOrder
+	if len(order) != len(s.Properties) {
+		order = sortedKeys(s.Properties)
+	}
+	for _, property := range order {
+		ps := s.Properties[property]
+		field := fieldNames.unique(GoName(property))
+		t := g.fieldType(ps, structName+field)
+
+		tags := fmt.Sprintf(`json:"%s"`, property)
+		if !required[property] {
+			tags = fmt.Sprintf(`json:"%s,omitempty"`, property)
+		}
+		if resolved := g.doc.schema(ps); resolved != nil {
+			if resolved.WriteOnly || resolved.Format == "password" {
+				tags += ` ` + rest.SensitiveTag + `:"true"`
+			}
+			if example, ok := exampleTag(resolved); ok {
+				tags += ` example:` + strconv.Quote(example)
+			}
+		}
+		if ps != nil {
+			if line := firstLine(ps.Description); line != "" {
+				fmt.Fprintf(fields, "\t// %s %s\n", field, line)
+			}
+		}
+		fmt.Fprintf(fields, "\t%s %s `%s`\n", field, t, tags)
+	}
+}
+
+// exampleTag is the schema's example as a mocking.ExampleTag, strings as is and anything
+// else as json
+func exampleTag(s *Schema) (string, bool) {
+	example := s.Example
+	if example == nil && len(s.Examples) > 0 {
+		example = s.Examples[0]
+	}
+	if example == nil {
+		return "", false
+	}
+	if str, ok := example.(string); ok {
+		return str, true
+	}
+	bts, err := json.Marshal(jsonable(example))
+	if err != nil {
+		return "", false
+	}
+	return string(bts), true
+}
+
+// jsonable converts the map[interface{}]interface{} yaml may decode to json friendly maps
+func jsonable(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{})
+		for k, value := range v {
+			m[fmt.Sprint(k)] = jsonable(value)
+		}
+		return m
+	case map[string]interface{}:
+		for k, value := range v {
+			v[k] = jsonable(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = jsonable(value)
+		}
+	}
+	return v
+}
+
+// resources models every path, the ResourceDefs and types of their operations are written
+func (g *specGenerator) resources() []*resourceModel {
+	resources := []*resourceModel{}
+	resourceNames := make(names)
+	for _, path := range sortedKeys(g.doc.Paths) {
+		item := g.doc.Paths[path]
+		ops := item.Operations()
+		if len(ops) == 0 {
+			continue
+		}
+		resource := &resourceModel{
+			name:        resourceNames.unique(resourceName(path, ops)),
+			path:        path,
+			contentType: rest.ContentTypeJson,
+		}
+		for _, op := range ops {
+			resource.operations = append(resource.operations, g.operation(resource, item, op))
+		}
+		if first := resource.operations[0]; len(first.responseContentTypes) > 0 && !contains(first.responseContentTypes, rest.ContentTypeJson) {
+			resource.contentType = first.responseContentTypes[0]
+		}
+		resources = append(resources, resource)
+	}
+	return resources
+}
+
+// resourceName is X when every operationId is the lowercase verb then X, as
+// documenting.NewDocument names them, otherwise the ResourceName of the path
+func resourceName(path string, ops []*VerbOperation) string {
+	name := ""
+	for _, op := range ops {
+		prefix := strings.ToLower(op.Verb)
+		if !strings.HasPrefix(op.OperationId, prefix) || len(op.OperationId) == len(prefix) {
+			return ResourceName(path)
+		}
+		remainder := op.OperationId[len(prefix):]
+		if name != "" && remainder != name {
+			return ResourceName(path)
+		}
+		name = remainder
+	}
+	return GoName(name)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *specGenerator) operation(resource *resourceModel, item *PathItem, op *VerbOperation) *operationModel {
+	name := GoName(op.OperationId)
+	if op.OperationId == "" {
+		name = GoName(strings.ToLower(op.Verb)) + resource.name
+	}
+	name = g.names.unique(name)
+	model := &operationModel{
+		name:    name,
+		def:     g.names.unique(name + "Def"),
+		verb:    op.Verb,
+		path:    resource.path,
+		summary: firstLine(op.Summary),
+	}
+	if model.summary == "" {
+		model.summary = firstLine(op.Description)
+	}
+
+	g.args(model, append(append([]*Parameter{}, item.Parameters...), op.Parameters...))
+
+	if body := g.doc.requestBody(op.RequestBody); body != nil && len(body.Content) > 0 {
+		model.requestContentTypes = sortedKeys(body.Content)
+		model.request = g.goType(preferredSchema(body.Content), name+"Request")
+	}
+	if response := g.doc.response(success(op.Responses)); response != nil && len(response.Content) > 0 {
+		model.responseContentTypes = sortedKeys(response.Content)
+		model.response = g.goType(preferredSchema(response.Content), name+"Response")
+	}
+	return model
+}
+
+// success is the 200 response, or else the first 2xx
+func success(responses map[string]*Response) *Response {
+	if r, ok := responses["200"]; ok {
+		return r
+	}
+	for _, code := range sortedKeys(responses) {
+		if strings.HasPrefix(code, "2") {
+			return responses[code]
+		}
+	}
+	return nil
+}
+
+// preferredSchema is the json schema, or else the first
+func preferredSchema(content map[string]*MediaType) *Schema {
+	if media, ok := content[rest.ContentTypeJson]; ok {
+		return media.Schema
+	}
+	return content[sortedKeys(content)[0]].Schema
+}
+
+// args writes the Args struct of the path and query parameters, the operation's override
+// the path item's.  Args are decoded from strings, so numbers and booleans are json
+// ,string fields, like rest.IdIntArg, and lists are comma separated strings.
+func (g *specGenerator) args(model *operationModel, params []*Parameter) {
+	byName := make(map[string]*Parameter)
+	order := []string{}
+	for _, p := range params {
+		p = g.doc.parameter(p)
+		key := p.In + " " + p.Name
+		if _, ok := byName[key]; !ok {
+			order = append(order, key)
+		}
+		byName[key] = p
+	}
+
+	var fields bytes.Buffer
+	fieldNames := make(names)
+	for _, key := range order {
+		p := byName[key]
+		switch p.In {
+		case "header":
+			model.headers = append(model.headers, p.Name)
+			continue
+		case "path", "query":
+		default:
+			continue
+		}
+
+		field := fieldNames.unique(GoName(p.Name))
+		t := "string"
+		jsonTag := p.Name
+		if schema := g.doc.schema(p.Schema); schema != nil && !schema.Type.Is("array") && !g.isStruct(schema) {
+			t = g.goType(p.Schema, model.name+field)
+			switch g.goType(schema, "") {
+			case "int", "int32", "int64", "float32", "float64", "bool":
+				jsonTag += ",string"
+			}
+		}
+		optional := ""
+		if p.In == "query" && !p.Required {
+			optional = ",omitempty"
+		}
+		if line := firstLine(p.Description); line != "" {
+			fmt.Fprintf(&fields, "\t// %s %s\n", field, line)
+		}
+		fmt.Fprintf(&fields, "\t%s %s `json:\"%s%s\" url:\"%s%s\" structs:\"%s%s\"`\n",
+			field, t, jsonTag, optional, p.Name, optional, p.Name, optional)
+	}
+
+	if fields.Len() > 0 {
+		model.args = g.names.unique(model.name + "Args")
+		g.structs[model.args] = true
+		fmt.Fprintf(&g.decls, "type %s struct {\n%s}\n\n", model.args, fields.String())
+	}
+}
+
+func (g *specGenerator) writeDef(out *bytes.Buffer, op *operationModel) {
+	if op.summary != "" {
+		fmt.Fprintf(out, "\t// %s %s\n", op.def, op.summary)
+	}
+	fmt.Fprintf(out, "\t%s = &rest.ResourceDef{\n", op.def)
+	fmt.Fprintf(out, "\t\tResourceT: %q,\n", op.path)
+	fmt.Fprintf(out, "\t\tVerb: %q,\n", op.verb)
+	if op.args != "" {
+		fmt.Fprintf(out, "\t\tResourceArgs: %s,\n", g.typeOf(op.args))
+	}
+	if len(op.headers) > 0 {
+		fmt.Fprintf(out, "\t\tHeaders: %s,\n", stringList(op.headers))
+	}
+	if op.request != "" {
+		fmt.Fprintf(out, "\t\tRequestBody: %s,\n", g.typeOf(op.request))
+	}
+	if op.response != "" {
+		fmt.Fprintf(out, "\t\tResponseBody: %s,\n", g.typeOf(op.response))
+	}
+	if len(op.requestContentTypes) > 0 {
+		fmt.Fprintf(out, "\t\tRequestContentTypes: %s,\n", stringList(op.requestContentTypes))
+	}
+	if len(op.responseContentTypes) > 0 {
+		fmt.Fprintf(out, "\t\tResponseContentTypes: %s,\n", stringList(op.responseContentTypes))
+	}
+	out.WriteString("\t}\n")
+}
+
+// typeOf is the reflect.Type expression of a go type, a composite literal when there is one
+func (g *specGenerator) typeOf(t string) string {
+	g.imports["reflect"] = true
+	if g.structs[t] || strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map[") {
+		return "reflect.TypeOf(" + t + "{})"
+	}
+	return "reflect.TypeOf((*" + t + ")(nil)).Elem()"
+}
+
+func stringList(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = strconv.Quote(s)
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
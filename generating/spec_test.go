@@ -0,0 +1,106 @@
+package generating_test
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"reflect"
+
+	"github.com/gotgo/gokn/documenting"
+	. "github.com/gotgo/gokn/generating"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type Widget struct {
+	Name string `json:"name"`
+}
+
+type WidgetApi struct {
+	Widget *rest.ResourceSpec
+}
+
+var _ = Describe("GenerateSpec", func() {
+	var src string
+
+	BeforeEach(func() {
+		doc, err := LoadOpenApi("testdata/orders.yaml")
+		Expect(err).To(BeNil())
+		bts, err := GenerateSpec(doc, &SpecOptions{Package: "orders"})
+		Expect(err).To(BeNil())
+		src = string(bts)
+	})
+
+	It("should generate a go file", func() {
+		_, err := parser.ParseFile(token.NewFileSet(), "orders.go", src, 0)
+		Expect(err).To(BeNil())
+		Expect(src).To(HavePrefix("// Code generated by goknGen from Orders 2.1. DO NOT EDIT.\n\npackage orders\n"))
+	})
+
+	It("should generate the schemas as structs", func() {
+		Expect(src).To(ContainSubstring("type Order struct {\n\tBase\n\tId     int64             `json:\"id\" example:\"42\"`\n"))
+		Expect(src).To(ContainSubstring("\tCard   string            `json:\"card,omitempty\" sensitive:\"true\"`\n"))
+		Expect(src).To(ContainSubstring("\tLines  []*OrderLinesItem `json:\"lines,omitempty\"`\n"))
+		Expect(src).To(ContainSubstring("\tParent *Order            `json:\"parent,omitempty\"`\n"))
+		Expect(src).To(ContainSubstring("type Status string\n"))
+		Expect(src).To(ContainSubstring("type CreateOrderRequest struct {\n\tSku      string `json:\"sku\"`\n"))
+	})
+
+	It("should generate args for the path and query parameters", func() {
+		Expect(src).To(ContainSubstring("\tPage   int    `json:\"page,string,omitempty\" url:\"page,omitempty\" structs:\"page,omitempty\"`\n"))
+		Expect(src).To(ContainSubstring("\tStatus Status `json:\"status\" url:\"status\" structs:\"status\"`\n"))
+		Expect(src).To(ContainSubstring("\tOrderId int64 `json:\"order_id,string\" url:\"order_id\" structs:\"order_id\"`\n"))
+	})
+
+	It("should generate a ResourceDef per operation", func() {
+		Expect(src).To(ContainSubstring(`	ListOrdersDef = &rest.ResourceDef{
+		ResourceT:            "/orders",
+		Verb:                 "GET",
+		ResourceArgs:         reflect.TypeOf(ListOrdersArgs{}),
+		Headers:              []string{"X-Tenant"},
+		ResponseBody:         reflect.TypeOf([]*Order{}),
+		ResponseContentTypes: []string{"application/json"},
+	}
+`))
+		Expect(src).To(ContainSubstring("\t\tRequestBody:          reflect.TypeOf(CreateOrderRequest{}),\n"))
+	})
+
+	It("should generate the spec struct", func() {
+		Expect(src).To(ContainSubstring("type Api struct {\n\tOrders          *rest.ResourceSpec\n\tOrdersByOrderId *rest.ResourceSpec\n}\n"))
+		Expect(src).To(ContainSubstring("\t\tOrders: rest.NewResourceSpec(\"application/json\").\n\t\t\tUse(ListOrdersDef).\n\t\t\tUse(CreateOrderDef),\n"))
+	})
+
+	It("should name resources like the documents it makes", func() {
+		api := &WidgetApi{
+			Widget: rest.NewResourceSpec(rest.ContentTypeJson).
+				Use(&rest.ResourceDef{ResourceT: "/widgets/{id}", Verb: "GET", ResponseBody: reflect.TypeOf(Widget{})}),
+		}
+		json, err := documenting.NewDocument(&documenting.Info{Title: "Widgets"}, api, "").Json()
+		Expect(err).To(BeNil())
+		doc, err := ReadOpenApi(bytes.NewReader(json))
+		Expect(err).To(BeNil())
+
+		bts, err := GenerateSpec(doc, &SpecOptions{Package: "widgets", Api: "WidgetApi"})
+		Expect(err).To(BeNil())
+		Expect(string(bts)).To(ContainSubstring("type WidgetApi struct {\n\tWidget *rest.ResourceSpec\n}\n"))
+		Expect(string(bts)).To(ContainSubstring("type Widget struct {\n\tName string `json:\"name\"`\n}\n"))
+		Expect(string(bts)).To(ContainSubstring("\t\tResponseBody:         reflect.TypeOf(Widget{}),\n"))
+	})
+
+	It("should only import reflect when a type is reflected", func() {
+		json := `{"openapi":"3.0.0","info":{"title":"Cache","version":"1"},` +
+			`"paths":{"/cache":{"delete":{"responses":{"204":{"description":"cleared"}}}}}}`
+		doc, err := ReadOpenApi(bytes.NewReader([]byte(json)))
+		Expect(err).To(BeNil())
+
+		bts, err := GenerateSpec(doc, &SpecOptions{Package: "cache"})
+		Expect(err).To(BeNil())
+		file, err := parser.ParseFile(token.NewFileSet(), "cache.go", bts, parser.ImportsOnly)
+		Expect(err).To(BeNil())
+		Expect(file.Imports).To(HaveLen(1))
+		Expect(file.Imports[0].Path.Value).To(Equal(`"github.com/gotgo/gokn/rest"`))
+		Expect(string(bts)).To(ContainSubstring(`Verb:      "DELETE"`))
+	})
+})
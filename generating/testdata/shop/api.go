@@ -0,0 +1,47 @@
+package shop
+
+import (
+	"reflect"
+
+	"github.com/gotgo/gokn/rest"
+)
+
+type Cart struct {
+	Items []string `json:"items"`
+}
+
+type CartArgs struct {
+	Id int `json:"id,string" url:"id" structs:"id"`
+}
+
+var GetCartDef = &rest.ResourceDef{
+	ResourceT:    "/carts/{id}",
+	Verb:         "GET",
+	ResourceArgs: reflect.TypeOf(CartArgs{}),
+	ResponseBody: reflect.TypeOf((*Cart)(nil)).Elem(),
+}
+
+type ShopApi struct {
+	Cart   *rest.ResourceSpec
+	Carts  *rest.ResourceSpec
+	Health *rest.ResourceSpec
+	name   string
+}
+
+func NewShopApi() *ShopApi {
+	putCart := &rest.ResourceDef{
+		ResourceT:    "/carts/{id}",
+		Verb:         "PUT",
+		ResourceArgs: reflect.TypeOf(CartArgs{}),
+		RequestBody:  reflect.TypeOf(&Cart{}),
+	}
+	return &ShopApi{
+		Cart: rest.NewResourceSpec(rest.ContentTypeJson).
+			Use(GetCartDef).
+			Use(putCart),
+		Carts: rest.NewResourceSpec(rest.ContentTypeJson).
+			Use(&rest.ResourceDef{ResourceT: "/carts", Verb: "post", RequestBody: reflect.TypeOf([]*Cart{})}),
+		Health: rest.NewResourceSpec(rest.ContentTypeJson).
+			Use(&rest.ResourceDef{ResourceT: "/health", Verb: "HEAD"}),
+	}
+}
@@ -0,0 +1,144 @@
+package handling
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type AccessLogFormat int
+
+const (
+	// CommonLogFormat is the NCSA common log, followed by the resource, latency, trace id
+	// and request id
+	CommonLogFormat AccessLogFormat = iota
+	// CombinedLogFormat adds the referer and user agent to the CommonLogFormat
+	CombinedLogFormat
+	// JsonLogFormat writes one AccessLogEntry per line
+	JsonLogFormat
+)
+
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogEntry is one line of the access log
+type AccessLogEntry struct {
+	Time       time.Time `json:"time"`
+	RemoteAddr string    `json:"remoteAddr"`
+	Method     string    `json:"method"`
+	ResourceT  string    `json:"resource"`
+	Path       string    `json:"path"`
+	Protocol   string    `json:"protocol"`
+	Status     int       `json:"status"`
+	Bytes      int       `json:"bytes"`
+	// Latency is in seconds
+	Latency   float64 `json:"latency"`
+	Principal string  `json:"principal,omitempty"`
+	TraceId   string  `json:"traceId,omitempty"`
+	RequestId string  `json:"requestId,omitempty"`
+	Referer   string  `json:"referer,omitempty"`
+	UserAgent string  `json:"userAgent,omitempty"`
+}
+
+// AccessLog writes a line for every request to the endpoints it is used on
+//
+//	Example:
+//
+//		root.Use(handling.NewAccessLog(os.Stdout, handling.JsonLogFormat).Middleware)
+type AccessLog struct {
+	Writer io.Writer
+	Format AccessLogFormat
+	lock   sync.Mutex
+}
+
+func NewAccessLog(writer io.Writer, format AccessLogFormat) *AccessLog {
+	return &AccessLog{
+		Writer: writer,
+		Format: format,
+	}
+}
+
+func (al *AccessLog) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r)
+		if x := ExchangeOf(r); x != nil {
+			al.Log(newAccessLogEntry(r, x))
+		}
+	}
+}
+
+func newAccessLogEntry(r *http.Request, x *Exchange) *AccessLogEntry {
+	entry := &AccessLogEntry{
+		Time:       x.Start,
+		RemoteAddr: r.RemoteAddr,
+		Method:     r.Method,
+		ResourceT:  x.Path,
+		Protocol:   r.Proto,
+		Status:     x.StatusCode,
+		Bytes:      x.BytesSent,
+		Latency:    x.Duration.Seconds(),
+		Referer:    r.Referer(),
+		UserAgent:  r.UserAgent(),
+		RequestId:  x.RequestId,
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		entry.RemoteAddr = host
+	}
+	if r.URL != nil {
+		entry.Path = r.URL.RequestURI()
+	}
+	if x.Request != nil {
+		entry.Principal = x.Request.Context.Principal
+	}
+	if x.TraceContext != nil {
+		entry.TraceId = x.TraceContext.TraceId
+	}
+	return entry
+}
+
+// Log writes the entry as a single line
+func (al *AccessLog) Log(entry *AccessLogEntry) {
+	var line string
+	switch al.Format {
+	case JsonLogFormat:
+		bts, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		line = string(bts) + "\n"
+	case CombinedLogFormat:
+		line = fmt.Sprintf("%s %q %q %s\n", commonLog(entry), orDash(entry.Referer), orDash(entry.UserAgent), extras(entry))
+	default:
+		line = fmt.Sprintf("%s %s\n", commonLog(entry), extras(entry))
+	}
+
+	al.lock.Lock()
+	defer al.lock.Unlock()
+	io.WriteString(al.Writer, line)
+}
+
+func commonLog(e *AccessLogEntry) string {
+	bytes := "-"
+	if e.Bytes > 0 {
+		bytes = fmt.Sprintf("%d", e.Bytes)
+	}
+	request := fmt.Sprintf("%s %s %s", e.Method, e.Path, e.Protocol)
+	return fmt.Sprintf("%s - %s [%s] %q %d %s",
+		orDash(e.RemoteAddr), orDash(strings.Replace(e.Principal, " ", "_", -1)),
+		e.Time.Format(clfTimeFormat), request, e.Status, bytes)
+}
+
+func extras(e *AccessLogEntry) string {
+	return fmt.Sprintf("%q %.6f %s %s", e.ResourceT, e.Latency, orDash(e.TraceId), orDash(e.RequestId))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
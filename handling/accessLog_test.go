@@ -0,0 +1,101 @@
+package handling_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+
+	. "github.com/gotgo/gokn/handling"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AccessLog", func() {
+
+	var (
+		root   *RootHandler
+		router *TestRouter
+		out    *bytes.Buffer
+		log    *AccessLog
+	)
+
+	BeforeEach(func() {
+		root = NewRootHandler()
+		root.Binder = func(h rest.HandlerFunc) func(*rest.Request, rest.Responder) {
+			return func(req *rest.Request, resp rest.Responder) {
+				req.Context.Principal = "alice"
+				h(req, resp)
+			}
+		}
+		router = NewTestRouter()
+		out = new(bytes.Buffer)
+		log = NewAccessLog(out, CommonLogFormat)
+		root.Use(log.Middleware)
+		root.Bind(router, getSpec("/items/{id}", "GET"), NewTestHandler(), "/v1")
+	})
+
+	get := func() {
+		request, _ := http.NewRequest("GET", "/v1/items/7?full=1", nil)
+		request.RemoteAddr = "10.0.0.1:5555"
+		request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		request.Header.Set("User-Agent", "tester")
+		request.Header.Set("X-Request-ID", "req-1")
+		router.Handlers[0](new(TestResponseWriter), request)
+	}
+
+	It("should write the common log format", func() {
+		get()
+		line := out.String()
+		Expect(line).To(HavePrefix(`10.0.0.1 - alice [`))
+		Expect(line).To(ContainSubstring(`] "GET /v1/items/7?full=1 HTTP/1.1" 200 `))
+		Expect(line).To(ContainSubstring(`"/v1/items/{id}"`))
+		Expect(line).To(HaveSuffix(" 4bf92f3577b34da6a3ce929d0e0e4736 req-1\n"))
+		Expect(line).ToNot(ContainSubstring("tester"))
+	})
+
+	It("should count the bytes of a streamed reply", func() {
+		log.Format = JsonLogFormat
+		def := &rest.ResourceDef{ResourceT: "/words", Verb: "GET", ResponseBody: reflect.TypeOf([]string{})}
+		root.Bind(router, rest.NewServerResource(def, nil, []string{rest.ContentTypeNdjson}), new(WordsHandler), "/v1")
+		request, _ := http.NewRequest("GET", "/v1/words", nil)
+		request.Header.Set("Accept", rest.ContentTypeNdjson)
+		recorder := httptest.NewRecorder()
+		router.Handlers[1](recorder, request)
+
+		entry := new(AccessLogEntry)
+		Expect(json.Unmarshal(out.Bytes(), entry)).To(Succeed())
+		Expect(recorder.Body.String()).To(Equal("\"a\"\n"))
+		Expect(entry.Bytes).To(Equal(recorder.Body.Len()))
+	})
+
+	It("should add the referer and user agent to the combined format", func() {
+		log.Format = CombinedLogFormat
+		get()
+		Expect(out.String()).To(ContainSubstring(`"-" "tester" "/v1/items/{id}"`))
+	})
+
+	It("should write json lines", func() {
+		log.Format = JsonLogFormat
+		get()
+		get()
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		Expect(lines).To(HaveLen(2))
+
+		entry := new(AccessLogEntry)
+		Expect(json.Unmarshal([]byte(lines[0]), entry)).To(Succeed())
+		Expect(entry.Method).To(Equal("GET"))
+		Expect(entry.ResourceT).To(Equal("/v1/items/{id}"))
+		Expect(entry.Path).To(Equal("/v1/items/7?full=1"))
+		Expect(entry.Status).To(Equal(200))
+		Expect(entry.Bytes).To(BeNumerically(">", 0))
+		Expect(entry.Latency).To(BeNumerically(">=", 0))
+		Expect(entry.Principal).To(Equal("alice"))
+		Expect(entry.TraceId).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+		Expect(entry.RequestId).To(Equal("req-1"))
+	})
+})
@@ -9,4 +9,6 @@ import (
 type ContentTypeDecoder struct {
 	ContentType string
 	Decode      func(r io.Reader, v interface{}, trace tracing.Tracer) error
+	// Strict, when set, decodes the bodies of the endpoints with a StrictBody
+	Strict func(r io.Reader, v interface{}, trace tracing.Tracer) error
 }
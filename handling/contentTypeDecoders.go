@@ -2,8 +2,7 @@ package handling
 
 import (
 	"bytes"
-	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
 	"io/ioutil"
 	"reflect"
@@ -26,45 +25,112 @@ func NewContentTypeDecoders() *ContentTypeDecoders {
 	json := &ContentTypeDecoder{
 		ContentType: "application/json",
 		Decode:      JsonDecoder,
+		Strict:      JsonStrictDecoder,
 	}
 	cd.library[json.ContentType] = json
+	cd.SetCodec(rest.NewXmlCodec(), rest.ContentTypeXml, rest.ContentTypeTextXml)
+	cd.SetCodec(rest.MsgpackCodec{}, rest.ContentTypeMsgpack, rest.ContentTypeXMsgpack)
+	cd.SetCodec(rest.CborCodec{})
+	cd.SetCodec(rest.ProtobufCodec{})
+	cd.SetCodec(rest.NdjsonCodec{})
+	cd.SetCodec(rest.CsvCodec{})
 	return cd
 }
 
+// JsonDecoder decodes with encoding/json, and proto.Messages with protojson
 func JsonDecoder(reader io.Reader, v interface{}, trace tracing.Tracer) error {
 	if bytes, err := ioutil.ReadAll(reader); err != nil {
 		return err
-	} else if err = json.Unmarshal(bytes, &v); err != nil {
+	} else {
+		return rest.JsonCodec{}.Decode(bytes, &v)
+	}
+}
+
+// JsonStrictDecoder is the JsonDecoder that also fails unknown fields, duplicate keys and
+// trailing data
+func JsonStrictDecoder(reader io.Reader, v interface{}, trace tracing.Tracer) error {
+	if bytes, err := ioutil.ReadAll(reader); err != nil {
 		return err
 	} else {
-		trace.Annotate(tracing.FromRequestData, "body", fmt.Sprintf("%s", bytes))
-		return nil
+		return rest.JsonCodec{}.DecodeStrict(bytes, &v)
 	}
 }
 
+// Get is the decoder of the first of the content types that has one, by its media type, or by
+// the first of its rest.MediaType Lookups that has one, such as application/json for
+// application/problem+json
 func (cd *ContentTypeDecoders) Get(types []string) *ContentTypeDecoder {
+	decoder, _ := cd.get(types)
+	return decoder
+}
+
+// get is the decoder and the content type it was found for
+func (cd *ContentTypeDecoders) get(types []string) (*ContentTypeDecoder, string) {
 	for _, t := range types {
-		decoder := cd.library[t]
-		if decoder != nil {
-			return decoder
+		mt, err := rest.ParseMediaType(t)
+		if err != nil {
+			continue
+		}
+		for _, key := range mt.Lookups() {
+			if decoder := cd.library[key]; decoder != nil {
+				return decoder, t
+			}
 		}
 	}
-	return nil
+	return nil, ""
 }
 
+// Set registers the decoder by the media type of its ContentType, which may be a wildcard
+// such as text/*
 func (cd *ContentTypeDecoders) Set(decoder *ContentTypeDecoder) {
-	cd.library[decoder.ContentType] = decoder
+	cd.library[essence(decoder.ContentType)] = decoder
 }
 
+// SetCodec decodes the content types, or the codec's own when none are given, with the codec.
+// A rest.StrictCodec also decodes strictly
+func (cd *ContentTypeDecoders) SetCodec(codec rest.Codec, contentTypes ...string) {
+	if len(contentTypes) == 0 {
+		contentTypes = []string{codec.ContentType()}
+	}
+	for _, ct := range contentTypes {
+		decoder := &ContentTypeDecoder{ContentType: ct, Decode: decodeWith(codec.Decode)}
+		if sc, ok := codec.(rest.StrictCodec); ok {
+			decoder.Strict = decodeWith(sc.DecodeStrict)
+		}
+		cd.Set(decoder)
+	}
+}
+
+// decodeWith decodes what the reader reads with the decode func of a codec
+func decodeWith(decode func(data []byte, v interface{}) error) func(io.Reader, interface{}, tracing.Tracer) error {
+	return func(reader io.Reader, v interface{}, trace tracing.Tracer) error {
+		if bytes, err := ioutil.ReadAll(reader); err != nil {
+			return err
+		} else {
+			return decode(bytes, v)
+		}
+	}
+}
+
+// containsType is true when one of the content types is of the media type c, whatever its
+// parameters
 func containsType(s []string, c string) bool {
 	for _, a := range s {
-		if a == c {
+		if essence(a) == c {
 			return true
 		}
 	}
 	return false
 }
 
+// essence is the type/subtype of a content type, or the content type when it can't be parsed
+func essence(contentType string) string {
+	if mt, err := rest.ParseMediaType(contentType); err == nil {
+		return mt.Essence()
+	}
+	return contentType
+}
+
 func isBytes(t reflect.Type) bool {
 	var b byte
 	bt := reflect.TypeOf(b)
@@ -81,6 +147,9 @@ func isBytes(t reflect.Type) bool {
 	}
 }
 
+// DecodeBody sets the request Body, the RootHandler traces it once redacted.  A body that fails
+// to decode is a rest.Malformed Error, with where it failed when the decoder tells.  An empty
+// body is left a new, empty instance
 func (cd *ContentTypeDecoders) DecodeBody(req *rest.Request, trace tracing.Tracer) error {
 	switch req.Raw.Method {
 	case "GET", "DELETE", "HEAD":
@@ -88,7 +157,7 @@ func (cd *ContentTypeDecoders) DecodeBody(req *rest.Request, trace tracing.Trace
 	}
 
 	ctype := req.Raw.Header["Content-Type"]
-	decoder := cd.Get(ctype)
+	decoder, contentType := cd.get(ctype)
 
 	//new empty instance
 	body := req.Definition.RequestBody()
@@ -100,29 +169,71 @@ func (cd *ContentTypeDecoders) DecodeBody(req *rest.Request, trace tracing.Trace
 		return err
 	}
 
-	if body != nil {
+	if body == nil {
+		return nil
+	} else if isBytes(reflect.TypeOf(body)) {
+		//if body type is castable to []byte, then we don't encode, just set directly
+		req.Body = bts
+		return nil
+	} else if len(bts) == 0 {
+		req.Body = body
+		return nil
+	}
 
-		if isBytes(reflect.TypeOf(body)) {
-			//if body type is castable to []byte, then we don't encode, just set directly
-			req.Body = bts
-			return nil
-		} else if decoder != nil {
-			decoder.Decode(bytes.NewReader(bts), &body, trace)
-			req.Body = body
-		} else if containsType(ctype, "application/x-www-form-urlencoded") {
-			req.Raw.ParseForm()
-			if err := util.MapHeaderToStruct(req.Raw.Form, &body); err != nil {
-				return err
-			}
-			trace.Annotate(tracing.FromRequestData, "body", fmt.Sprintf("%+v", body))
-			req.Body = body
-		} else {
-			if err = json.Unmarshal(bts, &body); err != nil {
-				return err
+	strict := false
+	if definition := rest.DefinitionOf(req.Definition); definition != nil {
+		strict = definition.StrictBody
+	}
+
+	if decoder != nil {
+		if bts, err = rest.DecodeCharset(bts, contentType); err != nil {
+			return bodyError(err)
+		}
+		decode := decoder.Decode
+		if strict && decoder.Strict != nil {
+			decode = decoder.Strict
+		}
+		if err := decode(bytes.NewReader(bts), &body, trace); err != nil {
+			return bodyError(err)
+		}
+	} else if containsType(ctype, "application/x-www-form-urlencoded") {
+		//the form is parsed from the body that's been read
+		req.Raw.Body = ioutil.NopCloser(bytes.NewReader(bts))
+		req.Raw.ParseForm()
+		if err := util.MapHeaderToStruct(req.Raw.Form, &body); err != nil {
+			return bodyError(err)
+		}
+	} else {
+		if len(ctype) > 0 {
+			if bts, err = rest.DecodeCharset(bts, ctype[0]); err != nil {
+				return bodyError(err)
 			}
-			trace.Annotate(tracing.FromRequestData, "body", fmt.Sprintf("%s", bts))
-			req.Body = body
+		}
+		decode := rest.JsonCodec{}.Decode
+		if strict {
+			decode = rest.JsonCodec{}.DecodeStrict
+		}
+		if err = decode(bts, &body); err != nil {
+			return bodyError(err)
 		}
 	}
+	req.Body = body
 	return nil
 }
+
+// bodyError is the rest.Malformed Error of a body that failed to decode, with the offset, field
+// and expected type of a rest.DecodeError as its details
+func bodyError(err error) error {
+	var de *rest.DecodeError
+	if !errors.As(err, &de) {
+		return rest.NewError(rest.Malformed, "malformed", "Failed to decode request body for the provided Content-Type").Wrap(err)
+	}
+	e := rest.NewError(rest.Malformed, de.Code, de.Error()).WithDetail("offset", de.Offset)
+	if de.Field != "" {
+		e.WithDetail("field", de.Field)
+	}
+	if de.Expected != "" {
+		e.WithDetail("expected", de.Expected)
+	}
+	return e.Wrap(err)
+}
@@ -3,6 +3,8 @@ package handling_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"reflect"
@@ -87,4 +89,83 @@ var _ = Describe("ContentTypeDecoder", func() {
 		Expect(request.Body).To(Equal(data))
 	})
 
+	It("should decode json whatever its parameters", func() {
+		decoded := 0
+		decoders.Set(&ContentTypeDecoder{ContentType: "application/json", Decode: func(r io.Reader, v interface{}, t tracing.Tracer) error {
+			decoded++
+			return JsonDecoder(r, v, t)
+		}})
+		request.Raw.Header["Content-Type"] = []string{"application/json; charset=utf-8"}
+		setBody(json.Marshal(requestBody))
+		Expect(decoders.DecodeBody(request, tracer)).To(Succeed())
+		Expect(decoded).To(Equal(1))
+		Expect(request.Body.(*RequestBody).Message).To(Equal(requestBody.Message))
+	})
+
+	It("should fall back to the decoder of the suffix, then of the wildcards", func() {
+		Expect(decoders.Get([]string{"application/vnd.orders+json"}).ContentType).To(Equal("application/json"))
+		Expect(decoders.Get([]string{"Application/Atom+XML; charset=utf-8"}).ContentType).To(Equal("application/xml"))
+		Expect(decoders.Get([]string{"text/markdown"})).To(BeNil())
+
+		decoders.Set(&ContentTypeDecoder{ContentType: "text/*", Decode: JsonDecoder})
+		Expect(decoders.Get([]string{"text/markdown"}).ContentType).To(Equal("text/*"))
+		Expect(decoders.Get([]string{"text/xml"}).ContentType).To(Equal(rest.ContentTypeTextXml))
+	})
+
+	It("should decode text of another charset", func() {
+		request.Raw.Header["Content-Type"] = []string{"application/json; charset=ISO-8859-1"}
+		setBody([]byte("{\"message\":\"caf\xe9\"}"), nil)
+		Expect(decoders.DecodeBody(request, tracer)).To(Succeed())
+		Expect(request.Body.(*RequestBody).Message).To(Equal("café"))
+
+		request.Raw.Header["Content-Type"] = []string{"application/json; charset=klingon"}
+		setBody([]byte("{}"), nil)
+		Expect(decoders.DecodeBody(request, tracer)).ToNot(Succeed())
+	})
+
+	It("should detect a form whatever its parameters", func() {
+		request.Raw.Method = "POST"
+		request.Raw.Header["Content-Type"] = []string{"application/x-www-form-urlencoded; charset=utf-8"}
+		setBody([]byte("message=hi"), nil)
+		Expect(decoders.DecodeBody(request, tracer)).To(Succeed())
+		Expect(request.Raw.Form.Get("message")).To(Equal("hi"))
+		Expect(request.Body).To(BeAssignableToTypeOf(&RequestBody{}))
+	})
+
+	It("should surface the errors of the decoder as a Malformed error", func() {
+		request.Raw.Header["Content-Type"] = []string{"application/json"}
+		setBody([]byte(`{"message": 1}`), nil)
+		err := decoders.DecodeBody(request, tracer)
+		Expect(errors.Is(err, rest.Malformed)).To(BeTrue())
+		var decodeErr *rest.DecodeError
+		Expect(errors.As(err, &decodeErr)).To(BeTrue())
+		Expect(decodeErr.Field).To(Equal("message"))
+	})
+
+	It("should surface the errors of any codec", func() {
+		request.Raw.Header["Content-Type"] = []string{"application/xml"}
+		setBody([]byte(`<broken`), nil)
+		err := decoders.DecodeBody(request, tracer)
+		Expect(errors.Is(err, rest.Malformed)).To(BeTrue())
+	})
+
+	It("should leave an empty body empty", func() {
+		request.Raw.Header["Content-Type"] = []string{"application/json"}
+		setBody([]byte{}, nil)
+		Expect(decoders.DecodeBody(request, tracer)).To(Succeed())
+		Expect(request.Body).To(Equal(&RequestBody{}))
+	})
+
+	It("should decode strictly for a StrictBody", func() {
+		resource.StrictBody = true
+		request.Raw.Header["Content-Type"] = []string{"application/json"}
+		setBody([]byte(`{"message":"a","other":1}`), nil)
+		Expect(decoders.DecodeBody(request, tracer)).To(MatchError(ContainSubstring("unknown field other")))
+	})
+
+	It("should default to strict json for a StrictBody", func() {
+		resource.StrictBody = true
+		setBody([]byte(`{"message":"a","other":1}`), nil)
+		Expect(decoders.DecodeBody(request, tracer)).To(MatchError(ContainSubstring("unknown field other")))
+	})
 })
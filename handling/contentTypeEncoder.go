@@ -1,6 +1,11 @@
 package handling
 
+import "io"
+
 type ContentTypeEncoder struct {
 	ContentType string
 	Encode      func(v interface{}) ([]byte, error)
+	// Stream, when set, writes the successful replies to the client as they're encoded, such as
+	// the rows of a list
+	Stream func(w io.Writer, v interface{}) error
 }
@@ -1,10 +1,11 @@
 package handling
 
 import (
-	"encoding/json"
 	"errors"
 	"io"
 	"io/ioutil"
+
+	"github.com/gotgo/gokn/rest"
 )
 
 type ContentTypeEncoders struct {
@@ -28,11 +29,18 @@ func NewContentTypeEncoders() *ContentTypeEncoders {
 
 	cd.library[json.ContentType] = json
 	cd.library[text.ContentType] = text
+	cd.SetCodec(rest.NewXmlCodec(), rest.ContentTypeXml, rest.ContentTypeTextXml)
+	cd.SetCodec(rest.MsgpackCodec{}, rest.ContentTypeMsgpack, rest.ContentTypeXMsgpack)
+	cd.SetCodec(rest.CborCodec{})
+	cd.SetCodec(rest.ProtobufCodec{})
+	cd.SetCodec(rest.NdjsonCodec{})
+	cd.SetCodec(rest.CsvCodec{})
 	return cd
 }
 
+// jsonEncoder encodes with encoding/json, and proto.Messages with protojson
 func jsonEncoder(v interface{}) ([]byte, error) {
-	if bytes, err := json.Marshal(&v); err != nil {
+	if bytes, err := (rest.JsonCodec{}).Encode(&v); err != nil {
 		return nil, err
 	} else {
 		return bytes, nil
@@ -47,8 +55,59 @@ func plainTextEncoder(v interface{}) ([]byte, error) {
 	}
 }
 
+// Set registers the encoder by the media type of its ContentType, which may be a wildcard
+// such as text/*
 func (cte *ContentTypeEncoders) Set(encoder *ContentTypeEncoder) {
-	cte.library[encoder.ContentType] = encoder
+	cte.library[essence(encoder.ContentType)] = encoder
+}
+
+// encoder is the encoder of the content type, or of the first of its rest.MediaType Lookups
+// that has one, or nil
+func (cte *ContentTypeEncoders) encoder(contentType string) *ContentTypeEncoder {
+	mt, err := rest.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	for _, key := range mt.Lookups() {
+		if encoder := cte.library[key]; encoder != nil {
+			return encoder
+		}
+	}
+	return nil
+}
+
+// SetCodec encodes the content types, or the codec's own when none are given, with the codec.
+// A rest.StreamCodec also streams them
+func (cte *ContentTypeEncoders) SetCodec(codec rest.Codec, contentTypes ...string) {
+	if len(contentTypes) == 0 {
+		contentTypes = []string{codec.ContentType()}
+	}
+	for _, ct := range contentTypes {
+		encoder := &ContentTypeEncoder{ContentType: ct, Encode: codec.Encode}
+		if sc, ok := codec.(rest.StreamCodec); ok {
+			encoder.Stream = sc.EncodeStream
+		}
+		cte.Set(encoder)
+	}
+}
+
+// Streams is true when the content type has a Stream encoder and the data isn't bytes, which
+// pass through
+func (cte *ContentTypeEncoders) Streams(data interface{}, contentType string) bool {
+	switch data.(type) {
+	case nil, []byte, io.Reader:
+		return false
+	}
+	encoder := cte.encoder(contentType)
+	return encoder != nil && encoder.Stream != nil
+}
+
+// Stream writes the data to w as it's encoded, see Streams
+func (cte *ContentTypeEncoders) Stream(w io.Writer, data interface{}, contentType string) error {
+	if encoder := cte.encoder(contentType); encoder != nil && encoder.Stream != nil {
+		return encoder.Stream(w, data)
+	}
+	return errors.New("Stream Fail.  No stream encoder for contentType " + contentType)
 }
 
 func (cte *ContentTypeEncoders) Encode(data interface{}, contentType string) ([]byte, error) {
@@ -64,7 +123,7 @@ func (cte *ContentTypeEncoders) Encode(data interface{}, contentType string) ([]
 		}
 	}
 
-	if encoder := cte.library[contentType]; encoder != nil {
+	if encoder := cte.encoder(contentType); encoder != nil {
 		return encoder.Encode(data)
 	}
 
@@ -0,0 +1,81 @@
+package handling
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gotgo/gokn/rest"
+)
+
+// Stages of the handling pipeline that can fail before the handler replies
+const (
+	FailureArgs   = "args"
+	FailureBody   = "body"
+	FailureEncode = "encode"
+	FailurePanic  = "panic"
+)
+
+// Middleware wraps the http handler of every endpoint bound after the call to Use
+type Middleware func(next http.HandlerFunc) http.HandlerFunc
+
+// Exchange is the record of one request and reply through a bound endpoint.  Middleware
+// gets it with ExchangeOf, it is complete once next has returned.
+type Exchange struct {
+	Endpoint rest.ServerResource
+	// Path is the bound path template, including the resource root
+	Path  string
+	Start time.Time
+	// Request is nil when the pipeline failed before the request was converted
+	Request *rest.Request
+	// Response is the reply set by the handler, nil when it wasn't called
+	Response      *rest.Response
+	StatusCode    int
+	StatusMessage string
+	ContentType   string
+	// RequestBody and ResponseBody are the raw bytes received and sent, a streamed reply has
+	// no ResponseBody unless KeepStream is set before it's sent
+	RequestBody  []byte
+	ResponseBody []byte
+	// BytesSent is the size of the reply body, streamed or not
+	BytesSent  int
+	Streamed   bool
+	KeepStream bool
+	Duration   time.Duration
+	// TraceContext is the span of this request
+	TraceContext *rest.TraceContext
+	RequestId    string
+	// Failure is the pipeline stage that failed, if any
+	Failure string
+	Panic   interface{}
+}
+
+type exchangeKey struct{}
+
+// ExchangeOf returns the Exchange for a request handled by a bound endpoint, or nil
+func ExchangeOf(r *http.Request) *Exchange {
+	x, _ := r.Context().Value(exchangeKey{}).(*Exchange)
+	return x
+}
+
+// Use adds middleware to every endpoint bound afterwards, the first added is the outermost
+func (root *RootHandler) Use(middleware ...Middleware) {
+	root.middleware = append(root.middleware, middleware...)
+}
+
+// chain wraps the endpoint handler in the middleware and starts the Exchange
+func (root *RootHandler) chain(endpoint rest.ServerResource, path string, handler http.HandlerFunc) func(http.ResponseWriter, *http.Request) {
+	next := handler
+	for i := len(root.middleware) - 1; i >= 0; i-- {
+		next = root.middleware[i](next)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		x := &Exchange{
+			Endpoint: endpoint,
+			Path:     path,
+			Start:    time.Now(),
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), exchangeKey{}, x)))
+	}
+}
@@ -2,6 +2,7 @@ package handling
 
 import (
 	"net/http"
+	"reflect"
 
 	"github.com/gotgo/gokn/rest"
 )
@@ -11,3 +12,14 @@ type BindingFunc func(rest.HandlerFunc) func(*rest.Request, rest.Responder)
 type SimpleRouter interface {
 	RegisterRoute(verb, path string, f func(http.ResponseWriter, *http.Request))
 }
+
+// getEndpoint defines the built in GET resources that reply with json
+func getEndpoint(resourceT string, responseBody interface{}) rest.ServerResource {
+	def := &rest.ResourceDef{
+		ResourceT:    resourceT,
+		Verb:         "GET",
+		ResponseBody: reflect.TypeOf(responseBody),
+	}
+	ct := []string{rest.ContentTypeJson}
+	return rest.NewServerResource(def, ct, ct)
+}
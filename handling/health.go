@@ -0,0 +1,174 @@
+package handling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gotgo/gokn/rest"
+)
+
+const (
+	HealthStatusOk   = "ok"
+	HealthStatusFail = "fail"
+
+	defaultHealthTimeout = 5 * time.Second
+)
+
+var (
+	// HealthzEndpoint is the liveness resource, is the process able to serve at all
+	HealthzEndpoint = getEndpoint("/healthz", HealthReport{})
+	// ReadyzEndpoint is the readiness resource, are the dependencies needed to serve available
+	ReadyzEndpoint = getEndpoint("/readyz", HealthReport{})
+)
+
+// HealthCheck is a named probe.  Check should return once ctx is done, a check that
+// ignores ctx is reported as failed when the timeout expires but is left running
+type HealthCheck struct {
+	Name    string
+	Timeout time.Duration
+	Check   func(ctx context.Context) error
+}
+
+type HealthResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type HealthReport struct {
+	Status string          `json:"status"`
+	Checks []*HealthResult `json:"checks"`
+}
+
+func (hr *HealthReport) Healthy() bool {
+	return hr.Status == HealthStatusOk
+}
+
+// HealthChecks is a set of checks that are run concurrently and aggregated into a single
+// HealthReport. It is a GetHandler, so it can be bound directly or with BindHealth
+type HealthChecks struct {
+	// DefaultTimeout applies to checks added without a timeout
+	DefaultTimeout time.Duration
+	lock           sync.RWMutex
+	checks         []*HealthCheck
+}
+
+func NewHealthChecks() *HealthChecks {
+	return &HealthChecks{
+		DefaultTimeout: defaultHealthTimeout,
+	}
+}
+
+// Add registers a check, a timeout of 0 uses the DefaultTimeout
+func (hc *HealthChecks) Add(name string, timeout time.Duration, check func(ctx context.Context) error) *HealthChecks {
+	hc.lock.Lock()
+	defer hc.lock.Unlock()
+	hc.checks = append(hc.checks, &HealthCheck{
+		Name:    name,
+		Timeout: timeout,
+		Check:   check,
+	})
+	return hc
+}
+
+// Run executes every check concurrently and waits for all of them to finish or time out
+func (hc *HealthChecks) Run(ctx context.Context) *HealthReport {
+	hc.lock.RLock()
+	checks := make([]*HealthCheck, len(hc.checks))
+	copy(checks, hc.checks)
+	hc.lock.RUnlock()
+
+	report := &HealthReport{
+		Status: HealthStatusOk,
+		Checks: make([]*HealthResult, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check *HealthCheck) {
+			defer wg.Done()
+			report.Checks[i] = hc.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != HealthStatusOk {
+			report.Status = HealthStatusFail
+		}
+	}
+	return report
+}
+
+func (hc *HealthChecks) run(parent context.Context, check *HealthCheck) *HealthResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = hc.DefaultTimeout
+	}
+	if timeout <= 0 {
+		timeout = defaultHealthTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- check.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := &HealthResult{
+		Name:     check.Name,
+		Status:   HealthStatusOk,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		result.Status = HealthStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Get replies 200 with the report when every check passes, otherwise 503 with the report
+func (hc *HealthChecks) Get(req *rest.Request, resp rest.Responder) {
+	ctx := context.Background()
+	if req != nil && req.Raw != nil {
+		ctx = req.Raw.Context()
+	}
+
+	report := hc.Run(ctx)
+	resp.SetBody(report)
+	if !report.Healthy() {
+		resp.SetStatus(http.StatusServiceUnavailable, "Service Unavailable", errors.New("health check failed"))
+	}
+}
+
+// BindHealth binds the liveness checks to /healthz and the readiness checks to /readyz.
+// Probes are bound anonymously, regardless of the RootHandler Binder. Either set can be nil.
+func (root *RootHandler) BindHealth(router SimpleRouter, liveness, readiness *HealthChecks, resourceRoot string) {
+	if liveness != nil {
+		root.bind(router, HealthzEndpoint, liveness, resourceRoot, AnonymousHandler)
+	}
+	if readiness != nil {
+		root.bind(router, ReadyzEndpoint, readiness, resourceRoot, AnonymousHandler)
+	}
+}
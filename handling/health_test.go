@@ -0,0 +1,120 @@
+package handling_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	. "github.com/gotgo/gokn/handling"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HealthChecks", func() {
+
+	var (
+		checks *HealthChecks
+		pass   func(context.Context) error
+		fail   func(context.Context) error
+	)
+
+	BeforeEach(func() {
+		checks = NewHealthChecks()
+		pass = func(context.Context) error { return nil }
+		fail = func(context.Context) error { return errors.New("db down") }
+	})
+
+	It("should be ok with no checks", func() {
+		report := checks.Run(context.Background())
+		Expect(report.Healthy()).To(BeTrue())
+		Expect(report.Checks).To(BeEmpty())
+	})
+
+	It("should aggregate the results in the order added", func() {
+		checks.Add("cache", 0, pass).Add("db", 0, fail)
+		report := checks.Run(context.Background())
+		Expect(report.Healthy()).To(BeFalse())
+		Expect(report.Checks).To(HaveLen(2))
+		Expect(report.Checks[0].Name).To(Equal("cache"))
+		Expect(report.Checks[0].Status).To(Equal(HealthStatusOk))
+		Expect(report.Checks[1].Name).To(Equal("db"))
+		Expect(report.Checks[1].Error).To(Equal("db down"))
+	})
+
+	It("should fail a check that exceeds its timeout", func() {
+		checks.Add("slow", 10*time.Millisecond, func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		})
+		report := checks.Run(context.Background())
+		Expect(report.Healthy()).To(BeFalse())
+		Expect(report.Checks[0].Error).To(Equal(context.DeadlineExceeded.Error()))
+	})
+
+	It("should run checks concurrently", func() {
+		sleep := func(context.Context) error {
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		}
+		checks.Add("a", 0, sleep).Add("b", 0, sleep).Add("c", 0, sleep)
+		start := time.Now()
+		checks.Run(context.Background())
+		Expect(time.Since(start)).To(BeNumerically("<", 140*time.Millisecond))
+	})
+
+	It("should report a panicking check as failed", func() {
+		checks.Add("boom", 0, func(context.Context) error { panic("boom") })
+		report := checks.Run(context.Background())
+		Expect(report.Healthy()).To(BeFalse())
+	})
+
+	Context("BindHealth", func() {
+		var (
+			root   *RootHandler
+			router *TestRouter
+			writer *TestResponseWriter
+		)
+
+		BeforeEach(func() {
+			root = NewRootHandler()
+			root.Binder = func(h rest.HandlerFunc) func(*rest.Request, rest.Responder) {
+				return func(req *rest.Request, resp rest.Responder) {
+					resp.SetStatus(http.StatusUnauthorized, "Unauthorized", nil)
+				}
+			}
+			router = NewTestRouter()
+			writer = new(TestResponseWriter)
+		})
+
+		It("should bind healthz and readyz anonymously", func() {
+			root.BindHealth(router, checks, NewHealthChecks(), "")
+			Expect(router.GetCount).To(Equal(2))
+
+			router.Handlers[0](writer, &http.Request{Method: "GET"})
+			Expect(writer.WriteHeaderCode).To(Equal(0))
+
+			report := &HealthReport{}
+			Expect(json.Unmarshal(writer.WriteBytes, report)).To(BeNil())
+			Expect(report.Status).To(Equal(HealthStatusOk))
+		})
+
+		It("should reply 503 with the report when a check fails", func() {
+			checks.Add("db", 0, fail)
+			root.BindHealth(router, nil, checks, "")
+			Expect(router.GetCount).To(Equal(1))
+
+			router.Handlers[0](writer, &http.Request{Method: "GET"})
+			Expect(writer.WriteHeaderCode).To(Equal(http.StatusServiceUnavailable))
+
+			report := &HealthReport{}
+			Expect(json.Unmarshal(writer.WriteBytes, report)).To(BeNil())
+			Expect(report.Status).To(Equal(HealthStatusFail))
+			Expect(report.Checks[0].Name).To(Equal("db"))
+		})
+	})
+})
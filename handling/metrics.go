@@ -0,0 +1,88 @@
+package handling
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+
+	"github.com/gotgo/gokn/metering"
+	"github.com/gotgo/gokn/rest"
+)
+
+// MetricsEndpoint exposes a metering.Registry in the Prometheus text format
+var MetricsEndpoint = func() rest.ServerResource {
+	def := &rest.ResourceDef{
+		ResourceT:    "/metrics",
+		Verb:         "GET",
+		ResponseBody: reflect.TypeOf([]byte{}),
+	}
+	return rest.NewServerResource(def, nil, []string{metering.TextContentType})
+}()
+
+// EndpointMetrics records RED metrics for every endpoint it is used on
+//
+//	Example:
+//
+//		registry := metering.NewRegistry()
+//		root.Use(handling.NewEndpointMetrics(registry).Middleware)
+//		root.BindAll(router, handlers, "/v1")
+//		root.BindMetrics(router, registry, "")
+type EndpointMetrics struct {
+	*metering.RED
+}
+
+func NewEndpointMetrics(registry *metering.Registry) *EndpointMetrics {
+	return &EndpointMetrics{
+		RED: metering.NewRED(registry, metering.ServerPrefix),
+	}
+}
+
+func (em *EndpointMetrics) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		x := ExchangeOf(r)
+		if x == nil {
+			next(w, r)
+			return
+		}
+
+		verb, resource := x.Endpoint.Verb(), x.Endpoint.ResourceT()
+		em.InFlight.Inc(verb, resource)
+		defer em.InFlight.Dec(verb, resource)
+
+		next(w, r)
+
+		class := metering.StatusClass(x.StatusCode)
+		em.Requests.Inc(verb, resource, class)
+		em.Latency.Observe(x.Duration.Seconds(), verb, resource, class)
+		em.RequestSize.Observe(float64(len(x.RequestBody)), verb, resource)
+		em.ResponseSize.Observe(float64(x.BytesSent), verb, resource)
+
+		switch x.Failure {
+		case FailureArgs, FailureBody:
+			em.DecodeFailures.Inc(verb, resource)
+		case FailureEncode:
+			em.EncodeFailures.Inc(verb, resource)
+		case FailurePanic:
+			em.Panics.Inc(verb, resource)
+		}
+	}
+}
+
+type metricsHandler struct {
+	registry *metering.Registry
+}
+
+func (mh *metricsHandler) Get(req *rest.Request, resp rest.Responder) {
+	var buf bytes.Buffer
+	if err := mh.registry.WriteText(&buf); err != nil {
+		resp.SetStatus(http.StatusInternalServerError, "Internal Server Error", err)
+		return
+	}
+	resp.SetContentType(metering.TextContentType)
+	resp.SetBody(buf.Bytes())
+}
+
+// BindMetrics binds /metrics anonymously, like the health probes
+func (root *RootHandler) BindMetrics(router SimpleRouter, registry *metering.Registry, resourceRoot string) {
+	root.bind(router, MetricsEndpoint, &metricsHandler{registry: registry}, resourceRoot, AnonymousHandler)
+}
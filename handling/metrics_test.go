@@ -0,0 +1,90 @@
+package handling_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	. "github.com/gotgo/gokn/handling"
+	"github.com/gotgo/gokn/metering"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type PanickingHandler struct{}
+
+func (ph *PanickingHandler) Get(req *rest.Request, resp rest.Responder) {
+	panic(errors.New("boom"))
+}
+
+var _ = Describe("EndpointMetrics", func() {
+
+	var (
+		root     *RootHandler
+		router   *TestRouter
+		registry *metering.Registry
+		metrics  *EndpointMetrics
+	)
+
+	BeforeEach(func() {
+		root = NewRootHandler()
+		router = NewTestRouter()
+		registry = metering.NewRegistry()
+		metrics = NewEndpointMetrics(registry)
+		root.Use(metrics.Middleware)
+	})
+
+	It("should count requests by verb, template and status class", func() {
+		root.Bind(router, getSpec("/items/{id}", "POST"), NewTestHandler(), "")
+		body := []byte(`{"Message":"hi"}`)
+		for i := 0; i < 2; i++ {
+			request := &http.Request{
+				Method: "POST",
+				Header: http.Header{"Content-Type": []string{"application/json"}},
+				Body:   ioutil.NopCloser(bytes.NewReader(body)),
+			}
+			router.Handlers[0](new(TestResponseWriter), request)
+		}
+
+		Expect(metrics.Requests.Value("POST", "/items/{id}", "2xx")).To(Equal(2.0))
+		Expect(metrics.Latency.Count("POST", "/items/{id}", "2xx")).To(Equal(uint64(2)))
+		Expect(metrics.RequestSize.Count("POST", "/items/{id}")).To(Equal(uint64(2)))
+		Expect(metrics.InFlight.Value("POST", "/items/{id}")).To(Equal(0.0))
+	})
+
+	It("should count panics separately", func() {
+		root.Bind(router, getSpec("/boom", "GET"), new(PanickingHandler), "")
+		router.Handlers[0](new(TestResponseWriter), &http.Request{Method: "GET"})
+
+		Expect(metrics.Panics.Value("GET", "/boom")).To(Equal(1.0))
+		Expect(metrics.Requests.Value("GET", "/boom", "5xx")).To(Equal(1.0))
+	})
+
+	It("should count encode failures", func() {
+		root.Encoders.Set(&ContentTypeEncoder{
+			ContentType: "fail",
+			Encode: func(v interface{}) ([]byte, error) {
+				return nil, errors.New("fail")
+			},
+		})
+		ct := []string{"fail"}
+		root.Bind(router, rest.NewServerResource(&rest.ResourceDef{ResourceT: "/enc", Verb: "GET"}, ct, ct), NewTestHandler(), "")
+		router.Handlers[0](new(TestResponseWriter), &http.Request{Method: "GET"})
+
+		Expect(metrics.EncodeFailures.Value("GET", "/enc")).To(Equal(1.0))
+	})
+
+	It("should expose the registry", func() {
+		root.Bind(router, getSpec("/items", "GET"), NewTestHandler(), "")
+		router.Handlers[0](new(TestResponseWriter), &http.Request{Method: "GET"})
+		root.BindMetrics(router, registry, "")
+
+		writer := new(TestResponseWriter)
+		router.Handlers[1](writer, &http.Request{Method: "GET"})
+		Expect(string(writer.WriteBytes)).To(ContainSubstring(
+			`gokn_http_server_requests_total{method="GET",resource="/items",status_class="2xx"} 1`))
+	})
+})
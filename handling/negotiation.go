@@ -0,0 +1,61 @@
+package handling
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gotgo/gokn/rest"
+)
+
+// mediaRange is a media range of an Accept header, with its quality
+type mediaRange struct {
+	rest.MediaType
+	quality float64
+}
+
+// parseAccept are the media ranges of an Accept header, by quality and then in order, the
+// ranges of quality 0 are left out
+func parseAccept(accept string) []mediaRange {
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		mt, err := rest.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := mt.Params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{MediaType: mt, quality: quality})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+	return ranges
+}
+
+// matches is true of the content types in the range, such as */* or text/*, whatever their
+// parameters
+func (mr mediaRange) matches(contentType string) bool {
+	mt, err := rest.ParseMediaType(contentType)
+	return err == nil && mr.Matches(mt)
+}
+
+// negotiate is the content type of the reply, the endpoint's content type that the Accept header
+// prefers, or "" when none is acceptable.  Only the endpoint's content types are ever chosen,
+// a type with an encoder the endpoint doesn't declare isn't
+func negotiate(accept string, contentTypes []string) string {
+	for _, mr := range parseAccept(accept) {
+		for _, ct := range contentTypes {
+			if mr.matches(ct) {
+				return ct
+			}
+		}
+	}
+	return ""
+}
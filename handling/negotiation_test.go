@@ -0,0 +1,338 @@
+package handling_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+
+	. "github.com/gotgo/gokn/handling"
+	"github.com/gotgo/gokn/rest"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type Note struct {
+	XMLName xml.Name `xml:"note" json:"-"`
+	Id      int      `xml:"id,attr" json:"id"`
+	Text    string   `xml:"text" json:"text"`
+}
+
+type NoteHandler struct{}
+
+func (nh *NoteHandler) Get(req *rest.Request, resp rest.Responder) {
+	resp.SetBody([]*Note{{Id: 1, Text: "a"}, {Id: 2, Text: "b"}})
+}
+
+func (nh *NoteHandler) Post(req *rest.Request, resp rest.Responder) {
+	note := req.Body.(*Note)
+	if note.Text == "" {
+		rest.ReplyError(resp, rest.NewError(rest.Validation, "text_missing", "a note needs text"))
+		return
+	}
+	note.Id = 7
+	resp.SetBody(note)
+}
+
+type ShoutHandler struct{}
+
+func (sh *ShoutHandler) Post(req *rest.Request, resp rest.Responder) {
+	resp.SetBody(wrapperspb.String(strings.ToUpper(req.Body.(*wrapperspb.StringValue).Value)))
+}
+
+type ReportHandler struct {
+	notes chan *Note
+}
+
+func (rh *ReportHandler) Get(req *rest.Request, resp rest.Responder) {
+	resp.SetBody(rh.notes)
+}
+
+// BrokenReportHandler's iterator panics after its first note
+type BrokenReportHandler struct{}
+
+func (bh *BrokenReportHandler) Get(req *rest.Request, resp rest.Responder) {
+	resp.SetBody(func(yield func(*Note) bool) {
+		yield(&Note{Id: 1, Text: "a"})
+		panic("producer failed")
+	})
+}
+
+type WordsHandler struct{}
+
+func (wh *WordsHandler) Get(req *rest.Request, resp rest.Responder) {
+	resp.SetBody([]string{"a"})
+}
+
+// verbRouter routes by verb and path
+type verbRouter map[string]func(http.ResponseWriter, *http.Request)
+
+func (vr verbRouter) RegisterRoute(verb, path string, f func(http.ResponseWriter, *http.Request)) {
+	vr[verb+" "+path] = f
+}
+
+func (vr verbRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f := vr[r.Method+" "+r.URL.Path]; f != nil {
+		f(w, r)
+	} else {
+		http.NotFound(w, r)
+	}
+}
+
+var _ = Describe("Content negotiation", func() {
+	var (
+		server *httptest.Server
+		client *rest.Client
+		spec   *rest.ResourceSpec
+	)
+
+	BeforeEach(func() {
+		spec = rest.NewResourceSpec(rest.ContentTypeJson).
+			WithContentTypes(rest.ContentTypeXml, rest.ContentTypeTextXml, rest.ContentTypeMsgpack, rest.ContentTypeCbor, "application/vnd.notes+json").
+			Use(&rest.ResourceDef{ResourceT: "/notes", Verb: "GET", ResponseBody: reflect.TypeOf([]*Note{})}).
+			Use(&rest.ResourceDef{ResourceT: "/notes", Verb: "POST", RequestBody: reflect.TypeOf(Note{}), ResponseBody: reflect.TypeOf(Note{})})
+
+		root := NewRootHandler()
+		router := make(verbRouter)
+		endpoints, _ := spec.ServeAll()
+		handlers := make(map[rest.ServerResource]rest.Handler)
+		for _, endpoint := range endpoints {
+			handlers[endpoint] = new(NoteHandler)
+		}
+		root.BindAll(router, handlers, "")
+		server = httptest.NewServer(router)
+
+		client = rest.NewClient()
+		client.Endpoints = []*rest.ResourceEndpoint{{Scheme: "http", Host: strings.TrimPrefix(server.URL, "http://")}}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should reply in the accepted content type", func() {
+		request, _ := http.NewRequest("GET", server.URL+"/notes", nil)
+		request.Header.Set("Accept", "text/html;q=0.9, text/xml")
+		resp, err := http.DefaultClient.Do(request)
+		Expect(err).To(BeNil())
+		Expect(resp.Header.Get("Content-Type")).To(Equal("text/xml"))
+
+		request.Header.Set("Accept", "*/*")
+		resp, err = http.DefaultClient.Do(request)
+		Expect(err).To(BeNil())
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+	})
+
+	It("should only reply in the endpoint's content types", func() {
+		jsonOnly := rest.NewResourceSpec(rest.ContentTypeJson).
+			Use(&rest.ResourceDef{ResourceT: "/notes", Verb: "GET", ResponseBody: reflect.TypeOf([]*Note{})})
+		endpoints, _ := jsonOnly.ServeAll()
+		router := make(verbRouter)
+		NewRootHandler().BindAll(router, map[rest.ServerResource]rest.Handler{endpoints[0]: new(NoteHandler)}, "")
+
+		browser := "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8"
+		for _, accept := range []string{browser, "text/plain, */*", "text/csv", "application/xml"} {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest("GET", "/notes", nil)
+			request.Header.Set("Accept", accept)
+			router.ServeHTTP(recorder, request)
+			Expect(recorder.Code).To(Equal(http.StatusOK), accept)
+			Expect(recorder.Header().Get("Content-Type")).To(Equal(rest.ContentTypeJson), accept)
+			Expect(recorder.Body.String()).To(HavePrefix(`[{"id":1,`), accept)
+		}
+	})
+
+	It("should not reply in an undeclared type that a suffix or wildcard has a codec for", func() {
+		request, _ := http.NewRequest("GET", server.URL+"/notes", nil)
+		request.Header.Set("Accept", "application/xhtml+xml, application/problem+json, image/*")
+		resp, err := http.DefaultClient.Do(request)
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal(rest.ContentTypeJson))
+	})
+
+	It("should encode a declared structured suffix with the codec of its syntax", func() {
+		request, _ := http.NewRequest("GET", server.URL+"/notes", nil)
+		request.Header.Set("Accept", "application/vnd.notes+json")
+		resp, err := http.DefaultClient.Do(request)
+		Expect(err).To(BeNil())
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/vnd.notes+json"))
+		body, _ := io.ReadAll(resp.Body)
+		Expect(string(body)).To(HavePrefix(`[{"id":1,`))
+
+		encoders := NewContentTypeEncoders()
+		bts, err := encoders.Encode(&Note{Id: 1}, "application/json; charset=utf-8")
+		Expect(err).To(BeNil())
+		Expect(string(bts)).To(Equal(`{"id":1,"text":""}`))
+	})
+
+	It("should send, accept and decode xml with the same ResourceSpec", func() {
+		client.ContentType = rest.ContentTypeXml
+
+		var notes []*Note
+		_, err := client.Fetch(spec.Get(nil), rest.NewRequestContext(), &notes)
+		Expect(err).To(BeNil())
+		Expect(notes).To(HaveLen(2))
+		Expect(notes[1].Text).To(Equal("b"))
+
+		created := new(Note)
+		resp, err := client.Fetch(spec.Post(nil, &Note{Text: "c"}), rest.NewRequestContext(), created)
+		Expect(err).To(BeNil())
+		Expect(resp.HttpResponse.Header.Get("Content-Type")).To(Equal(rest.ContentTypeXml))
+		Expect(created.Id).To(Equal(7))
+		Expect(created.Text).To(Equal("c"))
+	})
+
+	It("should send, accept and decode the binary codecs", func() {
+		for _, ct := range []string{rest.ContentTypeMsgpack, rest.ContentTypeCbor} {
+			client.ContentType = ct
+			created := new(Note)
+			resp, err := client.Fetch(spec.Post(nil, &Note{Text: "c"}), rest.NewRequestContext(), created)
+			Expect(err).To(BeNil())
+			Expect(resp.HttpResponse.Header.Get("Content-Type")).To(Equal(ct))
+			Expect(*created).To(Equal(Note{Id: 7, Text: "c"}))
+		}
+	})
+
+	It("should reconstruct an xml error", func() {
+		client.ContentType = rest.ContentTypeXml
+		_, err := client.Fetch(spec.Post(nil, &Note{}), rest.NewRequestContext(), new(Note))
+		Expect(errors.Is(err, rest.Validation)).To(BeTrue())
+		var domain *rest.Error
+		Expect(errors.As(err, &domain)).To(BeTrue())
+		Expect(domain.Code).To(Equal("text_missing"))
+	})
+})
+
+var _ = Describe("Protocol buffers", func() {
+	var (
+		server *httptest.Server
+		client *rest.Client
+		spec   *rest.ResourceSpec
+	)
+
+	BeforeEach(func() {
+		message := reflect.TypeOf(wrapperspb.StringValue{})
+		spec = rest.NewResourceSpec(rest.ContentTypeJson).
+			WithContentTypes(rest.ContentTypeProtobuf).
+			Use(&rest.ResourceDef{ResourceT: "/shout", Verb: "POST", RequestBody: message, ResponseBody: message})
+
+		root := NewRootHandler()
+		router := make(verbRouter)
+		endpoints, _ := spec.ServeAll()
+		root.BindAll(router, map[rest.ServerResource]rest.Handler{endpoints[0]: new(ShoutHandler)}, "")
+		server = httptest.NewServer(router)
+
+		client = rest.NewClient()
+		client.Endpoints = []*rest.ResourceEndpoint{{Scheme: "http", Host: strings.TrimPrefix(server.URL, "http://")}}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should serve messages as protobuf and as protojson", func() {
+		for _, ct := range []string{rest.ContentTypeProtobuf, ""} {
+			client.ContentType = ct
+			shouted := new(wrapperspb.StringValue)
+			_, err := client.Fetch(spec.Post(nil, wrapperspb.String("hi")), rest.NewRequestContext(), shouted)
+			Expect(err).To(BeNil())
+			Expect(shouted.Value).To(Equal("HI"))
+		}
+	})
+
+	It("should decode and encode protojson", func() {
+		resp, err := http.Post(server.URL+"/shout", rest.ContentTypeJson, strings.NewReader(`"hi"`))
+		Expect(err).To(BeNil())
+		body, _ := io.ReadAll(resp.Body)
+		Expect(string(body)).To(Equal(`"HI"`))
+	})
+})
+
+var _ = Describe("Streaming lists", func() {
+	var (
+		server *httptest.Server
+		client *rest.Client
+		spec   *rest.ResourceSpec
+		notes  chan *Note
+	)
+
+	BeforeEach(func() {
+		spec = rest.NewResourceSpec(rest.ContentTypeJson).
+			WithContentTypes(rest.ContentTypeNdjson, rest.ContentTypeCsv).
+			Use(&rest.ResourceDef{ResourceT: "/report", Verb: "GET", ResponseBody: reflect.TypeOf([]*Note{})})
+
+		notes = make(chan *Note)
+		root := NewRootHandler()
+		router := make(verbRouter)
+		endpoints, _ := spec.ServeAll()
+		root.BindAll(router, map[rest.ServerResource]rest.Handler{endpoints[0]: &ReportHandler{notes: notes}}, "")
+		server = httptest.NewServer(router)
+
+		client = rest.NewClient()
+		client.Endpoints = []*rest.ResourceEndpoint{{Scheme: "http", Host: strings.TrimPrefix(server.URL, "http://")}}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	readAsSent := func(contentType string) {
+		client.ContentType = contentType
+		go func() { notes <- &Note{Id: 1, Text: "a"} }()
+		items, err := rest.FetchItems[Note](client, spec.Get(nil), rest.NewRequestContext())
+		Expect(err).To(BeNil())
+		defer items.Close()
+		Expect(items.Next()).To(BeTrue())
+		Expect(items.Item()).To(Equal(Note{Id: 1, Text: "a"}))
+
+		//the server is still sending
+		notes <- &Note{Id: 2, Text: "b"}
+		Expect(items.Next()).To(BeTrue())
+		Expect(items.Item()).To(Equal(Note{Id: 2, Text: "b"}))
+
+		close(notes)
+		Expect(items.Next()).To(BeFalse())
+		Expect(items.Err()).To(BeNil())
+	}
+
+	It("should read each ndjson item as the server sends it", func() {
+		readAsSent(rest.ContentTypeNdjson)
+	})
+
+	It("should read each csv row as the server sends it", func() {
+		readAsSent(rest.ContentTypeCsv)
+	})
+
+	It("should abort a stream that panics once rows are sent", func() {
+		endpoints, _ := spec.ServeAll()
+		router := make(verbRouter)
+		NewRootHandler().BindAll(router, map[rest.ServerResource]rest.Handler{endpoints[0]: new(BrokenReportHandler)}, "")
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/report", nil)
+		request.Header.Set("Accept", rest.ContentTypeNdjson)
+
+		Expect(func() { router.ServeHTTP(recorder, request) }).To(PanicWith(http.ErrAbortHandler))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(Equal("{\"id\":1,\"text\":\"a\"}\n"))
+	})
+
+	It("should fail a stream that can't be encoded before it starts", func() {
+		spec = rest.NewResourceSpec(rest.ContentTypeJson).
+			WithContentTypes(rest.ContentTypeCsv).
+			Use(&rest.ResourceDef{ResourceT: "/words", Verb: "GET", ResponseBody: reflect.TypeOf([]string{})})
+		endpoints, _ := spec.ServeAll()
+		router := make(verbRouter)
+		NewRootHandler().BindAll(router, map[rest.ServerResource]rest.Handler{endpoints[0]: new(WordsHandler)}, "")
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/words", nil)
+		request.Header.Set("Accept", rest.ContentTypeCsv)
+		router.ServeHTTP(recorder, request)
+		Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+	})
+})
@@ -0,0 +1,137 @@
+package handling
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+
+	"github.com/gotgo/gokn/rest"
+)
+
+type PanicKind string
+
+// Kinds of recovered panic values
+const (
+	// PanicRuntime is a runtime.Error, eg nil dereference or index out of range
+	PanicRuntime PanicKind = "runtime"
+	// PanicAbort is http.ErrAbortHandler, the handler chose to abort the reply
+	PanicAbort  PanicKind = "abort"
+	PanicError  PanicKind = "error"
+	PanicString PanicKind = "string"
+	PanicOther  PanicKind = "other"
+)
+
+// RecoveredPanic is a panic recovered while handling a request
+type RecoveredPanic struct {
+	Value   interface{}
+	Kind    PanicKind
+	Message string
+	// Stack is the stack of the panicking goroutine only
+	Stack    []byte
+	Endpoint rest.ServerResource
+	// Request is nil when the panic happened before the request was converted
+	Request *rest.Request
+}
+
+// PanicHandler is called with every recovered panic, for alerting or to reply with a custom
+// error body.  The reply starts as a 500 Internal Server Error, the body is encoded with
+// the endpoint encoders.  http.ErrAbortHandler, and a panic once a streamed reply has sent
+// rows, aren't handled, they panic again with http.ErrAbortHandler for net/http to abort
+// the reply.
+//
+//	Example:
+//
+//		root.PanicHandler = func(p *handling.RecoveredPanic, reply rest.Responder) {
+//			alerts.Send(p.Message, p.Stack)
+//			reply.SetBody(&ApiError{Code: "internal", Message: "something went wrong"})
+//		}
+type PanicHandler func(p *RecoveredPanic, reply rest.Responder)
+
+func NewRecoveredPanic(value interface{}, stack []byte) *RecoveredPanic {
+	return &RecoveredPanic{
+		Value:   value,
+		Kind:    ClassifyPanic(value),
+		Message: panicMessage(value),
+		Stack:   stack,
+	}
+}
+
+func ClassifyPanic(value interface{}) PanicKind {
+	switch v := value.(type) {
+	case runtime.Error:
+		return PanicRuntime
+	case error:
+		if v == http.ErrAbortHandler {
+			return PanicAbort
+		}
+		return PanicError
+	case string:
+		return PanicString
+	}
+	return PanicOther
+}
+
+func panicMessage(value interface{}) string {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// handlePanic lets the PanicHandler replace the default reply
+func (root *RootHandler) handlePanic(writer http.ResponseWriter, response *responseData, p *RecoveredPanic) {
+	if root.PanicHandler == nil {
+		return
+	}
+
+	reply := &rest.Response{
+		Status:  response.StatusCode,
+		Message: response.StatusMessage,
+		Headers: make(map[string]string),
+	}
+	if !root.callPanicHandler(p, reply) {
+		return
+	}
+
+	response.StatusCode = reply.Status
+	response.StatusMessage = reply.Message
+	for k, v := range reply.Headers {
+		writer.Header().Set(k, v)
+	}
+	if reply.Body == nil {
+		return
+	}
+
+	contentType := reply.ContentType
+	if contentType == "" && p.Endpoint != nil {
+		if cts := p.Endpoint.ResponseContentTypes(); len(cts) > 0 {
+			contentType = cts[0]
+		}
+	}
+	if contentType == "" {
+		contentType = rest.ContentTypeJson
+	}
+	if bts, err := root.Encoders.Encode(reply.Body, contentType); err != nil {
+		root.Log.Error("failed to encode panic reply", err)
+	} else {
+		writer.Header().Set("Content-Type", contentType)
+		response.Data = bts
+	}
+}
+
+// callPanicHandler returns false if the PanicHandler panics too
+func (root *RootHandler) callPanicHandler(p *RecoveredPanic, reply *rest.Response) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			root.Log.Error("PanicHandler panicked", fmt.Errorf("%s", panicMessage(r)))
+			ok = false
+		}
+	}()
+	root.PanicHandler(p, reply)
+	return true
+}
@@ -0,0 +1,136 @@
+package handling_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gotgo/fw/tracing"
+	. "github.com/gotgo/gokn/handling"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type ApiError struct {
+	Code string `json:"code"`
+}
+
+// NilHandler dereferences nil, a runtime.Error panic
+type NilHandler struct {
+	Items map[string]*TestStruct
+}
+
+func (nh *NilHandler) Get(req *rest.Request, resp rest.Responder) {
+	resp.SetBody(nh.Items["missing"].Message)
+}
+
+// AbortHandler aborts the reply
+type AbortHandler struct{}
+
+func (ah *AbortHandler) Get(req *rest.Request, resp rest.Responder) {
+	panic(http.ErrAbortHandler)
+}
+
+var _ = Describe("Panics", func() {
+
+	Context("ClassifyPanic", func() {
+		It("should classify by type", func() {
+			var runtimeErr error
+			func() {
+				defer func() { runtimeErr = recover().(error) }()
+				var m map[string]*TestStruct
+				_ = m["x"].Message
+			}()
+
+			Expect(ClassifyPanic(runtimeErr)).To(Equal(PanicRuntime))
+			Expect(ClassifyPanic(http.ErrAbortHandler)).To(Equal(PanicAbort))
+			Expect(ClassifyPanic(errors.New("e"))).To(Equal(PanicError))
+			Expect(ClassifyPanic("s")).To(Equal(PanicString))
+			Expect(ClassifyPanic(42)).To(Equal(PanicOther))
+		})
+
+		It("should describe any value", func() {
+			Expect(NewRecoveredPanic(42, nil).Message).To(Equal("42"))
+			Expect(NewRecoveredPanic(fmt.Errorf("bad %d", 1), nil).Message).To(Equal("bad 1"))
+		})
+	})
+
+	Context("RootHandler", func() {
+		var (
+			root    *RootHandler
+			router  *TestRouter
+			writer  *TestResponseWriter
+			request *http.Request
+			caught  *RecoveredPanic
+		)
+
+		BeforeEach(func() {
+			caught = nil
+			root = NewRootHandler()
+			router = NewTestRouter()
+			writer = new(TestResponseWriter)
+			request, _ = http.NewRequest("GET", "/nil", nil)
+			root.Bind(router, getSpec("/nil", "GET"), new(NilHandler), "")
+		})
+
+		It("should reply 500 with the stack of the panicking goroutine", func() {
+			root.PanicHandler = func(p *RecoveredPanic, reply rest.Responder) {
+				caught = p
+			}
+			router.Handlers[0](writer, request)
+
+			Expect(writer.WriteHeaderCode).To(Equal(http.StatusInternalServerError))
+			Expect(caught.Kind).To(Equal(PanicRuntime))
+			Expect(caught.Endpoint.ResourceT()).To(Equal("/nil"))
+			Expect(caught.Request).ToNot(BeNil())
+			Expect(string(caught.Stack)).To(ContainSubstring("NilHandler"))
+			Expect(string(caught.Stack)).ToNot(ContainSubstring("goroutine 1 ["))
+		})
+
+		It("should send the body set by the PanicHandler", func() {
+			root.PanicHandler = func(p *RecoveredPanic, reply rest.Responder) {
+				reply.SetStatus(http.StatusServiceUnavailable, "try later", nil)
+				reply.SetBody(&ApiError{Code: "internal"})
+			}
+			router.Handlers[0](writer, request)
+
+			Expect(writer.WriteHeaderCode).To(Equal(http.StatusServiceUnavailable))
+			Expect(string(writer.WriteBytes)).To(Equal(`{"code":"internal"}`))
+		})
+
+		It("should survive a PanicHandler that panics", func() {
+			root.PanicHandler = func(p *RecoveredPanic, reply rest.Responder) {
+				reply.SetStatus(http.StatusTeapot, "", nil)
+				panic("again")
+			}
+			router.Handlers[0](writer, request)
+			Expect(writer.WriteHeaderCode).To(Equal(http.StatusInternalServerError))
+		})
+
+		It("should panic again with http.ErrAbortHandler, without a reply", func() {
+			var traced *tracing.TraceMessage
+			root.TraceHandler = func(t *tracing.TraceMessage) {
+				traced = t
+			}
+			root.PanicHandler = func(p *RecoveredPanic, reply rest.Responder) {
+				caught = p
+			}
+			root.Bind(router, getSpec("/abort", "GET"), new(AbortHandler), "")
+			request, _ = http.NewRequest("GET", "/abort", nil)
+
+			Expect(func() { router.Handlers[1](writer, request) }).To(PanicWith(http.ErrAbortHandler))
+			Expect(writer.WriteHeaderCode).To(Equal(0))
+			Expect(writer.WriteBytes).To(BeEmpty())
+			Expect(caught).To(BeNil())
+			Expect(traced).ToNot(BeNil())
+		})
+
+		It("should panic again after replying with RePanic", func() {
+			root.RePanic = true
+			Expect(func() { router.Handlers[0](writer, request) }).To(Panic())
+			Expect(writer.WriteHeaderCode).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
@@ -8,9 +8,11 @@ import (
 	"net/http"
 	"path"
 	"reflect"
-	"runtime"
+	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gotgo/fw/logging"
@@ -37,34 +39,120 @@ import (
 //		}
 //
 type RootHandler struct {
-	Log          logging.Logger `inject:""`
-	Binder       BindingFunc
+	Log    logging.Logger `inject:""`
+	Binder BindingFunc
+	// Propagator extracts the incoming trace context, nil uses the rest.DefaultPropagator
+	Propagator rest.Propagator
+	// TraceHeader and SpanHeader are the legacy trace headers, setting them to other than
+	// tr-trace & tr-span extracts from those headers when no Propagator is set
 	TraceHeader  string
 	SpanHeader   string
 	Encoders     *ContentTypeEncoders
 	Decoders     *ContentTypeDecoders
 	TraceHandler func(*tracing.TraceMessage)
+	// Sampler decides which requests reach the TraceHandler, nil traces them all
+	Sampler *Sampler
+	// Redactor cleans headers and bodies before they are traced, nil uses the rest.DefaultRedactor
+	Redactor *rest.Redactor
+	// MaxAnnotationBytes caps the size of a traced body, zero is no limit
+	MaxAnnotationBytes int
+	// PanicHandler is called with every panic recovered from a handler
+	PanicHandler PanicHandler
+	// RePanic panics again once the 500 reply is sent, so tests fail loudly
+	RePanic    bool
+	routes     []*RouteInfo
+	routesLock sync.Mutex
+	middleware []Middleware
 }
 
+// DefaultMaxAnnotationBytes is the largest body NewRootHandler traces before truncating
+const DefaultMaxAnnotationBytes = 4096
+
 func NewRootHandler() *RootHandler {
 	root := &RootHandler{
-		Log:          new(logging.NoOpLogger),
-		Binder:       AnonymousHandler,
-		TraceHeader:  traceHeader,
-		SpanHeader:   spanHeader,
-		Encoders:     NewContentTypeEncoders(),
-		Decoders:     NewContentTypeDecoders(),
-		TraceHandler: func(*tracing.TraceMessage) {},
+		Log:                new(logging.NoOpLogger),
+		Binder:             AnonymousHandler,
+		TraceHeader:        traceHeader,
+		SpanHeader:         spanHeader,
+		Encoders:           NewContentTypeEncoders(),
+		Decoders:           NewContentTypeDecoders(),
+		TraceHandler:       func(*tracing.TraceMessage) {},
+		MaxAnnotationBytes: DefaultMaxAnnotationBytes,
 	}
 
 	return root
 }
 
 const (
-	traceHeader = "tr-trace"
-	spanHeader  = "tr-span"
+	traceHeader = rest.LegacyTraceHeader
+	spanHeader  = rest.LegacySpanHeader
 )
 
+func (root *RootHandler) propagator() rest.Propagator {
+	if root.Propagator != nil {
+		return root.Propagator
+	}
+	if (root.TraceHeader != "" && root.TraceHeader != traceHeader) || (root.SpanHeader != "" && root.SpanHeader != spanHeader) {
+		return rest.Propagators{
+			&rest.LegacyPropagator{TraceHeader: root.TraceHeader, SpanHeader: root.SpanHeader},
+			rest.DefaultPropagator,
+		}
+	}
+	return rest.DefaultPropagator
+}
+
+// traceContext is the span for this request, a child of the caller's span when one was
+// propagated, which keeps the caller's sampling decision
+func (root *RootHandler) traceContext(r *http.Request, endpoint rest.ServerResource) *rest.TraceContext {
+	if incoming := root.propagator().Extract(r.Header); incoming != nil {
+		tc := incoming.Child()
+		if incoming.Deferred {
+			tc.Sampled = true
+			root.sample(endpoint, tc)
+		}
+		return tc
+	}
+	tc := rest.NewTraceContext()
+	root.sample(endpoint, tc)
+	return tc
+}
+
+// requestId is the caller's X-Request-ID when it is valid, otherwise a new one
+func requestId(r *http.Request) string {
+	if id := r.Header.Get(rest.RequestIdHeader); rest.ValidRequestId(id) {
+		return id
+	}
+	return rest.NewRequestId()
+}
+
+func (root *RootHandler) redactor() *rest.Redactor {
+	if root.Redactor != nil {
+		return root.Redactor
+	}
+	return rest.DefaultRedactor
+}
+
+// annotateBody traces a decoded body, with the sensitive fields redacted
+func (root *RootHandler) annotateBody(trace tracing.Tracer, from tracing.From, body interface{}) {
+	var value string
+	switch b := body.(type) {
+	case string:
+		value = b
+	case []byte:
+		value = string(b)
+	default:
+		value = root.redactor().Json(body)
+	}
+	trace.Annotate(from, "body", truncate(value, root.MaxAnnotationBytes))
+}
+
+func truncate(value string, max int) string {
+	if max <= 0 || len(value) <= max {
+		return value
+	}
+	return fmt.Sprintf("%s...[truncated %d bytes]", value[:max], len(value)-max)
+}
+
 func (rh *RootHandler) convertRequestResponse(w http.ResponseWriter, r *http.Request, endpoint rest.ServerResource) (*rest.Request, *rest.Response) {
 
 	request := rest.NewRequest(r, rest.NewRequestContext(), endpoint)
@@ -83,12 +171,12 @@ func requestName(r *rest.Request) string {
 }
 
 func setResponseContentType(response *rest.Response, req *http.Request, resp http.ResponseWriter, endpoint rest.ServerResource) {
-	// TODO: ideally we'd match the preferred accept type, with a type we can respond with,
-	// for now, until we support this, just ignore the accept string and return the first
-	// content type we know we can return
+	// the preferred accept type we can respond with, or the first content type of the endpoint
 	if response.ContentType == "" {
 		cts := endpoint.ResponseContentTypes()
-		if cts != nil && len(cts) > 0 {
+		if accepted := negotiate(req.Header.Get("Accept"), cts); accepted != "" {
+			response.ContentType = accepted
+		} else if cts != nil && len(cts) > 0 {
 			response.ContentType = cts[0]
 		} else if cts = req.Header["Content-Type"]; cts != nil && len(cts) > 0 {
 			response.ContentType = cts[0] //try returning the same as the requested type
@@ -106,34 +194,39 @@ type responseData struct {
 	PanicMessage  string
 }
 
-func getErrorMessage(e interface{}) string {
-	var msg string
-	if err, ok := e.(error); ok {
-		msg = err.Error()
-	} else if str, ok := e.(string); ok {
-		msg = str
-	} else if _, ok := e.(runtime.Error); ok {
-		msg = err.Error()
-	} else {
-		msg = ""
-	}
-	return msg
-}
-
-func (root *RootHandler) guaranteedReply(writer http.ResponseWriter, response *responseData, trace *tracing.TraceMessage) {
-	defer root.TraceHandler(trace)
+func (root *RootHandler) guaranteedReply(writer http.ResponseWriter, response *responseData, trace *tracing.TraceMessage, exchange *Exchange) {
+	defer func() {
+		if root.keepTrace(exchange.TraceContext, response.StatusCode) {
+			root.TraceHandler(trace)
+		}
+	}()
 
-	var panicMessage string
+	var recovered *RecoveredPanic
 	if r := recover(); r != nil {
-		stack := make([]byte, 2048)
-		runtime.Stack(stack, true)
-		panicMessage = getErrorMessage(r)
+		recovered = NewRecoveredPanic(r, debug.Stack())
+		recovered.Endpoint = exchange.Endpoint
+		recovered.Request = exchange.Request
+		exchange.Panic = r
+		exchange.Failure = FailurePanic
 		response.StatusMessage = "Internal Server Error"
 		response.StatusCode = 500
-		stackTrace := fmt.Sprintf("%s callstack: %s", panicMessage, stack)
-		trace.Annotate(tracing.FromPanic, "request fail", panicMessage)
-		trace.Annotate(tracing.FromPanic, "stack", stackTrace)
-		root.Log.Error("Panic Occured", me.NewErr(stackTrace))
+		response.Data = nil
+		stackTrace := fmt.Sprintf("%s callstack: %s", recovered.Message, recovered.Stack)
+		trace.Annotate(tracing.FromPanic, "request fail", recovered.Message)
+		trace.Annotate(tracing.FromPanic, "kind", string(recovered.Kind))
+		if recovered.Kind != PanicAbort {
+			trace.Annotate(tracing.FromPanic, "stack", stackTrace)
+			root.Log.Error("Panic Occured", me.NewErr(stackTrace), &logging.KV{Key: "kind", Value: string(recovered.Kind)})
+		}
+		if recovered.Kind == PanicAbort || exchange.Streamed && exchange.BytesSent > 0 {
+			//net/http cuts the reply short, a 500 can't replace it or follow the rows sent
+			trace.RequestFail()
+			exchange.StatusCode = response.StatusCode
+			exchange.StatusMessage = "Aborted"
+			exchange.Duration = time.Since(exchange.Start)
+			panic(http.ErrAbortHandler)
+		}
+		root.handlePanic(writer, response, recovered)
 	}
 
 	if response.StatusCode != 200 {
@@ -147,23 +240,44 @@ func (root *RootHandler) guaranteedReply(writer http.ResponseWriter, response *r
 
 		trace.Annotate(tracing.FromError, fmt.Sprintf("httpResponse: %v", response.StatusCode), response.StatusMessage)
 		trace.RequestFail()
-		http.Error(writer, response.StatusMessage, response.StatusCode)
-		writer.Write([]byte{})
+		if response.Data != nil {
+			//the handler supplied an error body, send it instead of the status message
+			writer.WriteHeader(response.StatusCode)
+			root.write(writer, response.Data)
+		} else {
+			http.Error(writer, response.StatusMessage, response.StatusCode)
+			writer.Write([]byte{})
+		}
 	} else {
 		data := response.Data
 		if data == nil {
 			data = []byte{}
 		}
-
-		if bytesSent, err := writer.Write(data); err != nil {
-			root.Log.Warn("failed to write response",
-				&logging.KV{"message", "partial reply, failed to send entire reply"},
-				&logging.KV{"bytesSent", bytesSent},
-				&logging.KV{"totalBytes", len(data)},
-			)
-		}
+		root.write(writer, data)
 		trace.RequestCompleted()
 	}
+
+	exchange.StatusCode = response.StatusCode
+	exchange.StatusMessage = response.StatusMessage
+	if !exchange.Streamed {
+		exchange.ResponseBody = response.Data
+		exchange.BytesSent = len(response.Data)
+	}
+	exchange.Duration = time.Since(exchange.Start)
+
+	if recovered != nil && root.RePanic {
+		panic(recovered.Value)
+	}
+}
+
+func (root *RootHandler) write(writer http.ResponseWriter, data []byte) {
+	if bytesSent, err := writer.Write(data); err != nil {
+		root.Log.Warn("failed to write response",
+			&logging.KV{Key: "message", Value: "partial reply, failed to send entire reply"},
+			&logging.KV{Key: "bytesSent", Value: bytesSent},
+			&logging.KV{Key: "totalBytes", Value: len(data)},
+		)
+	}
 }
 
 func flattenForm(form map[string][]string) map[string]string {
@@ -186,14 +300,33 @@ func (root *RootHandler) hackReqArgs(req *http.Request, existingArgs map[string]
 	}
 }
 
-func (root *RootHandler) createHttpHandler(handler rest.HandlerFunc, endpoint rest.ServerResource) func(http.ResponseWriter, *http.Request) {
+// requestBytes is the request body, which the decoders have already read for all but
+// GET, DELETE & HEAD
+func requestBytes(request *rest.Request) []byte {
+	if request.Raw.Body == nil {
+		return nil
+	}
+	if bts, err := request.Bytes(); err == nil {
+		return bts
+	}
+	return nil
+}
+
+func (root *RootHandler) createHttpHandler(handler rest.HandlerFunc, endpoint rest.ServerResource, binder BindingFunc) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		traceUid := rest.GetHeaderValue(root.TraceHeader, r.Header)
-		spanUid := rest.GetHeaderValue(root.SpanHeader, r.Header)
-		traceMessage := tracing.NewReceiveTrace(traceUid, spanUid)
+		traceContext := root.traceContext(r, endpoint)
+		traceMessage := tracing.NewReceiveTrace(traceContext.TraceId, traceContext.ParentSpanId)
+		traceMessage.SpanUid = traceContext.SpanId
 		tracer := tracing.NewMessageTracer(traceMessage)
+		exchange := ExchangeOf(r)
+		if exchange == nil {
+			exchange = &Exchange{Endpoint: endpoint, Start: time.Now()}
+		}
+		exchange.TraceContext = traceContext
+		exchange.RequestId = requestId(r)
+		w.Header().Set(rest.RequestIdHeader, exchange.RequestId)
 		responseData := &responseData{}
-		defer root.guaranteedReply(w, responseData, traceMessage)
+		defer root.guaranteedReply(w, responseData, traceMessage, exchange)
 
 		//should ParseMultipartForm be configurable?? so it's only called when needed?
 		r.ParseMultipartForm(120000)
@@ -202,41 +335,79 @@ func (root *RootHandler) createHttpHandler(handler rest.HandlerFunc, endpoint re
 
 		request, response := root.convertRequestResponse(w, r, endpoint)
 		request.Context.Trace = tracer
-
-		traceMessage.ReceivedRequest(requestName(request), args, r.Header)
+		request.Context.TraceContext = traceContext
+		request.Context.RequestId = exchange.RequestId
+		request.Context.Log = rest.NewContextLogger(root.Log,
+			&logging.KV{Key: "requestId", Value: exchange.RequestId},
+			&logging.KV{Key: "traceId", Value: traceContext.TraceId},
+			&logging.KV{Key: "spanId", Value: traceContext.SpanId},
+		)
+		exchange.Request = request
+
+		traceMessage.ReceivedRequest(requestName(request), args, root.redactor().Headers(r.Header))
+		traceMessage.Annotate(tracing.FromRequest, "requestId", exchange.RequestId)
 
 		if err := request.DecodeArgs(args); err != nil {
+			exchange.Failure = FailureArgs
 			responseData.StatusCode = http.StatusBadRequest
 			responseData.StatusMessage = "Bad Request: failed parse expected URL parameters"
 			return
 		}
 
-		if err := root.Decoders.DecodeBody(request, traceMessage); err != nil {
-			responseData.StatusCode = http.StatusBadRequest
-			responseData.StatusMessage = "Bad Request: Failed to decode request body for the provided Content-Type"
-			return
-		}
+		err := root.Decoders.DecodeBody(request, traceMessage)
+		exchange.RequestBody = requestBytes(request)
+		exchange.Response = response
+		if err != nil {
+			exchange.Failure = FailureBody
+			traceMessage.Annotate(tracing.FromError, "decode failed", err)
+			if _, ok := rest.AsError(err); !ok {
+				responseData.StatusCode = http.StatusBadRequest
+				responseData.StatusMessage = "Bad Request: Failed to decode request body for the provided Content-Type"
+				return
+			}
+			//the handler isn't called, the decode error is the body
+			rest.ReplyError(response, err)
+		} else {
+			if request.Body != nil && !isBytes(reflect.TypeOf(request.Body)) && root.sampled(traceContext) {
+				root.annotateBody(traceMessage, tracing.FromRequestData, request.Body)
+			}
 
-		boundHandler := root.Binder(handler)
-		boundHandler(request, response)
+			boundHandler := binder(handler)
+			boundHandler(request, response)
 
-		if response.Error != nil {
-			request.Context.Trace.Annotate(tracing.FromError, "request failed", response.Error)
+			if response.Error != nil {
+				request.Context.Trace.Annotate(tracing.FromError, "request failed", response.Error)
+				//domain errors reply with the status of their kind, and are the body
+				if _, ok := rest.AsError(response.Error); ok && response.Body == nil {
+					rest.ReplyError(response, response.Error)
+				}
+			}
 		}
 
 		responseData.StatusCode = response.Status
 
 		if response.Status != http.StatusOK {
 			responseData.StatusMessage = response.Message
-			return
+			if response.Body == nil {
+				return
+			}
 		}
 
 		setResponseContentType(response, r, w, endpoint)
 
-		var bts []byte
-		var err error
+		exchange.ContentType = response.ContentType
 
+		if response.Status == http.StatusOK && root.Encoders.Streams(response.Body, response.ContentType) {
+			if root.stream(w, response, exchange, traceMessage) == 0 && exchange.Failure != "" {
+				responseData.StatusCode = http.StatusInternalServerError
+				responseData.StatusMessage = "Internal Server Error - Failed to encode response body"
+			}
+			return
+		}
+
+		var bts []byte
 		if bts, err = root.Encoders.Encode(response.Body, response.ContentType); err != nil {
+			exchange.Failure = FailureEncode
 			responseData.StatusCode = http.StatusInternalServerError
 			responseData.StatusMessage = "Internal Server Error - Failed to encode response body"
 			return
@@ -244,15 +415,73 @@ func (root *RootHandler) createHttpHandler(handler rest.HandlerFunc, endpoint re
 		w.Header()["Content-Length"] = []string{strconv.Itoa(len(bts))}
 		responseData.Data = bts
 
-		if response.IsBinary() {
-			traceMessage.AnnotateBinary(tracing.FromResponseData, "body", bytes.NewReader(bts), response.ContentType)
+		if !root.sampled(traceContext) {
+			return
+		} else if response.IsBinary() {
+			traced := bts
+			if max := root.MaxAnnotationBytes; max > 0 && len(traced) > max {
+				traced = traced[:max]
+			}
+			traceMessage.AnnotateBinary(tracing.FromResponseData, "body", bytes.NewReader(traced), response.ContentType)
 		} else {
-			traceMessage.Annotate(tracing.FromResponseData, "body", fmt.Sprintf("%s", response.Body))
+			root.annotateBody(traceMessage, tracing.FromResponseData, response.Body)
+		}
+	}
+}
+
+// stream writes the rows of the response body as they're encoded, and is the bytes sent.  A
+// failure once rows are sent can only cut the reply short
+func (root *RootHandler) stream(w http.ResponseWriter, response *rest.Response, exchange *Exchange, trace *tracing.TraceMessage) int {
+	trace.Annotate(tracing.FromResponseData, "stream", response.ContentType)
+	writer := &flushWriter{w: w}
+	if exchange.KeepStream {
+		writer.kept = new(bytes.Buffer)
+	}
+	exchange.Streamed = true
+	defer func() {
+		exchange.BytesSent = writer.sent
+		if writer.kept != nil {
+			exchange.ResponseBody = writer.kept.Bytes()
 		}
+	}()
+	if err := root.Encoders.Stream(writer, response.Body, response.ContentType); err != nil {
+		exchange.Failure = FailureEncode
+		trace.Annotate(tracing.FromError, "stream failed", err)
+		if writer.sent > 0 {
+			root.Log.Warn("failed to stream response",
+				&logging.KV{Key: "message", Value: "partial reply, failed to encode entire reply"},
+				&logging.KV{Key: "bytesSent", Value: writer.sent},
+				&logging.KV{Key: "error", Value: err.Error()},
+			)
+		}
+	}
+	return writer.sent
+}
+
+// flushWriter flushes every write, so each row reaches the client as it's encoded
+type flushWriter struct {
+	w    http.ResponseWriter
+	sent int
+	kept *bytes.Buffer
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	fw.sent += n
+	if fw.kept != nil {
+		fw.kept.Write(p[:n])
+	}
+	if flusher, ok := fw.w.(http.Flusher); ok {
+		flusher.Flush()
 	}
+	return n, err
 }
 
 func (root *RootHandler) Bind(router SimpleRouter, endpoint rest.ServerResource, handler rest.Handler, resourceRoot string) {
+	root.bind(router, endpoint, handler, resourceRoot, root.Binder)
+}
+
+func (root *RootHandler) bind(router SimpleRouter, endpoint rest.ServerResource, handler rest.Handler, resourceRoot string, binder BindingFunc) {
 	if handler == nil {
 		panic(fmt.Sprintf("handler can't be nil", endpoint))
 	}
@@ -301,11 +530,42 @@ func (root *RootHandler) Bind(router SimpleRouter, endpoint rest.ServerResource,
 		}
 	}
 
-	wrappedHandler := root.createHttpHandler(fn, endpoint)
+	root.bindFunc(router, endpoint, fn, typeName(handler), resourceRoot, binder)
+}
+
+// BindFunc binds a handler function to the endpoint, such as the typed handler of a
+// rest.Endpoint.  See BindEndpoint
+func (root *RootHandler) BindFunc(router SimpleRouter, endpoint rest.ServerResource, fn rest.HandlerFunc, resourceRoot string) {
+	if fn == nil {
+		panic(fmt.Sprintf("handler of %s %s can't be nil", endpoint.Verb(), endpoint.ResourceT()))
+	}
+	root.bindFunc(router, endpoint, fn, funcName(fn), resourceRoot, root.Binder)
+}
+
+func (root *RootHandler) bindFunc(router SimpleRouter, endpoint rest.ServerResource, fn rest.HandlerFunc, handlerName, resourceRoot string, binder BindingFunc) {
+	resourcePathT := path.Join(resourceRoot, endpoint.ResourceT())
+	httpMethod := endpoint.Verb()
+	wrappedHandler := root.chain(endpoint, resourcePathT, root.createHttpHandler(fn, endpoint, binder))
 	router.RegisterRoute(httpMethod, resourcePathT, wrappedHandler)
+	root.addRoute(newRouteInfo(endpoint, resourcePathT, handlerName, binder))
 	root.Log.Inform(fmt.Sprintf("Bound endpoint %s %s", httpMethod, resourcePathT))
 }
 
+// BindEndpoint binds a typed handler function to a rest.Endpoint, it's given the decoded
+// Args and Body and its result is the response body
+//
+//	Example:
+//
+//		handling.BindEndpoint(root, router, api.GetOrder, func(ctx *rest.RequestContext, args api.OrderArgs, _ rest.None) (api.Order, error) {
+//			return orders.Find(args.Id)
+//		}, "/v1")
+func BindEndpoint[A, B, R any](root *RootHandler, router SimpleRouter, endpoint *rest.Endpoint[A, B, R], fn func(*rest.RequestContext, A, B) (R, error), resourceRoot string) {
+	if fn == nil {
+		panic(fmt.Sprintf("handler of %s %s can't be nil", endpoint.Verb, endpoint.ResourceT))
+	}
+	root.bindFunc(router, endpoint.Resource(), endpoint.Handle(fn), funcName(fn), resourceRoot, root.Binder)
+}
+
 // BindAll is a helper for calling Bind on a list of endpoints
 func (root *RootHandler) BindAll(router SimpleRouter, endpoints map[rest.ServerResource]rest.Handler, resourceRoot string) {
 	for definition, handler := range endpoints {
@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 
+	"github.com/gotgo/fw/tracing"
 	. "github.com/gotgo/gokn/handling"
 	"github.com/gotgo/gokn/rest"
 
@@ -82,6 +86,29 @@ type TestStruct struct {
 	Message string
 }
 
+type SecretStruct struct {
+	User     string `json:"user"`
+	Password string `json:"password" sensitive:"true"`
+}
+
+type ItemArgs struct {
+	Id int `json:"id,string"`
+}
+
+// EchoHandler replies with the request body
+type EchoHandler struct{}
+
+func (eh *EchoHandler) Post(req *rest.Request, resp rest.Responder) {
+	resp.SetBody(req.Body)
+}
+
+// ConflictHandler fails with a domain error
+type ConflictHandler struct{}
+
+func (ch *ConflictHandler) Post(req *rest.Request, resp rest.Responder) {
+	resp.SetStatus(http.StatusInternalServerError, "failed", fmt.Errorf("saving: %w", rest.Conflict))
+}
+
 func NewTestHandler() *TestHandler {
 	h := new(TestHandler)
 	h.ResponseStatus = 200
@@ -285,4 +312,270 @@ var _ = Describe("RootHandler", func() {
 
 	})
 
+	Context("Trace propagation", func() {
+		var captured *rest.RequestContext
+
+		BeforeEach(func() {
+			captured = nil
+			root.Binder = func(h rest.HandlerFunc) func(*rest.Request, rest.Responder) {
+				return func(req *rest.Request, resp rest.Responder) {
+					captured = req.Context
+					h(req, resp)
+				}
+			}
+			root.Bind(router, getSpec("/test", "GET"), handler, "")
+		})
+
+		It("should continue an incoming W3C trace", func() {
+			request.Method = "GET"
+			request.Header = http.Header{}
+			request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			router.Handlers[0](writer, request)
+
+			tc := captured.TraceContext
+			Expect(tc.TraceId).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+			Expect(tc.ParentSpanId).To(Equal("00f067aa0ba902b7"))
+			Expect(tc.SpanId).ToNot(Equal("00f067aa0ba902b7"))
+		})
+
+		It("should export the span it propagates", func() {
+			var exported *tracing.TraceMessage
+			root.TraceHandler = func(t *tracing.TraceMessage) {
+				exported = t
+			}
+			request.Method = "GET"
+			request.Header = http.Header{}
+			request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			router.Handlers[0](writer, request)
+
+			Expect(exported).ToNot(BeNil())
+			Expect(exported.TraceUid).To(Equal(captured.TraceContext.TraceId))
+			Expect(exported.ParentSpanUid).To(Equal("00f067aa0ba902b7"))
+			Expect(exported.SpanUid).To(Equal(captured.TraceContext.SpanId))
+			Expect(captured.TraceContext.Child().ParentSpanId).To(Equal(exported.SpanUid))
+		})
+
+		It("should read the legacy headers", func() {
+			request.Method = "GET"
+			request.Header = http.Header{}
+			request.Header.Set("tr-trace", "legacy")
+			router.Handlers[0](writer, request)
+			Expect(captured.TraceContext.TraceId).To(Equal("legacy"))
+		})
+
+		It("should start a new trace", func() {
+			request.Method = "GET"
+			request.Header = http.Header{}
+			router.Handlers[0](writer, request)
+			Expect(captured.TraceContext.TraceId).To(HaveLen(32))
+			Expect(captured.TraceContext.ParentSpanId).To(Equal(""))
+		})
+
+		It("should log with the trace and span ids", func() {
+			request.Method = "GET"
+			request.Header = http.Header{}
+			router.Handlers[0](writer, request)
+
+			log, ok := captured.Log.(*rest.ContextLogger)
+			Expect(ok).To(BeTrue())
+			Expect(log.KV).To(HaveLen(3))
+			Expect(log.KV[0].Value).To(Equal(captured.RequestId))
+			Expect(log.KV[1].Value).To(Equal(captured.TraceContext.TraceId))
+			Expect(log.KV[2].Value).To(Equal(captured.TraceContext.SpanId))
+		})
+	})
+
+	Context("Request id", func() {
+		var (
+			captured *rest.RequestContext
+			recorder *httptest.ResponseRecorder
+		)
+
+		BeforeEach(func() {
+			captured = nil
+			recorder = httptest.NewRecorder()
+			root.Binder = func(h rest.HandlerFunc) func(*rest.Request, rest.Responder) {
+				return func(req *rest.Request, resp rest.Responder) {
+					captured = req.Context
+					h(req, resp)
+				}
+			}
+			root.Bind(router, getSpec("/test", "GET"), handler, "")
+			request.Method = "GET"
+			request.Header = http.Header{}
+		})
+
+		It("should keep a valid incoming id and echo it", func() {
+			request.Header.Set("X-Request-ID", "01ARZ3NDEKTSV4RRFFQ69G5FAV")
+			router.Handlers[0](recorder, request)
+			Expect(captured.RequestId).To(Equal("01ARZ3NDEKTSV4RRFFQ69G5FAV"))
+			Expect(recorder.Header().Get("X-Request-ID")).To(Equal("01ARZ3NDEKTSV4RRFFQ69G5FAV"))
+		})
+
+		It("should replace an invalid incoming id", func() {
+			request.Header.Set("X-Request-ID", "bad id\nforged line")
+			router.Handlers[0](recorder, request)
+			Expect(captured.RequestId).To(HaveLen(36))
+			Expect(recorder.Header().Get("X-Request-ID")).To(Equal(captured.RequestId))
+		})
+
+		It("should generate an id", func() {
+			router.Handlers[0](recorder, request)
+			Expect(captured.RequestId).To(HaveLen(36))
+			Expect(recorder.Header().Get("X-Request-ID")).To(Equal(captured.RequestId))
+		})
+	})
+
+	Context("Domain errors", func() {
+		It("should map the error a handler sets", func() {
+			def := &rest.ResourceDef{ResourceT: "/items", Verb: "POST"}
+			root.Bind(router, rest.NewServerResource(def, nil, []string{"application/json"}), new(ConflictHandler), "")
+			recorder := httptest.NewRecorder()
+			request.Header = http.Header{}
+			router.Handlers[0](recorder, request)
+			Expect(recorder.Code).To(Equal(http.StatusConflict))
+			Expect(recorder.Body.String()).To(MatchJSON(`{"kind":"conflict","message":"Conflict"}`))
+		})
+	})
+
+	Context("Malformed bodies", func() {
+		var def *rest.ResourceDef
+
+		BeforeEach(func() {
+			def = &rest.ResourceDef{
+				ResourceT:    "/login",
+				Verb:         "POST",
+				RequestBody:  reflect.TypeOf(SecretStruct{}),
+				ResponseBody: reflect.TypeOf(SecretStruct{}),
+			}
+			ct := []string{"application/json"}
+			root.Bind(router, rest.NewServerResource(def, ct, ct), new(EchoHandler), "")
+		})
+
+		post := func(body string) *httptest.ResponseRecorder {
+			recorder := httptest.NewRecorder()
+			request, _ := http.NewRequest("POST", "/login", strings.NewReader(body))
+			request.Header.Set("Content-Type", "application/json; charset=utf-8")
+			router.Handlers[0](recorder, request)
+			return recorder
+		}
+
+		It("should reply where the body failed to decode, without calling the handler", func() {
+			recorder := post(`{"user": 7}`)
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(recorder.Body.String()).To(MatchJSON(`{"kind":"malformed","code":"type",
+				"message":"field user should be a string at offset 9",
+				"details":{"offset":9,"field":"user","expected":"string"}}`))
+		})
+
+		It("should reject unknown fields and duplicate keys of a StrictBody only", func() {
+			Expect(post(`{"user":"bob","admin":true}`).Code).To(Equal(http.StatusOK))
+			Expect(post(`{"user":"bob","user":"eve"}`).Code).To(Equal(http.StatusOK))
+
+			def.StrictBody = true
+			recorder := post(`{"user":"bob","admin":true}`)
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(recorder.Body.String()).To(ContainSubstring(`"code":"unknown_field"`))
+			recorder = post(`{"user":"bob","user":"eve"}`)
+			Expect(recorder.Body.String()).To(ContainSubstring(`"code":"duplicate_key"`))
+		})
+	})
+
+	Context("Trace annotations", func() {
+		var traced string
+
+		BeforeEach(func() {
+			traced = ""
+			root.TraceHandler = func(t *tracing.TraceMessage) {
+				bts, _ := json.Marshal(t)
+				traced = string(bts)
+			}
+			def := &rest.ResourceDef{
+				ResourceT:    "/login",
+				Verb:         "POST",
+				RequestBody:  reflect.TypeOf(SecretStruct{}),
+				ResponseBody: reflect.TypeOf(SecretStruct{}),
+			}
+			ct := []string{"application/json"}
+			root.Bind(router, rest.NewServerResource(def, ct, ct), new(EchoHandler), "")
+		})
+
+		post := func(body string) {
+			request, _ := http.NewRequest("POST", "/login", strings.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set("Authorization", "Bearer token")
+			router.Handlers[0](writer, request)
+		}
+
+		It("should redact sensitive fields from the traced bodies", func() {
+			post(`{"user":"bob","password":"hunter2"}`)
+			Expect(traced).To(ContainSubstring("bob"))
+			Expect(traced).To(ContainSubstring(rest.RedactedValue))
+			Expect(traced).ToNot(ContainSubstring("hunter2"))
+			Expect(string(writer.WriteBytes)).To(ContainSubstring("hunter2"))
+		})
+
+		It("should truncate large bodies", func() {
+			root.MaxAnnotationBytes = 16
+			user := strings.Repeat("x", 100)
+			post(`{"user":"` + user + `"}`)
+			Expect(traced).ToNot(ContainSubstring(user))
+			Expect(traced).To(ContainSubstring("truncated"))
+		})
+	})
+
+	Context("BindEndpoint", func() {
+		var endpoint *rest.Endpoint[ItemArgs, TestStruct, TestStruct]
+
+		BeforeEach(func() {
+			endpoint = rest.NewEndpoint[ItemArgs, TestStruct, TestStruct]("POST", "/items")
+		})
+
+		post := func(body string) *httptest.ResponseRecorder {
+			request, _ := http.NewRequest("POST", "/items?id=7", strings.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.Handlers[0](recorder, request)
+			return recorder
+		}
+
+		It("should pass the typed args and body and reply with the result", func() {
+			BindEndpoint(root, router, endpoint, func(ctx *rest.RequestContext, args ItemArgs, body TestStruct) (TestStruct, error) {
+				Expect(ctx).ToNot(BeNil())
+				return TestStruct{Message: fmt.Sprintf("%d %s", args.Id, body.Message)}, nil
+			}, "/v1")
+			Expect(router.PostCount).To(Equal(1))
+
+			recorder := post(`{"Message":"hello"}`)
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(MatchJSON(`{"Message":"7 hello"}`))
+		})
+
+		It("should reply 500 to an error", func() {
+			BindEndpoint(root, router, endpoint, func(*rest.RequestContext, ItemArgs, TestStruct) (TestStruct, error) {
+				return TestStruct{}, errors.New("failed")
+			}, "")
+			Expect(post(`{}`).Code).To(Equal(http.StatusInternalServerError))
+		})
+
+		It("should reply with the status and body of a domain error", func() {
+			BindEndpoint(root, router, endpoint, func(*rest.RequestContext, ItemArgs, TestStruct) (TestStruct, error) {
+				return TestStruct{}, rest.NewError(rest.NotFound, "item_missing", "no such item").Wrap(errors.New("secret"))
+			}, "")
+			recorder := post(`{}`)
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+			Expect(recorder.Body.String()).To(MatchJSON(`{"kind":"not_found","code":"item_missing","message":"no such item"}`))
+		})
+
+		It("should list the route", func() {
+			BindEndpoint(root, router, endpoint, func(*rest.RequestContext, ItemArgs, TestStruct) (TestStruct, error) {
+				return TestStruct{}, nil
+			}, "/v1")
+			routes := root.Routes()
+			Expect(routes).To(HaveLen(1))
+			Expect(routes[0].Path).To(Equal("/v1/items"))
+			Expect(routes[0].Args).To(Equal("handling_test.ItemArgs"))
+			Expect(routes[0].Handler).ToNot(BeEmpty())
+		})
+	})
 })
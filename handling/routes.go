@@ -0,0 +1,105 @@
+package handling
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+
+	"github.com/gotgo/gokn/rest"
+)
+
+// RoutesEndpoint lists every endpoint bound on the RootHandler
+var RoutesEndpoint = getEndpoint("/routes", RouteTable{})
+
+// RouteInfo describes an endpoint bound with RootHandler.Bind
+type RouteInfo struct {
+	Verb                 string   `json:"verb"`
+	ResourceT            string   `json:"resourceT"`
+	Path                 string   `json:"path"`
+	RequestContentTypes  []string `json:"requestContentTypes,omitempty"`
+	ResponseContentTypes []string `json:"responseContentTypes,omitempty"`
+	Headers              []string `json:"headers,omitempty"`
+	Args                 string   `json:"args,omitempty"`
+	RequestBody          string   `json:"requestBody,omitempty"`
+	ResponseBody         string   `json:"responseBody,omitempty"`
+	Handler              string   `json:"handler"`
+	Binder               string   `json:"binder"`
+}
+
+type RouteTable struct {
+	Routes []*RouteInfo `json:"routes"`
+}
+
+func newRouteInfo(endpoint rest.ServerResource, path string, handler string, binder BindingFunc) *RouteInfo {
+	return &RouteInfo{
+		Verb:                 endpoint.Verb(),
+		ResourceT:            endpoint.ResourceT(),
+		Path:                 path,
+		RequestContentTypes:  endpoint.RequestContentTypes(),
+		ResponseContentTypes: endpoint.ResponseContentTypes(),
+		Headers:              endpoint.Headers(),
+		Args:                 typeName(endpoint.ResourceArgs()),
+		RequestBody:          typeName(endpoint.RequestBody()),
+		ResponseBody:         typeName(endpoint.ResponseBody()),
+		Handler:              handler,
+		Binder:               funcName(binder),
+	}
+}
+
+// typeName of an instance, the pointer created by a ServerResource is dropped
+func typeName(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.String()
+}
+
+func funcName(f interface{}) string {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
+
+func (root *RootHandler) addRoute(route *RouteInfo) {
+	root.routesLock.Lock()
+	defer root.routesLock.Unlock()
+	root.routes = append(root.routes, route)
+}
+
+// Routes returns every endpoint bound so far, sorted by path then verb
+func (root *RootHandler) Routes() []*RouteInfo {
+	root.routesLock.Lock()
+	routes := make([]*RouteInfo, len(root.routes))
+	copy(routes, root.routes)
+	root.routesLock.Unlock()
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path == routes[j].Path {
+			return routes[i].Verb < routes[j].Verb
+		}
+		return routes[i].Path < routes[j].Path
+	})
+	return routes
+}
+
+type routesHandler struct {
+	root *RootHandler
+}
+
+func (rh *routesHandler) Get(req *rest.Request, resp rest.Responder) {
+	resp.SetBody(&RouteTable{Routes: rh.root.Routes()})
+}
+
+// BindRoutes binds the /routes introspection resource using the RootHandler Binder
+func (root *RootHandler) BindRoutes(router SimpleRouter, resourceRoot string) {
+	root.Bind(router, RoutesEndpoint, &routesHandler{root: root}, resourceRoot)
+}
@@ -0,0 +1,74 @@
+package handling_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	. "github.com/gotgo/gokn/handling"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Routes", func() {
+
+	var (
+		root    *RootHandler
+		handler *TestHandler
+		router  *TestRouter
+	)
+
+	BeforeEach(func() {
+		root = NewRootHandler()
+		handler = NewTestHandler()
+		router = NewTestRouter()
+	})
+
+	It("should record each bound endpoint", func() {
+		def := &rest.ResourceDef{
+			ResourceT:    "/items/{id}",
+			ResourceArgs: reflect.TypeOf(rest.IdIntArg{}),
+			Verb:         "PUT",
+			RequestBody:  reflect.TypeOf(TestStruct{}),
+			ResponseBody: reflect.TypeOf(TestStruct{}),
+		}
+		ct := []string{"application/json"}
+		root.Bind(router, rest.NewServerResource(def, ct, ct), handler, "/v1")
+		root.Bind(router, getSpec("/items", "GET"), handler, "/v1")
+
+		routes := root.Routes()
+		Expect(routes).To(HaveLen(2))
+		Expect(routes[0].Path).To(Equal("/v1/items"))
+		route := routes[1]
+		Expect(route.Verb).To(Equal("PUT"))
+		Expect(route.ResourceT).To(Equal("/items/{id}"))
+		Expect(route.Path).To(Equal("/v1/items/{id}"))
+		Expect(route.RequestContentTypes).To(Equal(ct))
+		Expect(route.Args).To(Equal("rest.IdIntArg"))
+		Expect(route.RequestBody).To(Equal("handling_test.TestStruct"))
+		Expect(route.ResponseBody).To(Equal("handling_test.TestStruct"))
+		Expect(route.Handler).To(Equal("handling_test.TestHandler"))
+		Expect(route.Binder).To(HaveSuffix("handling.AnonymousHandler"))
+	})
+
+	It("should serve the route table", func() {
+		root.BindAll(router, map[rest.ServerResource]rest.Handler{
+			getSpec("/a", "GET"):  handler,
+			getSpec("/b", "POST"): handler,
+		}, "")
+		root.BindRoutes(router, "")
+		Expect(router.RegisterCount).To(Equal(3))
+
+		writer := new(TestResponseWriter)
+		router.Handlers[2](writer, &http.Request{Method: "GET"})
+
+		table := &RouteTable{}
+		Expect(json.Unmarshal(writer.WriteBytes, table)).To(BeNil())
+		Expect(table.Routes).To(HaveLen(3))
+		Expect(table.Routes[0].Path).To(Equal("/a"))
+		Expect(table.Routes[1].Path).To(Equal("/b"))
+		Expect(table.Routes[2].Path).To(Equal("/routes"))
+	})
+})
@@ -0,0 +1,98 @@
+package handling
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/gotgo/gokn/rest"
+)
+
+// Sampler makes the head based decision of which requests are traced.  The decision is
+// made once, where the trace starts, and is propagated to every downstream call, so a
+// request with an incoming trace context keeps the caller's decision.
+//
+//	Example:
+//
+//		root.Sampler = handling.NewSampler(0.1).
+//			SetRate("GET", "/health", 0).
+//			SetRate("POST", "/orders", 1)
+type Sampler struct {
+	// Rate is the fraction of requests traced, from 0 to 1
+	Rate float64
+	// AlwaysOnError traces every failed request, sampled or not, a failure is a 5xx reply
+	AlwaysOnError bool
+	// ClientErrors makes a 4xx reply a failure too
+	ClientErrors bool
+	rates         map[string]float64
+	lock          sync.RWMutex
+}
+
+func NewSampler(rate float64) *Sampler {
+	return &Sampler{
+		Rate:          rate,
+		AlwaysOnError: true,
+		rates:         make(map[string]float64),
+	}
+}
+
+// SetRate overrides the Rate for one endpoint
+func (s *Sampler) SetRate(verb, resourceT string, rate float64) *Sampler {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.rates == nil {
+		s.rates = make(map[string]float64)
+	}
+	s.rates[verb+" "+resourceT] = rate
+	return s
+}
+
+// RateOf is the rate for the endpoint
+func (s *Sampler) RateOf(endpoint rest.ServerResource) float64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if rate, ok := s.rates[endpoint.Verb()+" "+endpoint.ResourceT()]; ok {
+		return rate
+	}
+	return s.Rate
+}
+
+// Sampled decides from the trace id, so every service sampling at the same rate makes
+// the same decision for a trace
+func (s *Sampler) Sampled(endpoint rest.ServerResource, traceId string) bool {
+	rate := s.RateOf(endpoint)
+	if rate >= 1 {
+		return true
+	} else if rate <= 0 {
+		return false
+	}
+	//the low 56 bits of the trace id are random in every id format we generate
+	hexId := rest.HexTraceId(traceId)
+	n, err := strconv.ParseUint(hexId[len(hexId)-14:], 16, 64)
+	if err != nil {
+		return true
+	}
+	return float64(n) < rate*float64(uint64(1)<<56)
+}
+
+// sample applies the Sampler to a trace that starts here, or whose caller deferred the decision
+func (root *RootHandler) sample(endpoint rest.ServerResource, tc *rest.TraceContext) {
+	if root.Sampler != nil {
+		tc.Sampled = root.Sampler.Sampled(endpoint, tc.TraceId)
+	}
+}
+
+// sampled reports whether the trace is kept whatever its reply, unsampled traces skip the
+// body annotations
+func (root *RootHandler) sampled(tc *rest.TraceContext) bool {
+	return root.Sampler == nil || tc == nil || tc.Sampled
+}
+
+// keepTrace reports whether the finished trace, of a reply with the status, goes to the
+// TraceHandler
+func (root *RootHandler) keepTrace(tc *rest.TraceContext, status int) bool {
+	if root.sampled(tc) {
+		return true
+	}
+	failed := status >= 500 || (status >= 400 && root.Sampler.ClientErrors)
+	return failed && root.Sampler.AlwaysOnError
+}
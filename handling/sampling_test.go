@@ -0,0 +1,191 @@
+package handling_test
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gotgo/fw/tracing"
+	. "github.com/gotgo/gokn/handling"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sampler", func() {
+
+	var (
+		sampler *Sampler
+		orders  rest.ServerResource
+		health  rest.ServerResource
+	)
+
+	BeforeEach(func() {
+		sampler = NewSampler(0.25)
+		orders = getSpec("/orders", "POST")
+		health = getSpec("/health", "GET")
+	})
+
+	It("should use the endpoint rate over the default", func() {
+		sampler.SetRate("GET", "/health", 0)
+		Expect(sampler.RateOf(health)).To(Equal(0.0))
+		Expect(sampler.RateOf(orders)).To(Equal(0.25))
+	})
+
+	It("should always or never sample at the extremes", func() {
+		sampler.SetRate("GET", "/health", 0).SetRate("POST", "/orders", 1)
+		for i := 0; i < 50; i++ {
+			tc := rest.NewTraceContext()
+			Expect(sampler.Sampled(health, tc.TraceId)).To(BeFalse())
+			Expect(sampler.Sampled(orders, tc.TraceId)).To(BeTrue())
+		}
+	})
+
+	It("should sample close to the rate", func() {
+		sampled := 0
+		for i := 0; i < 4000; i++ {
+			if sampler.Sampled(orders, rest.NewTraceContext().TraceId) {
+				sampled++
+			}
+		}
+		Expect(sampled).To(BeNumerically("~", 1000, 150))
+	})
+
+	It("should decide the same for the same trace id", func() {
+		for i := 0; i < 20; i++ {
+			id := rest.NewTraceContext().TraceId
+			Expect(sampler.Sampled(orders, id)).To(Equal(sampler.Sampled(orders, id)))
+		}
+	})
+
+	It("should accept trace ids that aren't hex", func() {
+		Expect(func() { sampler.Sampled(orders, "legacy-trace") }).ToNot(Panic())
+	})
+
+	Context("RootHandler", func() {
+		var (
+			root    *RootHandler
+			router  *TestRouter
+			handler *TestHandler
+			traces  []*tracing.TraceMessage
+		)
+
+		BeforeEach(func() {
+			traces = nil
+			root = NewRootHandler()
+			root.TraceHandler = func(t *tracing.TraceMessage) {
+				traces = append(traces, t)
+			}
+			root.Sampler = NewSampler(0)
+			router = NewTestRouter()
+			handler = NewTestHandler()
+			root.Bind(router, getSpec("/test", "GET"), handler, "")
+		})
+
+		get := func(headers http.Header) {
+			request, _ := http.NewRequest("GET", "/test", nil)
+			if headers != nil {
+				request.Header = headers
+			}
+			router.Handlers[0](new(TestResponseWriter), request)
+		}
+
+		It("should drop unsampled traces", func() {
+			get(nil)
+			Expect(traces).To(BeEmpty())
+		})
+
+		It("should keep failed traces", func() {
+			handler.ResponseStatus = 500
+			get(nil)
+			Expect(traces).To(HaveLen(1))
+		})
+
+		It("should only count 5xx replies as failures, and 4xx with ClientErrors", func() {
+			for _, status := range []int{http.StatusCreated, http.StatusNoContent, http.StatusNotModified, http.StatusNotFound} {
+				handler.ResponseStatus = status
+				get(nil)
+			}
+			Expect(traces).To(BeEmpty())
+
+			root.Sampler.ClientErrors = true
+			get(nil)
+			Expect(traces).To(HaveLen(1))
+		})
+
+		It("should skip the body annotations of unsampled traces", func() {
+			def := &rest.ResourceDef{
+				ResourceT:    "/login",
+				Verb:         "POST",
+				RequestBody:  reflect.TypeOf(SecretStruct{}),
+				ResponseBody: reflect.TypeOf(SecretStruct{}),
+			}
+			ct := []string{"application/json"}
+			root.Bind(router, rest.NewServerResource(def, ct, ct), new(ConflictHandler), "")
+			root.Sampler.ClientErrors = true
+			post := func() {
+				request, _ := http.NewRequest("POST", "/login", strings.NewReader(`{"user":"bob"}`))
+				request.Header.Set("Content-Type", "application/json")
+				router.Handlers[1](new(TestResponseWriter), request)
+			}
+			bodies := func(t *tracing.TraceMessage) int {
+				n := 0
+				for _, a := range t.Annotations {
+					if a.Key == "body" {
+						n++
+					}
+				}
+				return n
+			}
+
+			post()
+			Expect(traces).To(HaveLen(1))
+			Expect(bodies(traces[0])).To(Equal(0))
+
+			root.Sampler.Rate = 1
+			post()
+			Expect(traces).To(HaveLen(2))
+			Expect(bodies(traces[1])).To(Equal(2))
+		})
+
+		It("should drop failed traces unless AlwaysOnError", func() {
+			root.Sampler.AlwaysOnError = false
+			handler.ResponseStatus = 500
+			get(nil)
+			Expect(traces).To(BeEmpty())
+		})
+
+		It("should follow the caller's decision", func() {
+			headers := http.Header{}
+			headers.Set("traceparent", fmt.Sprintf("00-%s-%s-01", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"))
+			get(headers)
+			Expect(traces).To(HaveLen(1))
+
+			root.Sampler.Rate = 1
+			headers.Set("traceparent", fmt.Sprintf("00-%s-%s-00", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"))
+			get(headers)
+			Expect(traces).To(HaveLen(1))
+		})
+
+		It("should decide when the caller deferred", func() {
+			root.Propagator = &rest.B3Propagator{Multi: true}
+			headers := http.Header{}
+			headers.Set("X-B3-TraceId", "4bf92f3577b34da6a3ce929d0e0e4736")
+			headers.Set("X-B3-SpanId", "00f067aa0ba902b7")
+			get(headers)
+			Expect(traces).To(BeEmpty())
+
+			root.Sampler.Rate = 1
+			get(headers)
+			Expect(traces).To(HaveLen(1))
+		})
+
+		It("should keep every trace without a Sampler", func() {
+			root.Sampler = nil
+			get(nil)
+			Expect(traces).To(HaveLen(1))
+		})
+	})
+})
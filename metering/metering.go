@@ -0,0 +1,47 @@
+package metering
+
+import "fmt"
+
+// Prefixes of the metrics recorded by the server handling pipeline and by the rest.Client
+const (
+	ServerPrefix = "gokn_http_server"
+	ClientPrefix = "gokn_http_client"
+)
+
+// Unknown is used as the resource label when no ResourceT is known, so raw paths
+// never become label values
+const Unknown = "unknown"
+
+// RED is the set of Rate, Errors & Duration metrics recorded per endpoint.  Every metric
+// is labeled by method and resource, the ResourceT template
+type RED struct {
+	Requests       *Counter   // method, resource, status_class
+	Latency        *Histogram // method, resource, status_class
+	RequestSize    *Histogram
+	ResponseSize   *Histogram
+	InFlight       *Gauge
+	DecodeFailures *Counter
+	EncodeFailures *Counter
+	Panics         *Counter
+}
+
+func NewRED(registry *Registry, prefix string) *RED {
+	return &RED{
+		Requests:       registry.Counter(prefix+"_requests_total", "Requests completed.", "method", "resource", "status_class"),
+		Latency:        registry.Histogram(prefix+"_request_duration_seconds", "Request latency in seconds.", LatencyBuckets, "method", "resource", "status_class"),
+		RequestSize:    registry.Histogram(prefix+"_request_size_bytes", "Request body size in bytes.", SizeBuckets, "method", "resource"),
+		ResponseSize:   registry.Histogram(prefix+"_response_size_bytes", "Response body size in bytes.", SizeBuckets, "method", "resource"),
+		InFlight:       registry.Gauge(prefix+"_requests_in_flight", "Requests currently being processed.", "method", "resource"),
+		DecodeFailures: registry.Counter(prefix+"_decode_failures_total", "Requests or responses that failed to decode.", "method", "resource"),
+		EncodeFailures: registry.Counter(prefix+"_encode_failures_total", "Requests or responses that failed to encode.", "method", "resource"),
+		Panics:         registry.Counter(prefix+"_panics_total", "Panics recovered while handling a request.", "method", "resource"),
+	}
+}
+
+// StatusClass groups a status code as 1xx..5xx, anything else is "error"
+func StatusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "error"
+	}
+	return fmt.Sprintf("%dxx", code/100)
+}
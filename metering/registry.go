@@ -0,0 +1,309 @@
+package metering
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TextContentType is the content-type of the Prometheus text exposition format
+const TextContentType = "text/plain; version=0.0.4"
+
+var (
+	// LatencyBuckets in seconds
+	LatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// SizeBuckets in bytes
+	SizeBuckets = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304}
+)
+
+const (
+	kindCounter   = "counter"
+	kindGauge     = "gauge"
+	kindHistogram = "histogram"
+)
+
+// Registry holds metrics and writes them in the Prometheus text format
+type Registry struct {
+	lock    sync.Mutex
+	metrics []*metric
+	names   map[string]*metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		names: make(map[string]*metric),
+	}
+}
+
+type metric struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+	lock    sync.Mutex
+	series  map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// register returns an existing metric with the same name, so the same metrics can be
+// declared by more than one component sharing a Registry
+func (r *Registry) register(name, help, kind string, buckets []float64, labels []string) *metric {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if m := r.names[name]; m != nil {
+		if m.kind != kind || !sameLabels(m.labels, labels) {
+			panic(fmt.Sprintf("metric %s already registered as a different %s", name, m.kind))
+		}
+		return m
+	}
+
+	m := &metric{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	r.names[name] = m
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+func sameLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *metric) find(labelValues []string) *series {
+	if len(labelValues) != len(m.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", m.name, len(m.labels), len(labelValues)))
+	}
+	return m.series[strings.Join(labelValues, "\xff")]
+}
+
+func (m *metric) get(labelValues []string) *series {
+	s := m.find(labelValues)
+	if s == nil {
+		values := make([]string, len(labelValues))
+		copy(values, labelValues)
+		s = &series{labelValues: values}
+		if m.kind == kindHistogram {
+			s.counts = make([]uint64, len(m.buckets))
+		}
+		m.series[strings.Join(values, "\xff")] = s
+	}
+	return s
+}
+
+// Counter only goes up
+type Counter struct {
+	m *metric
+}
+
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{m: r.register(name, help, kindCounter, nil, labels)}
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("counter can not decrease")
+	}
+	c.m.lock.Lock()
+	c.m.get(labelValues).value += v
+	c.m.lock.Unlock()
+}
+
+// Value is the current count, mostly useful for tests
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.m.lock.Lock()
+	defer c.m.lock.Unlock()
+	if s := c.m.find(labelValues); s != nil {
+		return s.value
+	}
+	return 0
+}
+
+// Gauge goes up and down
+type Gauge struct {
+	m *metric
+}
+
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{m: r.register(name, help, kindGauge, nil, labels)}
+}
+
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.m.lock.Lock()
+	g.m.get(labelValues).value += v
+	g.m.lock.Unlock()
+}
+
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.m.lock.Lock()
+	g.m.get(labelValues).value = v
+	g.m.lock.Unlock()
+}
+
+func (g *Gauge) Value(labelValues ...string) float64 {
+	g.m.lock.Lock()
+	defer g.m.lock.Unlock()
+	if s := g.m.find(labelValues); s != nil {
+		return s.value
+	}
+	return 0
+}
+
+// Histogram counts observations into cumulative buckets
+type Histogram struct {
+	m *metric
+}
+
+// Histogram buckets are upper bounds, +Inf is implied
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	sorted := make([]float64, len(buckets))
+	copy(sorted, buckets)
+	sort.Float64s(sorted)
+	return &Histogram{m: r.register(name, help, kindHistogram, sorted, labels)}
+}
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.m.lock.Lock()
+	defer h.m.lock.Unlock()
+	s := h.m.get(labelValues)
+	for i, upper := range h.m.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+// Count is the number of observations, mostly useful for tests
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.m.lock.Lock()
+	defer h.m.lock.Unlock()
+	if s := h.m.find(labelValues); s != nil {
+		return s.count
+	}
+	return 0
+}
+
+// WriteText writes every metric in the Prometheus text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.lock.Lock()
+	metrics := make([]*metric, len(r.metrics))
+	copy(metrics, r.metrics)
+	r.lock.Unlock()
+
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].name < metrics[j].name })
+
+	for _, m := range metrics {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *metric) write(w io.Writer) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	keys := make([]string, 0, len(m.series))
+	for k := range m.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s %s\n", m.name, escapeHelp(m.help))
+	fmt.Fprintf(&b, "# TYPE %s %s\n", m.name, m.kind)
+
+	for _, k := range keys {
+		s := m.series[k]
+		if m.kind != kindHistogram {
+			fmt.Fprintf(&b, "%s%s %s\n", m.name, formatLabels(m.labels, s.labelValues, "", ""), formatFloat(s.value))
+			continue
+		}
+		for i, upper := range m.buckets {
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", m.name, formatLabels(m.labels, s.labelValues, "le", formatFloat(upper)), s.counts[i])
+		}
+		fmt.Fprintf(&b, "%s_bucket%s %d\n", m.name, formatLabels(m.labels, s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(&b, "%s_sum%s %s\n", m.name, formatLabels(m.labels, s.labelValues, "", ""), formatFloat(s.sum))
+		fmt.Fprintf(&b, "%s_count%s %d\n", m.name, formatLabels(m.labels, s.labelValues, "", ""), s.count)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, escapeLabel(values[i])))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extraName, extraValue))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
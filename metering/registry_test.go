@@ -0,0 +1,75 @@
+package metering_test
+
+import (
+	"bytes"
+
+	. "github.com/gotgo/gokn/metering"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry", func() {
+
+	var registry *Registry
+
+	var text = func() string {
+		var buf bytes.Buffer
+		Expect(registry.WriteText(&buf)).To(BeNil())
+		return buf.String()
+	}
+
+	BeforeEach(func() {
+		registry = NewRegistry()
+	})
+
+	It("should write counters with labels", func() {
+		c := registry.Counter("requests_total", "Requests.", "method", "resource")
+		c.Inc("GET", "/a/{id}")
+		c.Add(2, "GET", "/a/{id}")
+		Expect(c.Value("GET", "/a/{id}")).To(Equal(3.0))
+		Expect(text()).To(Equal("# HELP requests_total Requests.\n" +
+			"# TYPE requests_total counter\n" +
+			`requests_total{method="GET",resource="/a/{id}"} 3` + "\n"))
+	})
+
+	It("should escape label values", func() {
+		registry.Gauge("g", "help", "l").Set(1.5, "a\"b\\c\n")
+		Expect(text()).To(ContainSubstring(`g{l="a\"b\\c\n"} 1.5`))
+	})
+
+	It("should write cumulative histogram buckets", func() {
+		h := registry.Histogram("latency", "Latency.", []float64{1, 0.1})
+		h.Observe(0.05)
+		h.Observe(0.5)
+		h.Observe(5)
+		Expect(h.Count()).To(Equal(uint64(3)))
+		Expect(text()).To(Equal("# HELP latency Latency.\n" +
+			"# TYPE latency histogram\n" +
+			`latency_bucket{le="0.1"} 1` + "\n" +
+			`latency_bucket{le="1"} 2` + "\n" +
+			`latency_bucket{le="+Inf"} 3` + "\n" +
+			"latency_sum 5.55\n" +
+			"latency_count 3\n"))
+	})
+
+	It("should share a metric registered twice", func() {
+		a := registry.Counter("shared", "help", "l")
+		b := registry.Counter("shared", "help", "l")
+		a.Inc("x")
+		Expect(b.Value("x")).To(Equal(1.0))
+	})
+
+	It("should panic on a conflicting registration", func() {
+		registry.Counter("dupe", "help")
+		Expect(func() { registry.Gauge("dupe", "help") }).To(Panic())
+		registry.Counter("labelled", "help", "verb")
+		Expect(func() { registry.Counter("labelled", "help", "status") }).To(Panic())
+	})
+
+	It("should group status codes", func() {
+		Expect(StatusClass(204)).To(Equal("2xx"))
+		Expect(StatusClass(503)).To(Equal("5xx"))
+		Expect(StatusClass(0)).To(Equal("error"))
+	})
+})
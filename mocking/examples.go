@@ -0,0 +1,117 @@
+package mocking
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ExampleTag sets the example value of a field, in the field's type
+//
+//	type Order struct {
+//		Id    string  `json:"id" example:"ord_123"`
+//		Total float64 `json:"total" example:"99.95"`
+//		Tags  []string `json:"tags" example:"[\"gift\"]"`
+//	}
+const ExampleTag = "example"
+
+// ExampleTime is the value of every synthesized time.Time
+var ExampleTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// maxDepth stops the synthesis of recursive types
+const maxDepth = 6
+
+// Example synthesizes a pointer to a new t with every field filled in.  Slices and maps get
+// one element, fields with an example tag get the tagged value.
+func Example(t reflect.Type) interface{} {
+	if t == nil {
+		return nil
+	}
+	v := reflect.New(t)
+	fill(v.Elem(), "", 0)
+	return v.Interface()
+}
+
+func fill(v reflect.Value, name string, depth int) {
+	if depth > maxDepth {
+		return
+	}
+
+	if v.Type() == timeType {
+		v.Set(reflect.ValueOf(ExampleTime))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if depth < maxDepth {
+			v.Set(reflect.New(v.Type().Elem()))
+			fill(v.Elem(), name, depth+1)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			if tag, ok := f.Tag.Lookup(ExampleTag); ok && setTagged(v.Field(i), tag) {
+				continue
+			}
+			fill(v.Field(i), fieldName(f), depth+1)
+		}
+	case reflect.Slice:
+		s := reflect.MakeSlice(v.Type(), 1, 1)
+		fill(s.Index(0), name, depth+1)
+		v.Set(s)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fill(v.Index(i), name, depth+1)
+		}
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		key := reflect.New(v.Type().Key()).Elem()
+		if key.Kind() == reflect.String {
+			key.SetString("key")
+		} else {
+			fill(key, "", depth+1)
+		}
+		value := reflect.New(v.Type().Elem()).Elem()
+		fill(value, name, depth+1)
+		m.SetMapIndex(key, value)
+		v.Set(m)
+	case reflect.String:
+		if name == "" {
+			name = "string"
+		}
+		v.SetString(name)
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	}
+}
+
+// setTagged parses the tag into the field, strings are taken as is and anything else as json
+func setTagged(v reflect.Value, tag string) bool {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(tag)
+		return true
+	}
+	return json.Unmarshal([]byte(tag), v.Addr().Interface()) == nil
+}
+
+func fieldName(f reflect.StructField) string {
+	if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return f.Name
+}
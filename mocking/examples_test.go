@@ -0,0 +1,62 @@
+package mocking_test
+
+import (
+	"reflect"
+	"time"
+
+	. "github.com/gotgo/gokn/mocking"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type Line struct {
+	Sku      string `json:"sku"`
+	Quantity int    `json:"quantity" example:"3"`
+}
+
+type Order struct {
+	Id       string            `json:"id" example:"ord_123"`
+	Total    float64           `json:"total"`
+	Paid     bool              `json:"paid"`
+	Lines    []*Line           `json:"lines"`
+	Labels   map[string]string `json:"labels"`
+	Tags     []string          `json:"tags" example:"[\"gift\"]"`
+	Created  time.Time         `json:"created"`
+	Parent   *Order            `json:"parent"`
+	internal string
+}
+
+var _ = Describe("Example", func() {
+
+	It("should fill in every field", func() {
+		order := Example(reflect.TypeOf(Order{})).(*Order)
+		Expect(order.Total).To(Equal(1.5))
+		Expect(order.Paid).To(BeTrue())
+		Expect(order.Lines).To(HaveLen(1))
+		Expect(order.Lines[0].Sku).To(Equal("sku"))
+		Expect(order.Labels).To(Equal(map[string]string{"key": "labels"}))
+		Expect(order.Created).To(Equal(ExampleTime))
+		Expect(order.internal).To(BeEmpty())
+	})
+
+	It("should use the example tags", func() {
+		order := Example(reflect.TypeOf(Order{})).(*Order)
+		Expect(order.Id).To(Equal("ord_123"))
+		Expect(order.Tags).To(Equal([]string{"gift"}))
+		Expect(order.Lines[0].Quantity).To(Equal(3))
+	})
+
+	It("should stop at recursive types", func() {
+		order := Example(reflect.TypeOf(Order{})).(*Order)
+		depth := 0
+		for o := order; o != nil; o = o.Parent {
+			depth++
+		}
+		Expect(depth).To(BeNumerically("<=", 7))
+	})
+
+	It("should be nil without a type", func() {
+		Expect(Example(nil)).To(BeNil())
+	})
+})
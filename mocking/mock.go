@@ -0,0 +1,149 @@
+package mocking
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gotgo/gokn/handling"
+	"github.com/gotgo/gokn/rest"
+)
+
+// Headers a caller sends to control a single mocked reply
+const (
+	// StatusHeader forces the status code of the reply, eg X-Mock-Status: 404
+	StatusHeader = "X-Mock-Status"
+	// DelayHeader adds latency to the reply, eg X-Mock-Delay: 250ms
+	DelayHeader = "X-Mock-Delay"
+)
+
+// Mock replies to every endpoint of an api spec with example bodies, so clients can be
+// built before the real handlers exist
+//
+//	Example:
+//
+//		mock := mocking.NewMock()
+//		mock.Latency = 100 * time.Millisecond
+//		mock.ErrorRate = 0.05
+//		mock.Bind(root, router, &api.Spec{}, "/v1")
+type Mock struct {
+	// Latency is added to every reply, plus a random Jitter of up to Jitter
+	Latency time.Duration
+	Jitter  time.Duration
+	// ErrorRate is the fraction of requests that fail with ErrorStatus, a 500 when zero
+	ErrorRate   float64
+	ErrorStatus int
+	random      *rand.Rand
+	lock        sync.Mutex
+}
+
+func NewMock() *Mock {
+	return &Mock{
+		ErrorStatus: http.StatusInternalServerError,
+	}
+}
+
+// Handlers are the mock handlers of every endpoint in the api spec, for RootHandler.BindAll
+func (m *Mock) Handlers(apiSpec interface{}) map[rest.ServerResource]rest.Handler {
+	handlers := make(map[rest.ServerResource]rest.Handler)
+	for _, endpoint := range rest.FullApi(apiSpec) {
+		handlers[endpoint] = m.Handler(endpoint)
+	}
+	return handlers
+}
+
+// Bind binds the mock handlers of every endpoint in the api spec
+func (m *Mock) Bind(root *handling.RootHandler, router handling.SimpleRouter, apiSpec interface{}, resourceRoot string) {
+	for _, endpoint := range rest.FullApi(apiSpec) {
+		root.Bind(router, endpoint, m.Handler(endpoint), resourceRoot)
+	}
+}
+
+// Handler replies for one endpoint with its ResourceDef ResponseExample, or an example
+// synthesized from its ResponseBody type
+func (m *Mock) Handler(endpoint rest.ServerResource) *Handler {
+	h := &Handler{mock: m, endpoint: endpoint}
+	if def := rest.DefinitionOf(endpoint); def != nil {
+		if def.ResponseExample != nil {
+			h.example = def.ResponseExample
+		} else if def.ResponseBody != nil {
+			h.example = Example(def.ResponseBody)
+		}
+	}
+	return h
+}
+
+func (m *Mock) delay() time.Duration {
+	d := m.Latency
+	if m.Jitter > 0 {
+		m.lock.Lock()
+		d += time.Duration(m.source().Int63n(int64(m.Jitter)))
+		m.lock.Unlock()
+	}
+	return d
+}
+
+func (m *Mock) fails() bool {
+	if m.ErrorRate <= 0 {
+		return false
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.source().Float64() < m.ErrorRate
+}
+
+// source is the random source, made on first use so a Mock literal works, the lock must be held
+func (m *Mock) source() *rand.Rand {
+	if m.random == nil {
+		m.random = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return m.random
+}
+
+func (m *Mock) errorStatus() int {
+	if m.ErrorStatus == 0 {
+		return http.StatusInternalServerError
+	}
+	return m.ErrorStatus
+}
+
+// Handler is the mock of one endpoint, it handles every verb
+type Handler struct {
+	mock     *Mock
+	endpoint rest.ServerResource
+	example  interface{}
+}
+
+func (h *Handler) reply(req *rest.Request, resp rest.Responder) {
+	delay := h.mock.delay()
+	if d, err := time.ParseDuration(req.Raw.Header.Get(DelayHeader)); err == nil {
+		delay += d
+	}
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+
+	if status, err := strconv.Atoi(req.Raw.Header.Get(StatusHeader)); err == nil && status >= 100 && status < 600 {
+		if status != http.StatusOK {
+			resp.SetStatus(status, http.StatusText(status), nil)
+			return
+		}
+	} else if h.mock.fails() {
+		status := h.mock.errorStatus()
+		resp.SetStatus(status, http.StatusText(status), nil)
+		return
+	}
+
+	if h.example != nil && req.Raw.Method != "HEAD" {
+		resp.SetBody(h.example)
+	}
+}
+
+func (h *Handler) Get(req *rest.Request, resp rest.Responder)    { h.reply(req, resp) }
+func (h *Handler) Post(req *rest.Request, resp rest.Responder)   { h.reply(req, resp) }
+func (h *Handler) Put(req *rest.Request, resp rest.Responder)    { h.reply(req, resp) }
+func (h *Handler) Delete(req *rest.Request, resp rest.Responder) { h.reply(req, resp) }
+func (h *Handler) Patch(req *rest.Request, resp rest.Responder)  { h.reply(req, resp) }
+func (h *Handler) Head(req *rest.Request, resp rest.Responder)   { h.reply(req, resp) }
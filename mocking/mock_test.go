@@ -0,0 +1,143 @@
+package mocking_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gotgo/gokn/handling"
+	. "github.com/gotgo/gokn/mocking"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type OrderApi struct {
+	Orders *rest.ResourceSpec
+	Order  *rest.ResourceSpec
+}
+
+func newOrderApi() *OrderApi {
+	return &OrderApi{
+		Orders: rest.NewResourceSpec(rest.ContentTypeJson).
+			Use(&rest.ResourceDef{
+				ResourceT:    "/orders",
+				Verb:         "POST",
+				RequestBody:  reflect.TypeOf(Order{}),
+				ResponseBody: reflect.TypeOf(Order{}),
+			}),
+		Order: rest.NewResourceSpec(rest.ContentTypeJson).
+			Use(&rest.ResourceDef{
+				ResourceT:       "/orders/{id}",
+				Verb:            "GET",
+				ResponseBody:    reflect.TypeOf(Order{}),
+				ResponseExample: &Order{Id: "ord_999"},
+			}).
+			Use(&rest.ResourceDef{
+				ResourceT: "/orders/{id}",
+				Verb:      "DELETE",
+			}),
+	}
+}
+
+// testRouter keeps the bound handlers by verb and path template
+type testRouter map[string]func(http.ResponseWriter, *http.Request)
+
+func (tr testRouter) RegisterRoute(verb, path string, f func(http.ResponseWriter, *http.Request)) {
+	tr[verb+" "+path] = f
+}
+
+var _ = Describe("Mock", func() {
+	var (
+		mock   *Mock
+		router testRouter
+	)
+
+	BeforeEach(func() {
+		mock = NewMock()
+		router = make(testRouter)
+		mock.Bind(handling.NewRootHandler(), router, newOrderApi(), "/v1")
+	})
+
+	serve := func(verb, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(verb, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", rest.ContentTypeJson)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		router[verb+" /v1/orders/{id}"](w, req)
+		return w
+	}
+
+	It("should bind every endpoint of the spec", func() {
+		Expect(router).To(HaveKey("POST /v1/orders"))
+		Expect(router).To(HaveKey("GET /v1/orders/{id}"))
+		Expect(router).To(HaveKey("DELETE /v1/orders/{id}"))
+	})
+
+	It("should reply with the response example", func() {
+		w := serve("GET", "/v1/orders/1", "", nil)
+		Expect(w.Code).To(Equal(200))
+		order := new(Order)
+		Expect(json.Unmarshal(w.Body.Bytes(), order)).To(Succeed())
+		Expect(order.Id).To(Equal("ord_999"))
+	})
+
+	It("should reply with an example synthesized from the response type", func() {
+		req := httptest.NewRequest("POST", "/v1/orders", strings.NewReader(`{"id":"x"}`))
+		req.Header.Set("Content-Type", rest.ContentTypeJson)
+		w := httptest.NewRecorder()
+		router["POST /v1/orders"](w, req)
+		Expect(w.Code).To(Equal(200))
+		order := new(Order)
+		Expect(json.Unmarshal(w.Body.Bytes(), order)).To(Succeed())
+		Expect(order.Id).To(Equal("ord_123"))
+		Expect(order.Lines).To(HaveLen(1))
+	})
+
+	It("should reply with the status requested by the caller", func() {
+		w := serve("GET", "/v1/orders/1", "", map[string]string{StatusHeader: "404"})
+		Expect(w.Code).To(Equal(404))
+	})
+
+	It("should add the delay requested by the caller", func() {
+		start := time.Now()
+		serve("GET", "/v1/orders/1", "", map[string]string{DelayHeader: "20ms"})
+		Expect(time.Since(start)).To(BeNumerically(">=", 20*time.Millisecond))
+	})
+
+	It("should fail at the error rate", func() {
+		mock.ErrorRate = 1
+		mock.ErrorStatus = 503
+		w := serve("GET", "/v1/orders/1", "", nil)
+		Expect(w.Code).To(Equal(503))
+	})
+
+	It("should add the latency", func() {
+		mock.Latency = 10 * time.Millisecond
+		mock.Jitter = 5 * time.Millisecond
+		start := time.Now()
+		serve("GET", "/v1/orders/1", "", nil)
+		Expect(time.Since(start)).To(BeNumerically(">=", 10*time.Millisecond))
+	})
+
+	It("should work as a literal", func() {
+		mock = &Mock{ErrorRate: 0.5, Jitter: time.Millisecond}
+		mock.Bind(handling.NewRootHandler(), router, newOrderApi(), "/v1")
+		codes := map[int]bool{}
+		for i := 0; i < 50; i++ {
+			codes[serve("GET", "/v1/orders/1", "", nil).Code] = true
+		}
+		Expect(codes).To(Equal(map[int]bool{200: true, 500: true}))
+	})
+
+	It("should make handlers for BindAll", func() {
+		handlers := mock.Handlers(newOrderApi())
+		Expect(handlers).To(HaveLen(3))
+	})
+})
@@ -0,0 +1,13 @@
+package mocking_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestMocking(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Mocking Suite")
+}
@@ -0,0 +1,210 @@
+package recording
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gotgo/gokn/rest"
+)
+
+type CassetteMode int
+
+const (
+	// CassetteReplay replies from the cassette, nothing goes to the network
+	CassetteReplay CassetteMode = iota
+	// CassetteRecord sends requests to the real endpoint and records them, the cassette
+	// is written on Close
+	CassetteRecord
+)
+
+var (
+	ErrCassetteMissing = errors.New("cassette missing, record it first")
+	ErrCassetteStale   = errors.New("cassette is stale, record it again")
+)
+
+// Cassette is a http.RoundTripper for a rest.Client that records the requests and replies
+// of a test to a file of json lines, then replays them without the network.
+//
+//	Example:
+//
+//		cassette, err := recording.NewCassette("testdata/orders.jsonl", recording.CassetteReplay, 0)
+//		defer cassette.Close()
+//		client := rest.NewClient()
+//		client.Transport = cassette
+type Cassette struct {
+	Path string
+	Mode CassetteMode
+	// Matchers must all match in replay, the default is MatchExact
+	Matchers []Matcher
+	// Redactor cleans the recorded headers, nil uses the rest.DefaultRedactor
+	Redactor *rest.Redactor
+	// Transport sends the requests when recording, nil uses the http.DefaultTransport
+	Transport http.RoundTripper
+	replay    *ReplayServer
+	recorded  []*rest.FrozenCommunication
+	lock      sync.Mutex
+}
+
+// NewCassette opens the cassette at path.  In replay the file must exist, ErrCassetteMissing,
+// and when maxAge is not zero, have been recorded within maxAge, ErrCassetteStale.
+func NewCassette(path string, mode CassetteMode, maxAge time.Duration) (*Cassette, error) {
+	c := &Cassette{
+		Path:     path,
+		Mode:     mode,
+		Matchers: []Matcher{MatchExact},
+	}
+	if mode == CassetteRecord {
+		return c, nil
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, ErrCassetteMissing
+	} else if err != nil {
+		return nil, err
+	}
+	if maxAge > 0 && time.Since(info.ModTime()) > maxAge {
+		return nil, ErrCassetteStale
+	}
+
+	recordings, err := LoadRecordings(path)
+	if err != nil {
+		return nil, err
+	}
+	c.replay = NewReplayServer(recordings)
+	c.replay.Strict = true
+	return c, nil
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if c.Mode == CassetteRecord {
+		return c.record(req, body)
+	}
+	return c.play(req, body)
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+func (c *Cassette) play(req *http.Request, body []byte) (*http.Response, error) {
+	fc := c.replay.match(c.Matchers, req, body)
+	if fc == nil {
+		return nil, fmt.Errorf("%s: no recording matches %s %s", c.Path, req.Method, req.URL.RequestURI())
+	}
+
+	status, err := strconv.Atoi(fc.Response.StatusCode)
+	if err != nil {
+		return nil, fmt.Errorf("%s: recorded status code %q is not a number", c.Path, fc.Response.StatusCode)
+	}
+	responseBody, err := rest.ThawBody(fc.Response.Body, fc.Response.BodyEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(http.Header)
+	for k, v := range fc.Response.Headers {
+		headers[http.CanonicalHeaderKey(k)] = v
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        headers,
+		Body:          ioutil.NopCloser(bytes.NewReader(responseBody)),
+		ContentLength: int64(len(responseBody)),
+		Request:       req,
+	}, nil
+}
+
+func (c *Cassette) record(req *http.Request, body []byte) (*http.Response, error) {
+	transport := c.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	redactor := c.Redactor
+	if redactor == nil {
+		redactor = rest.DefaultRedactor
+	}
+	fc := &rest.FrozenCommunication{
+		Request: &rest.FrozenRequest{
+			Headers:  redactor.Headers(req.Header),
+			Verb:     req.Method,
+			Resource: req.URL.RequestURI(),
+		},
+		Response: &rest.FrozenResponse{
+			Headers:    redactor.Headers(resp.Header),
+			StatusCode: strconv.Itoa(resp.StatusCode),
+			Status:     http.StatusText(resp.StatusCode),
+		},
+	}
+	fc.Request.Body, fc.Request.BodyEncoding = rest.FreezeBody(body)
+	fc.Response.Body, fc.Response.BodyEncoding = rest.FreezeBody(responseBody)
+
+	c.lock.Lock()
+	c.recorded = append(c.recorded, fc)
+	c.lock.Unlock()
+	return resp, nil
+}
+
+// Unplayed lists the recordings that weren't replayed, as "VERB resource".  A test that
+// leaves some unplayed no longer makes the calls it was recorded with.
+func (c *Cassette) Unplayed() []string {
+	unplayed := []string{}
+	if c.replay == nil {
+		return unplayed
+	}
+	c.replay.lock.Lock()
+	defer c.replay.lock.Unlock()
+	for _, fc := range c.replay.recordings {
+		if c.replay.replayed[fc] == 0 {
+			unplayed = append(unplayed, fc.Request.Verb+" "+fc.Request.Resource)
+		}
+	}
+	return unplayed
+}
+
+// Close writes the recordings made in CassetteRecord mode
+func (c *Cassette) Close() error {
+	if c.Mode != CassetteRecord {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var buf bytes.Buffer
+	if err := WriteRecordings(&buf, c.recorded); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.Path, buf.Bytes(), 0644)
+}
@@ -0,0 +1,128 @@
+package recording_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/gotgo/gokn/recording"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cassette", func() {
+
+	var (
+		dir    string
+		path   string
+		server *httptest.Server
+		calls  int
+	)
+
+	newClient := func(transport http.RoundTripper, host string) *rest.Client {
+		client := rest.NewClient()
+		client.Transport = transport
+		client.Endpoints = []*rest.ResourceEndpoint{{Scheme: "http", Host: host}}
+		return client
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "cassette")
+		Expect(err).To(BeNil())
+		path = filepath.Join(dir, "orders.jsonl")
+
+		calls = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Set-Cookie", "session=secret")
+			w.Write([]byte(`{"id":"` + r.URL.Query().Get("id") + `"}`))
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	record := func() {
+		cassette, err := NewCassette(path, CassetteRecord, 0)
+		Expect(err).To(BeNil())
+		u, _ := url.Parse(server.URL)
+		client := newClient(cassette, u.Host)
+		client.Headers = map[string][]string{"Authorization": {"Bearer secret"}}
+
+		order := make(map[string]string)
+		_, err = client.Fetch(&rest.ClientRequest{Verb: "GET", Resource: "/orders?id=7"}, rest.NewRequestContext(), &order)
+		Expect(err).To(BeNil())
+		Expect(order["id"]).To(Equal("7"))
+		Expect(cassette.Close()).To(Succeed())
+	}
+
+	It("should replay without the network", func() {
+		record()
+		server.Close()
+
+		cassette, err := NewCassette(path, CassetteReplay, time.Hour)
+		Expect(err).To(BeNil())
+		client := newClient(cassette, "unreachable.invalid")
+
+		order := make(map[string]string)
+		resp, err := client.Fetch(&rest.ClientRequest{Verb: "GET", Resource: "/orders?id=7"}, rest.NewRequestContext(), &order)
+		Expect(err).To(BeNil())
+		Expect(resp.HttpResponse.StatusCode).To(Equal(200))
+		Expect(order["id"]).To(Equal("7"))
+		Expect(calls).To(Equal(1))
+		Expect(cassette.Unplayed()).To(BeEmpty())
+	})
+
+	It("should redact the recorded headers", func() {
+		record()
+		bts, err := ioutil.ReadFile(path)
+		Expect(err).To(BeNil())
+		Expect(string(bts)).ToNot(ContainSubstring("secret"))
+		Expect(string(bts)).To(ContainSubstring(rest.RedactedValue))
+	})
+
+	It("should fail requests that aren't on the cassette", func() {
+		record()
+		cassette, err := NewCassette(path, CassetteReplay, 0)
+		Expect(err).To(BeNil())
+		client := newClient(cassette, "unreachable.invalid")
+
+		_, err = client.Send(&rest.ClientRequest{Verb: "GET", Resource: "/orders?id=8"}, rest.NewRequestContext())
+		Expect(err).To(MatchError(ContainSubstring("no recording matches GET /orders?id=8")))
+		Expect(cassette.Unplayed()).To(Equal([]string{"GET /orders?id=7"}))
+	})
+
+	It("should use the matchers", func() {
+		record()
+		cassette, err := NewCassette(path, CassetteReplay, 0)
+		Expect(err).To(BeNil())
+		cassette.Matchers = []Matcher{MatchTemplate}
+		client := newClient(cassette, "unreachable.invalid")
+
+		_, err = client.Send(&rest.ClientRequest{Verb: "GET", Resource: "/orders?id=8"}, rest.NewRequestContext())
+		Expect(err).To(BeNil())
+	})
+
+	It("should report a missing cassette", func() {
+		_, err := NewCassette(path, CassetteReplay, 0)
+		Expect(err).To(Equal(ErrCassetteMissing))
+	})
+
+	It("should report a stale cassette", func() {
+		record()
+		old := time.Now().Add(-48 * time.Hour)
+		Expect(os.Chtimes(path, old, old)).To(Succeed())
+		_, err := NewCassette(path, CassetteReplay, 24*time.Hour)
+		Expect(err).To(Equal(ErrCassetteStale))
+	})
+})
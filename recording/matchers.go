@@ -0,0 +1,193 @@
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gotgo/gokn/rest"
+)
+
+// Matcher reports whether an incoming request matches a recorded one.  body is the
+// incoming request body, already read.
+type Matcher func(r *http.Request, body []byte, recorded *rest.FrozenRequest) bool
+
+// MatchExact matches the verb and the resource, path and query exactly as recorded
+func MatchExact(r *http.Request, body []byte, recorded *rest.FrozenRequest) bool {
+	return r.Method == recorded.Verb && r.URL.RequestURI() == recorded.Resource
+}
+
+// MatchIgnoreQueryOrder matches the verb, the path and the same query values in any order
+func MatchIgnoreQueryOrder(r *http.Request, body []byte, recorded *rest.FrozenRequest) bool {
+	if r.Method != recorded.Verb {
+		return false
+	}
+	recordedURL, err := url.Parse(recorded.Resource)
+	if err != nil || recordedURL.Path != r.URL.Path {
+		return false
+	}
+	return sameQuery(r.URL.Query(), recordedURL.Query())
+}
+
+func sameQuery(a, b url.Values) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, av := range a {
+		bv, ok := b[k]
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		counts := make(map[string]int)
+		for _, v := range av {
+			counts[v]++
+		}
+		for _, v := range bv {
+			if counts[v]--; counts[v] < 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// MatchTemplate matches the verb and the path against the recorded ResourceT, so one
+// recording of /orders/{id} replies for every order.  The query is ignored.  A recording
+// without a ResourceT must match the path exactly.
+func MatchTemplate(r *http.Request, body []byte, recorded *rest.FrozenRequest) bool {
+	if r.Method != recorded.Verb {
+		return false
+	}
+	if recorded.ResourceT == "" {
+		recordedURL, err := url.Parse(recorded.Resource)
+		return err == nil && recordedURL.Path == r.URL.Path
+	}
+	return templatePattern(recorded.ResourceT).MatchString(r.URL.Path)
+}
+
+var (
+	templates     = make(map[string]*regexp.Regexp)
+	templatesLock sync.Mutex
+)
+
+// templatePattern converts a gorilla mux template, /a/{b}/{c:[0-9]+}, to a regexp
+func templatePattern(resourceT string) *regexp.Regexp {
+	templatesLock.Lock()
+	defer templatesLock.Unlock()
+	if re, ok := templates[resourceT]; ok {
+		return re
+	}
+
+	var pattern bytes.Buffer
+	pattern.WriteString("^")
+	remaining := resourceT
+	for {
+		start := strings.Index(remaining, "{")
+		if start < 0 {
+			break
+		}
+		end := matchingBrace(remaining, start)
+		if end < 0 {
+			break
+		}
+		pattern.WriteString(regexp.QuoteMeta(remaining[:start]))
+		variable := remaining[start+1 : end]
+		if i := strings.Index(variable, ":"); i >= 0 {
+			pattern.WriteString("(?:" + variable[i+1:] + ")")
+		} else {
+			pattern.WriteString("[^/]+")
+		}
+		remaining = remaining[end+1:]
+	}
+	pattern.WriteString(regexp.QuoteMeta(remaining))
+	pattern.WriteString("$")
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		re = regexp.MustCompile("^" + regexp.QuoteMeta(resourceT) + "$")
+	}
+	templates[resourceT] = re
+	return re
+}
+
+func matchingBrace(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// MatchBody matches json bodies by value, whatever the spacing and key order, and other
+// bodies byte for byte
+func MatchBody(r *http.Request, body []byte, recorded *rest.FrozenRequest) bool {
+	recordedBody, err := rest.ThawBody(recorded.Body, recorded.BodyEncoding)
+	if err != nil {
+		return false
+	}
+	if emptyBody(body) || emptyBody(recordedBody) {
+		return emptyBody(body) && emptyBody(recordedBody)
+	}
+	var a, b interface{}
+	if json.Unmarshal(body, &a) == nil && json.Unmarshal(recordedBody, &b) == nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return bytes.Equal(body, recordedBody)
+}
+
+// emptyBody is true of no body, and of the null a rest.Client sends without one
+func emptyBody(body []byte) bool {
+	return len(body) == 0 || string(body) == "null"
+}
+
+// MatchHeaders matches the values of the named headers, a redacted recorded value matches
+// any value
+func MatchHeaders(names ...string) Matcher {
+	return func(r *http.Request, body []byte, recorded *rest.FrozenRequest) bool {
+		recordedHeaders := http.Header(recorded.Headers)
+		for _, name := range names {
+			values := recordedHeaders[http.CanonicalHeaderKey(name)]
+			if len(values) == 1 && values[0] == rest.RedactedValue {
+				continue
+			}
+			if !reflect.DeepEqual(r.Header[http.CanonicalHeaderKey(name)], values) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Strategy names the request line matchers, for configuration
+func Strategy(name string) (Matcher, bool) {
+	switch name {
+	case "exact":
+		return MatchExact, true
+	case "ignore-query-order":
+		return MatchIgnoreQueryOrder, true
+	case "template":
+		return MatchTemplate, true
+	}
+	return nil, false
+}
+
+func matches(matchers []Matcher, r *http.Request, body []byte, recorded *rest.FrozenRequest) bool {
+	for _, m := range matchers {
+		if !m(r, body, recorded) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package recording_test
+
+import (
+	"net/http/httptest"
+
+	. "github.com/gotgo/gokn/recording"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Matchers", func() {
+
+	recorded := &rest.FrozenRequest{
+		Verb:      "GET",
+		Resource:  "/v1/orders/7?a=1&b=2&b=3",
+		ResourceT: "/v1/orders/{id:[0-9]+}",
+		Headers:   map[string][]string{"Accept": {"application/json"}, "Authorization": {rest.RedactedValue}},
+		Body:      []byte(`{"a":1,"b":[1,2]}`),
+	}
+
+	It("should match exactly", func() {
+		Expect(MatchExact(httptest.NewRequest("GET", "/v1/orders/7?a=1&b=2&b=3", nil), nil, recorded)).To(BeTrue())
+		Expect(MatchExact(httptest.NewRequest("GET", "/v1/orders/7?b=2&b=3&a=1", nil), nil, recorded)).To(BeFalse())
+		Expect(MatchExact(httptest.NewRequest("POST", "/v1/orders/7?a=1&b=2&b=3", nil), nil, recorded)).To(BeFalse())
+	})
+
+	It("should match the query in any order", func() {
+		Expect(MatchIgnoreQueryOrder(httptest.NewRequest("GET", "/v1/orders/7?b=3&a=1&b=2", nil), nil, recorded)).To(BeTrue())
+		Expect(MatchIgnoreQueryOrder(httptest.NewRequest("GET", "/v1/orders/7?b=3&a=1", nil), nil, recorded)).To(BeFalse())
+		Expect(MatchIgnoreQueryOrder(httptest.NewRequest("GET", "/v1/orders/8?a=1&b=2&b=3", nil), nil, recorded)).To(BeFalse())
+	})
+
+	It("should match the template", func() {
+		Expect(MatchTemplate(httptest.NewRequest("GET", "/v1/orders/99", nil), nil, recorded)).To(BeTrue())
+		Expect(MatchTemplate(httptest.NewRequest("GET", "/v1/orders/abc", nil), nil, recorded)).To(BeFalse())
+		Expect(MatchTemplate(httptest.NewRequest("GET", "/v1/orders/9/items", nil), nil, recorded)).To(BeFalse())
+
+		plain := &rest.FrozenRequest{Verb: "GET", Resource: "/v1/orders/{x}/items/{y}", ResourceT: "/v1/orders/{x}/items/{y}"}
+		Expect(MatchTemplate(httptest.NewRequest("GET", "/v1/orders/1/items/2", nil), nil, plain)).To(BeTrue())
+	})
+
+	It("should match json bodies by value", func() {
+		r := httptest.NewRequest("GET", "/", nil)
+		Expect(MatchBody(r, []byte(`{ "b":[1,2], "a":1 }`), recorded)).To(BeTrue())
+		Expect(MatchBody(r, []byte(`{"b":[2,1],"a":1}`), recorded)).To(BeFalse())
+		Expect(MatchBody(r, nil, recorded)).To(BeFalse())
+	})
+
+	It("should match headers, any value for a redacted one", func() {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		r.Header.Set("Authorization", "Bearer x")
+		Expect(MatchHeaders("accept", "Authorization")(r, nil, recorded)).To(BeTrue())
+		r.Header.Set("Accept", "text/plain")
+		Expect(MatchHeaders("accept")(r, nil, recorded)).To(BeFalse())
+	})
+
+	It("should name the strategies", func() {
+		for _, name := range []string{"exact", "ignore-query-order", "template"} {
+			_, ok := Strategy(name)
+			Expect(ok).To(BeTrue())
+		}
+		_, ok := Strategy("fuzzy")
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,204 @@
+package recording
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gotgo/gokn/exporting"
+	"github.com/gotgo/gokn/handling"
+	"github.com/gotgo/gokn/rest"
+)
+
+// Recorder freezes the requests and replies of the endpoints it is used on and writes them
+// as json lines of rest.FrozenCommunication
+//
+//	Example:
+//
+//		recorder, err := recording.NewFileRecorder("/var/log/api/traffic.jsonl", 100<<20, 5)
+//		recorder.Sampler = handling.NewSampler(0.01)
+//		recorder.MinStatus = 500
+//		root.Use(recorder.Middleware)
+type Recorder struct {
+	Writer io.Writer
+	// Sampler decides which requests are recorded, nil records them all
+	Sampler *handling.Sampler
+	// MinStatus and MaxStatus limit recording to a range of status codes, zero is unbounded
+	MinStatus int
+	MaxStatus int
+	// Redactor cleans the headers and bodies, nil uses the rest.DefaultRedactor
+	Redactor  *rest.Redactor
+	endpoints map[string]bool
+	lock      sync.Mutex
+	failed    uint64
+}
+
+func NewRecorder(writer io.Writer) *Recorder {
+	return &Recorder{
+		Writer:    writer,
+		endpoints: make(map[string]bool),
+	}
+}
+
+// NewFileRecorder records to a file that is rotated once it grows past maxBytes
+func NewFileRecorder(path string, maxBytes int64, maxBackups int) (*Recorder, error) {
+	file, err := exporting.NewRotatingFile(path, maxBytes, maxBackups)
+	if err != nil {
+		return nil, err
+	}
+	return NewRecorder(file), nil
+}
+
+// Only limits recording to the endpoint, call it once for each endpoint to record
+func (rec *Recorder) Only(verb, resourceT string) *Recorder {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	if rec.endpoints == nil {
+		rec.endpoints = make(map[string]bool)
+	}
+	rec.endpoints[verb+" "+resourceT] = true
+	return rec
+}
+
+// Failed is the count of communications that couldn't be written
+func (rec *Recorder) Failed() uint64 {
+	return atomic.LoadUint64(&rec.failed)
+}
+
+func (rec *Recorder) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if x := handling.ExchangeOf(r); x != nil {
+			x.KeepStream = true
+		}
+		next(w, r)
+		if x := handling.ExchangeOf(r); x != nil && rec.records(x) {
+			if err := rec.Record(rec.Freeze(r, w.Header(), x)); err != nil {
+				atomic.AddUint64(&rec.failed, 1)
+			}
+		}
+	}
+}
+
+func (rec *Recorder) records(x *handling.Exchange) bool {
+	if rec.MinStatus > 0 && x.StatusCode < rec.MinStatus {
+		return false
+	}
+	if rec.MaxStatus > 0 && x.StatusCode > rec.MaxStatus {
+		return false
+	}
+
+	rec.lock.Lock()
+	only := len(rec.endpoints) > 0 && !rec.endpoints[x.Endpoint.Verb()+" "+x.Endpoint.ResourceT()]
+	rec.lock.Unlock()
+	if only {
+		return false
+	}
+
+	if rec.Sampler != nil && x.TraceContext != nil {
+		return rec.Sampler.Sampled(x.Endpoint, x.TraceContext.TraceId)
+	}
+	return true
+}
+
+func (rec *Recorder) redactor() *rest.Redactor {
+	if rec.Redactor != nil {
+		return rec.Redactor
+	}
+	return rest.DefaultRedactor
+}
+
+// Freeze converts a finished exchange, the sensitive headers and body fields are redacted
+func (rec *Recorder) Freeze(r *http.Request, responseHeaders http.Header, x *handling.Exchange) *rest.FrozenCommunication {
+	redactor := rec.redactor()
+
+	request := &rest.FrozenRequest{
+		Headers:   redactor.Headers(r.Header),
+		Verb:      r.Method,
+		ResourceT: x.Path,
+		Requestor: &rest.FrozenSender{Location: r.RemoteAddr},
+	}
+	if r.URL != nil {
+		request.Resource = r.URL.RequestURI()
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		request.Requestor.Location = host
+	}
+
+	var decoded interface{}
+	if x.Request != nil {
+		request.Requestor.User = x.Request.Context.Principal
+		decoded = x.Request.Body
+	}
+	requestBody := redactedBody(redactor, x.RequestBody, x.Endpoint.RequestBody(), decoded)
+	request.Body, request.BodyEncoding = rest.FreezeBody(requestBody)
+
+	response := &rest.FrozenResponse{
+		Headers:    redactor.Headers(responseHeaders),
+		StatusCode: strconv.Itoa(x.StatusCode),
+		Status:     x.StatusMessage,
+	}
+	if response.Status == "" {
+		response.Status = http.StatusText(x.StatusCode)
+	}
+
+	responseBody := x.ResponseBody
+	if x.Streamed {
+		//the rows are encoded as they're sent, their sensitive fields can't be redacted
+		if redactor.Sensitive(x.Endpoint.ResponseBody()) {
+			responseBody = nil
+		}
+	} else if x.Response != nil && x.Failure == "" {
+		responseBody = redactedBody(redactor, responseBody, x.Endpoint.ResponseBody(), x.Response.Body)
+	}
+	response.Body, response.BodyEncoding = rest.FreezeBody(responseBody)
+
+	return &rest.FrozenCommunication{
+		Request:  request,
+		Response: response,
+	}
+}
+
+// redactedBody replaces a json body with its redacted decoded value.  A body that wasn't
+// decoded is as sensitive as the endpoint's declared type, and a sensitive body that isn't
+// json, or wasn't decoded, can't be redacted and is dropped
+func redactedBody(redactor *rest.Redactor, raw []byte, declared, decoded interface{}) []byte {
+	sensitive := redactor.Sensitive(decoded)
+	if decoded == nil {
+		sensitive = redactor.Sensitive(declared)
+	}
+	if len(raw) == 0 || !sensitive {
+		return raw
+	}
+	if decoded != nil && json.Valid(raw) {
+		if bts, err := json.Marshal(redactor.Value(decoded)); err == nil {
+			return bts
+		}
+	}
+	return nil
+}
+
+// Record writes the communication as one line
+func (rec *Recorder) Record(c *rest.FrozenCommunication) error {
+	bts, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	bts = append(bts, '\n')
+
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	_, err = rec.Writer.Write(bts)
+	return err
+}
+
+// Close closes the Writer if it is an io.Closer
+func (rec *Recorder) Close() error {
+	if closer, ok := rec.Writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package recording_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/gotgo/gokn/handling"
+	. "github.com/gotgo/gokn/recording"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type Account struct {
+	Name     string `json:"name"`
+	Password string `json:"password,omitempty" sensitive:"true"`
+}
+
+type accountHandler struct {
+	status int
+}
+
+func (ah *accountHandler) Post(req *rest.Request, resp rest.Responder) {
+	if ah.status != 0 {
+		resp.SetStatus(ah.status, "nope", nil)
+		return
+	}
+	account := req.Body.(*Account)
+	resp.SetBody(&Account{Name: account.Name, Password: "returned-secret"})
+}
+
+func (ah *accountHandler) Get(req *rest.Request, resp rest.Responder) {
+	resp.SetContentType("text/plain")
+	resp.SetBody("plain text")
+}
+
+// listHandler replies with a list, streamed as ndjson
+type listHandler struct {
+	list interface{}
+}
+
+func (lh *listHandler) Get(req *rest.Request, resp rest.Responder) {
+	resp.SetBody(lh.list)
+}
+
+func listEndpoint(resourceT string, list interface{}) rest.ServerResource {
+	def := &rest.ResourceDef{ResourceT: resourceT, Verb: "GET", ResponseBody: reflect.TypeOf(list)}
+	return rest.NewServerResource(def, []string{rest.ContentTypeJson}, []string{rest.ContentTypeNdjson})
+}
+
+// testRouter keeps the bound handlers by verb and path template
+type testRouter map[string]func(http.ResponseWriter, *http.Request)
+
+func (tr testRouter) RegisterRoute(verb, path string, f func(http.ResponseWriter, *http.Request)) {
+	tr[verb+" "+path] = f
+}
+
+func accountEndpoint(verb string) rest.ServerResource {
+	def := &rest.ResourceDef{
+		ResourceT:    "/accounts/{id}",
+		Verb:         verb,
+		RequestBody:  reflect.TypeOf(Account{}),
+		ResponseBody: reflect.TypeOf(Account{}),
+	}
+	return rest.NewServerResource(def, []string{rest.ContentTypeJson}, []string{rest.ContentTypeJson, "text/plain"})
+}
+
+func frozenLines(buf *bytes.Buffer) []*rest.FrozenCommunication {
+	frozen := []*rest.FrozenCommunication{}
+	scanner := bufio.NewScanner(bytes.NewReader(buf.Bytes()))
+	for scanner.Scan() {
+		fc := new(rest.FrozenCommunication)
+		Expect(json.Unmarshal(scanner.Bytes(), fc)).To(Succeed())
+		frozen = append(frozen, fc)
+	}
+	return frozen
+}
+
+var _ = Describe("Recorder", func() {
+
+	var (
+		root     *handling.RootHandler
+		router   testRouter
+		handler  *accountHandler
+		out      *bytes.Buffer
+		recorder *Recorder
+	)
+
+	BeforeEach(func() {
+		root = handling.NewRootHandler()
+		root.Binder = func(h rest.HandlerFunc) func(*rest.Request, rest.Responder) {
+			return func(req *rest.Request, resp rest.Responder) {
+				req.Context.Principal = "alice"
+				h(req, resp)
+			}
+		}
+		router = make(testRouter)
+		handler = new(accountHandler)
+		out = new(bytes.Buffer)
+		recorder = NewRecorder(out)
+		root.Use(recorder.Middleware)
+		root.Bind(router, accountEndpoint("POST"), handler, "/v1")
+		root.Bind(router, accountEndpoint("GET"), handler, "/v1")
+	})
+
+	post := func(body string) {
+		r := httptest.NewRequest("POST", "/v1/accounts/7?x=1", strings.NewReader(body))
+		r.Header.Set("Content-Type", rest.ContentTypeJson)
+		r.Header.Set("Authorization", "Bearer abc")
+		router["POST /v1/accounts/{id}"](httptest.NewRecorder(), r)
+	}
+
+	It("should freeze the request and response", func() {
+		post(`{"name":"bob","password":"hunter2"}`)
+
+		frozen := frozenLines(out)
+		Expect(frozen).To(HaveLen(1))
+		req, resp := frozen[0].Request, frozen[0].Response
+
+		Expect(req.Verb).To(Equal("POST"))
+		Expect(req.Resource).To(Equal("/v1/accounts/7?x=1"))
+		Expect(req.ResourceT).To(Equal("/v1/accounts/{id}"))
+		Expect(req.Requestor.User).To(Equal("alice"))
+		Expect(req.Requestor.Location).To(Equal("192.0.2.1"))
+		Expect(req.Headers["Authorization"]).To(Equal([]string{rest.RedactedValue}))
+		Expect(string(req.Body)).To(Equal(`{"name":"bob","password":"[REDACTED]"}`))
+
+		Expect(resp.StatusCode).To(Equal("200"))
+		Expect(resp.Headers["Content-Type"]).To(Equal([]string{rest.ContentTypeJson}))
+		Expect(string(resp.Body)).To(Equal(`{"name":"bob","password":"[REDACTED]"}`))
+		Expect(out.String()).ToNot(ContainSubstring("hunter2"))
+		Expect(out.String()).ToNot(ContainSubstring("returned-secret"))
+	})
+
+	It("should drop the sensitive bodies it can't redact", func() {
+		def := &rest.ResourceDef{ResourceT: "/signup", Verb: "POST", RequestBody: reflect.TypeOf(Account{}), StrictBody: true}
+		form := "application/x-www-form-urlencoded"
+		root.Bind(router, rest.NewServerResource(def, []string{rest.ContentTypeJson, form}, []string{rest.ContentTypeJson}), handler, "/v1")
+		for contentType, body := range map[string]string{
+			form:                 "name=bob&password=hunter2",
+			rest.ContentTypeJson: `{"name":"bob","password":"hunter2","admin":true}`,
+		} {
+			r := httptest.NewRequest("POST", "/v1/signup", strings.NewReader(body))
+			r.Header.Set("Content-Type", contentType)
+			router["POST /v1/signup"](httptest.NewRecorder(), r)
+		}
+
+		frozen := frozenLines(out)
+		Expect(frozen).To(HaveLen(2))
+		for _, fc := range frozen {
+			Expect(string(fc.Request.Body)).To(Equal("null"))
+		}
+		Expect(out.String()).ToNot(ContainSubstring("hunter2"))
+	})
+
+	It("should freeze text bodies", func() {
+		router["GET /v1/accounts/{id}"](httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/accounts/7", nil))
+
+		resp := frozenLines(out)[0].Response
+		Expect(resp.BodyEncoding).To(Equal(rest.BodyEncodingText))
+		body, err := rest.ThawBody(resp.Body, resp.BodyEncoding)
+		Expect(err).To(BeNil())
+		Expect(string(body)).To(Equal("plain text"))
+	})
+
+	It("should freeze streamed bodies, unless their items are sensitive", func() {
+		names, accounts := []string{"a", "b"}, []*Account{{Name: "bob", Password: "hunter2"}}
+		root.Bind(router, listEndpoint("/names", names), &listHandler{list: names}, "/v1")
+		root.Bind(router, listEndpoint("/accounts", accounts), &listHandler{list: accounts}, "/v1")
+		for _, path := range []string{"/v1/names", "/v1/accounts"} {
+			r := httptest.NewRequest("GET", path, nil)
+			r.Header.Set("Accept", rest.ContentTypeNdjson)
+			router["GET "+path](httptest.NewRecorder(), r)
+		}
+
+		frozen := frozenLines(out)
+		Expect(frozen).To(HaveLen(2))
+		body, err := rest.ThawBody(frozen[0].Response.Body, frozen[0].Response.BodyEncoding)
+		Expect(err).To(BeNil())
+		Expect(string(body)).To(Equal("\"a\"\n\"b\"\n"))
+		Expect(string(frozen[1].Response.Body)).To(Equal("null"))
+		Expect(out.String()).ToNot(ContainSubstring("hunter2"))
+	})
+
+	It("should filter by status", func() {
+		recorder.MinStatus = 400
+		post(`{"name":"ok"}`)
+		handler.status = http.StatusConflict
+		post(`{"name":"conflict"}`)
+
+		frozen := frozenLines(out)
+		Expect(frozen).To(HaveLen(1))
+		Expect(frozen[0].Response.StatusCode).To(Equal("409"))
+		Expect(frozen[0].Response.Status).To(Equal("nope"))
+	})
+
+	It("should filter by endpoint", func() {
+		recorder.Only("GET", "/accounts/{id}")
+		post(`{"name":"bob"}`)
+		Expect(out.Len()).To(Equal(0))
+	})
+
+	It("should sample", func() {
+		recorder.Sampler = handling.NewSampler(0)
+		post(`{"name":"bob"}`)
+		Expect(out.Len()).To(Equal(0))
+	})
+
+	It("should rotate files", func() {
+		dir, err := ioutil.TempDir("", "recording")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "traffic.jsonl")
+		fileRecorder, err := NewFileRecorder(path, 200, 2)
+		Expect(err).To(BeNil())
+
+		for i := 0; i < 3; i++ {
+			fc := &rest.FrozenCommunication{
+				Request:  &rest.FrozenRequest{Verb: "GET", Resource: strings.Repeat("/x", 50)},
+				Response: &rest.FrozenResponse{StatusCode: "200"},
+			}
+			Expect(fileRecorder.Record(fc)).To(Succeed())
+		}
+		Expect(fileRecorder.Close()).To(Succeed())
+
+		Expect(path).To(BeAnExistingFile())
+		Expect(path + ".1").To(BeAnExistingFile())
+		Expect(fileRecorder.Failed()).To(Equal(uint64(0)))
+	})
+})
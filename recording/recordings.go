@@ -0,0 +1,68 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gotgo/gokn/rest"
+)
+
+// maxLine is the longest recorded communication that can be read
+const maxLine = 64 << 20
+
+// ReadRecordings reads json lines of rest.FrozenCommunication, blank lines are skipped
+func ReadRecordings(reader io.Reader) ([]*rest.FrozenCommunication, error) {
+	recordings := []*rest.FrozenCommunication{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		fc := new(rest.FrozenCommunication)
+		if err := json.Unmarshal([]byte(text), fc); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		if fc.Request == nil || fc.Response == nil {
+			return nil, fmt.Errorf("line %d: request and response are required", line)
+		}
+		recordings = append(recordings, fc)
+	}
+	return recordings, scanner.Err()
+}
+
+// LoadRecordings reads the recordings of every file, in order
+func LoadRecordings(paths ...string) ([]*rest.FrozenCommunication, error) {
+	recordings := []*rest.FrozenCommunication{}
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		fcs, err := ReadRecordings(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		recordings = append(recordings, fcs...)
+	}
+	return recordings, nil
+}
+
+// WriteRecordings writes the recordings as json lines
+func WriteRecordings(writer io.Writer, recordings []*rest.FrozenCommunication) error {
+	encoder := json.NewEncoder(writer)
+	for _, fc := range recordings {
+		if err := encoder.Encode(fc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package recording
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gotgo/gokn/rest"
+)
+
+// ReplayServer replies to requests with the recorded responses of matching requests.  When
+// several recordings match they are replayed in recorded order, then the last one repeats.
+//
+//	Example:
+//
+//		recordings, err := recording.LoadRecordings("testdata/orders.jsonl")
+//		replay := recording.NewReplayServer(recordings)
+//		replay.Matchers = []recording.Matcher{recording.MatchTemplate, recording.MatchBody}
+//		replay.Strict = true
+//		server := httptest.NewServer(replay)
+//		...
+//		Expect(replay.Err()).To(BeNil())
+type ReplayServer struct {
+	// Matchers must all match, the default is MatchExact
+	Matchers []Matcher
+	// Strict replies 501 Not Implemented to unmatched requests and reports them from Err,
+	// otherwise they get a 404 Not Found
+	Strict bool
+	// OnUnmatched is called with each request that matched no recording
+	OnUnmatched func(r *http.Request)
+	lock        sync.Mutex
+	recordings  []*rest.FrozenCommunication
+	replayed    map[*rest.FrozenCommunication]int
+	unmatched   []string
+}
+
+func NewReplayServer(recordings []*rest.FrozenCommunication) *ReplayServer {
+	return &ReplayServer{
+		Matchers:   []Matcher{MatchExact},
+		recordings: recordings,
+		replayed:   make(map[*rest.FrozenCommunication]int),
+	}
+}
+
+// Add more recordings, they match after those already added
+func (rs *ReplayServer) Add(recordings ...*rest.FrozenCommunication) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	rs.recordings = append(rs.recordings, recordings...)
+}
+
+// Match finds the recording to replay and marks it replayed, nil when none match
+func (rs *ReplayServer) Match(r *http.Request, body []byte) *rest.FrozenCommunication {
+	return rs.match(rs.Matchers, r, body)
+}
+
+func (rs *ReplayServer) match(matchers []Matcher, r *http.Request, body []byte) *rest.FrozenCommunication {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	if rs.replayed == nil {
+		rs.replayed = make(map[*rest.FrozenCommunication]int)
+	}
+
+	var last *rest.FrozenCommunication
+	for _, fc := range rs.recordings {
+		if !matches(matchers, r, body, fc.Request) {
+			continue
+		}
+		if rs.replayed[fc] == 0 {
+			rs.replayed[fc]++
+			return fc
+		}
+		last = fc
+	}
+	if last != nil {
+		rs.replayed[last]++
+	}
+	return last
+}
+
+// Unmatched lists the requests that matched no recording, as "VERB resource"
+func (rs *ReplayServer) Unmatched() []string {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	return append([]string{}, rs.unmatched...)
+}
+
+// Err is an error listing the unmatched requests of a Strict server
+func (rs *ReplayServer) Err() error {
+	unmatched := rs.Unmatched()
+	if !rs.Strict || len(unmatched) == 0 {
+		return nil
+	}
+	return errors.New("no recording matched: " + strings.Join(unmatched, ", "))
+}
+
+func (rs *ReplayServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body []byte
+	if r.Body != nil {
+		body, _ = ioutil.ReadAll(r.Body)
+		r.Body.Close()
+	}
+
+	fc := rs.Match(r, body)
+	if fc == nil {
+		request := fmt.Sprintf("%s %s", r.Method, r.URL.RequestURI())
+		rs.lock.Lock()
+		rs.unmatched = append(rs.unmatched, request)
+		rs.lock.Unlock()
+		if rs.OnUnmatched != nil {
+			rs.OnUnmatched(r)
+		}
+
+		if rs.Strict {
+			http.Error(w, "no recording matches "+request, http.StatusNotImplemented)
+		} else {
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	if err := Replay(w, fc.Response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Replay writes a recorded response
+func Replay(w http.ResponseWriter, response *rest.FrozenResponse) error {
+	status, err := strconv.Atoi(response.StatusCode)
+	if err != nil {
+		return fmt.Errorf("recorded status code %q is not a number", response.StatusCode)
+	}
+	body, err := rest.ThawBody(response.Body, response.BodyEncoding)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range response.Headers {
+		if http.CanonicalHeaderKey(k) == "Content-Length" {
+			continue
+		}
+		w.Header()[http.CanonicalHeaderKey(k)] = v
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	w.Write(body)
+	return nil
+}
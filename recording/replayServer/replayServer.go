@@ -0,0 +1,59 @@
+// replayServer serves recorded rest.FrozenCommunication json lines files
+//
+//	replayServer -addr :8080 -strategy template -match-body -strict traffic.jsonl more.jsonl
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gotgo/gokn/recording"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	strategy := flag.String("strategy", "exact", "request matching: exact, ignore-query-order or template")
+	matchBody := flag.Bool("match-body", false, "match the request body too")
+	matchHeaders := flag.String("match-headers", "", "comma separated headers that must match too")
+	strict := flag.Bool("strict", false, "reply 501 to unmatched requests, rather than 404")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] recording.jsonl...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	matcher, ok := recording.Strategy(*strategy)
+	if !ok {
+		log.Fatalf("unknown strategy %q", *strategy)
+	}
+	recordings, err := recording.LoadRecordings(flag.Args()...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := recording.NewReplayServer(recordings)
+	server.Strict = *strict
+	server.Matchers = []recording.Matcher{matcher}
+	if *matchBody {
+		server.Matchers = append(server.Matchers, recording.MatchBody)
+	}
+	if *matchHeaders != "" {
+		server.Matchers = append(server.Matchers, recording.MatchHeaders(strings.Split(*matchHeaders, ",")...))
+	}
+
+	server.OnUnmatched = func(r *http.Request) {
+		log.Printf("no recording matches %s %s", r.Method, r.URL.RequestURI())
+	}
+
+	log.Printf("replaying %d recordings on %s", len(recordings), *addr)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
@@ -0,0 +1,133 @@
+package recording_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/gotgo/gokn/recording"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func frozen(verb, resource, resourceT, status, body string) *rest.FrozenCommunication {
+	fc := &rest.FrozenCommunication{
+		Request: &rest.FrozenRequest{Verb: verb, Resource: resource, ResourceT: resourceT},
+		Response: &rest.FrozenResponse{
+			StatusCode: status,
+			Headers:    map[string][]string{"Content-Type": {"application/json"}, "Content-Length": {"999"}},
+		},
+	}
+	fc.Response.Body, fc.Response.BodyEncoding = rest.FreezeBody([]byte(body))
+	return fc
+}
+
+var _ = Describe("Replay", func() {
+
+	var (
+		recordings []*rest.FrozenCommunication
+		replay     *ReplayServer
+		server     *httptest.Server
+	)
+
+	BeforeEach(func() {
+		recordings = []*rest.FrozenCommunication{
+			frozen("GET", "/orders/1", "/orders/{id}", "200", `{"id":1}`),
+			frozen("GET", "/orders/1", "/orders/{id}", "200", `{"id":1,"state":"shipped"}`),
+			frozen("DELETE", "/orders/1", "/orders/{id}", "204", ``),
+		}
+		replay = NewReplayServer(recordings)
+		server = httptest.NewServer(replay)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	call := func(verb, path string) (int, string, http.Header) {
+		req, _ := http.NewRequest(verb, server.URL+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).To(BeNil())
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), resp.Header
+	}
+
+	It("should replay the recorded status, headers and body", func() {
+		status, body, headers := call("GET", "/orders/1")
+		Expect(status).To(Equal(200))
+		Expect(body).To(Equal(`{"id":1}`))
+		Expect(headers.Get("Content-Type")).To(Equal("application/json"))
+		Expect(headers.Get("Content-Length")).To(Equal("8"))
+
+		status, body, _ = call("DELETE", "/orders/1")
+		Expect(status).To(Equal(204))
+		Expect(body).To(Equal(""))
+	})
+
+	It("should replay matches in order then repeat the last", func() {
+		_, first, _ := call("GET", "/orders/1")
+		_, second, _ := call("GET", "/orders/1")
+		_, third, _ := call("GET", "/orders/1")
+		Expect(first).To(Equal(`{"id":1}`))
+		Expect(second).To(ContainSubstring("shipped"))
+		Expect(third).To(Equal(second))
+	})
+
+	It("should match templates", func() {
+		replay.Matchers = []Matcher{MatchTemplate}
+		status, _, _ := call("GET", "/orders/42")
+		Expect(status).To(Equal(200))
+	})
+
+	It("should 404 unmatched requests", func() {
+		status, _, _ := call("GET", "/orders/42")
+		Expect(status).To(Equal(http.StatusNotFound))
+		Expect(replay.Unmatched()).To(Equal([]string{"GET /orders/42"}))
+		Expect(replay.Err()).To(BeNil())
+	})
+
+	It("should fail unmatched requests when strict", func() {
+		replay.Strict = true
+		var seen *http.Request
+		replay.OnUnmatched = func(r *http.Request) { seen = r }
+
+		status, body, _ := call("GET", "/orders/42?x=1")
+		Expect(status).To(Equal(http.StatusNotImplemented))
+		Expect(body).To(ContainSubstring("GET /orders/42?x=1"))
+		Expect(seen.URL.Path).To(Equal("/orders/42"))
+		Expect(replay.Err()).To(MatchError(ContainSubstring("GET /orders/42?x=1")))
+	})
+
+	Context("Files", func() {
+		It("should write and load recordings", func() {
+			dir, err := ioutil.TempDir("", "replay")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "orders.jsonl")
+			var buf bytes.Buffer
+			Expect(WriteRecordings(&buf, recordings)).To(Succeed())
+			Expect(ioutil.WriteFile(path, append(buf.Bytes(), '\n'), 0644)).To(Succeed())
+
+			loaded, err := LoadRecordings(path, path)
+			Expect(err).To(BeNil())
+			Expect(loaded).To(HaveLen(6))
+			Expect(loaded[1].Response.Body).To(Equal(recordings[1].Response.Body))
+		})
+
+		It("should report the bad line", func() {
+			_, err := ReadRecordings(strings.NewReader("{\"request\":{},\"response\":{}}\nnot json\n"))
+			Expect(err).To(MatchError(HavePrefix("line 2:")))
+
+			_, err = ReadRecordings(strings.NewReader("{}\n"))
+			Expect(err).To(MatchError(ContainSubstring("required")))
+		})
+	})
+})
@@ -0,0 +1,118 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"reflect"
+)
+
+// ContentTypeProblemJson is the content type of an RFC 7807 Problem
+const ContentTypeProblemJson = "application/problem+json"
+
+// Problem is the RFC 7807 body of an error reply
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// APIError is a reply of a status other than the Client's SuccessStatuses.  It unwraps to
+// the domain Error of the reply, so errors.Is(err, rest.NotFound) holds of a 404.
+//
+//	Example:
+//
+//		_, err := client.Fetch(req, ctx, &order)
+//		var apiErr *rest.APIError
+//		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+//			...
+//		}
+type APIError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+	// Verb and URL of the request, and its RequestId
+	Verb      string
+	URL       string
+	RequestId string
+	// Problem is the body of a problem+json reply
+	Problem *Problem
+	// Detail is the body decoded into a new Client.ErrorType, when it's set
+	Detail interface{}
+	// Err is the domain Error of the reply, see Client.ResponseError
+	Err error
+}
+
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("%s %s: %s", e.Verb, e.URL, e.Status)
+	if e.Problem != nil && e.Problem.Detail != "" {
+		return message + ": " + e.Problem.Detail
+	} else if e.Problem != nil && e.Problem.Title != "" {
+		return message + ": " + e.Problem.Title
+	} else if domain, ok := e.Err.(*Error); ok && domain.Message != e.Status {
+		return message + ": " + domain.Error()
+	}
+	return message
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// success is true of the Client's SuccessStatuses, 2xx when it has none
+func (c *Client) success(status int) bool {
+	if len(c.SuccessStatuses) == 0 {
+		return status >= 200 && status <= 299
+	}
+	for _, s := range c.SuccessStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// apiError reads the body of a reply that failed, it's replaced so it can be read again
+func (c *Client) apiError(req *http.Request, resp *EndpointResponse) *APIError {
+	hr := resp.HttpResponse
+	body, _ := resp.Bytes()
+	hr.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	e := newAPIError(hr, body)
+	e.Verb = req.Method
+	e.URL = req.URL.String()
+	e.RequestId = req.Header.Get(RequestIdHeader)
+
+	if e.Problem == nil && c.ErrorType != nil && len(body) > 0 {
+		detail := reflect.New(c.ErrorType).Interface()
+		if err := c.decode(resp, body, detail); err == nil {
+			e.Detail = detail
+		}
+	}
+	if domain, ok := c.ResponseError(resp, body).(*Error); ok {
+		e.Err = domain
+	}
+	return e
+}
+
+func newAPIError(hr *http.Response, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: hr.StatusCode,
+		Status:     hr.Status,
+		Header:     hr.Header,
+		Body:       body,
+	}
+	if mediaType, _, err := mime.ParseMediaType(hr.Header.Get("Content-Type")); err == nil && mediaType == ContentTypeProblemJson {
+		problem := new(Problem)
+		if err := json.Unmarshal(body, problem); err == nil {
+			e.Problem = problem
+		}
+	}
+	return e
+}
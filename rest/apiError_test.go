@@ -0,0 +1,128 @@
+package rest_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type legacyError struct {
+	Reason string `json:"reason"`
+}
+
+var _ = Describe("APIError", func() {
+	var (
+		server *httptest.Server
+		client *rest.Client
+		reply  func(w http.ResponseWriter)
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reply(w)
+		}))
+		client = rest.NewClient()
+		client.Endpoints = []*rest.ResourceEndpoint{{Scheme: "http", Host: strings.TrimPrefix(server.URL, "http://")}}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	get := func() *rest.ClientRequest {
+		return &rest.ClientRequest{Verb: "GET", Resource: "/orders", Headers: map[string][]string{"X-Request-Id": {"req-1"}}}
+	}
+
+	It("should not decode an error into the success type", func() {
+		reply = func(w http.ResponseWriter) {
+			w.Header().Set("Content-Type", rest.ContentTypeProblemJson)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"title":"Not Found","status":404,"detail":"no order 7"}`))
+		}
+		result := &order{Note: "untouched"}
+		resp, err := client.Fetch(get(), rest.NewRequestContext(), result)
+		Expect(result.Note).To(Equal("untouched"))
+		Expect(resp).ToNot(BeNil())
+
+		var apiErr *rest.APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.StatusCode).To(Equal(http.StatusNotFound))
+		Expect(apiErr.Verb).To(Equal("GET"))
+		Expect(apiErr.URL).To(Equal(server.URL + "/orders"))
+		Expect(apiErr.RequestId).To(Equal("req-1"))
+		Expect(apiErr.Header.Get("Content-Type")).To(Equal(rest.ContentTypeProblemJson))
+		Expect(apiErr.Problem.Detail).To(Equal("no order 7"))
+		Expect(err.Error()).To(HaveSuffix("404 Not Found: no order 7"))
+		Expect(errors.Is(err, rest.NotFound)).To(BeTrue())
+	})
+
+	It("should unwrap to the domain error of the reply", func() {
+		reply = func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"kind":"conflict","code":"taken","message":"name taken"}`))
+		}
+		_, err := client.Send(get(), rest.NewRequestContext())
+		var domain *rest.Error
+		Expect(errors.As(err, &domain)).To(BeTrue())
+		Expect(domain.Code).To(Equal("taken"))
+		Expect(err.Error()).To(HaveSuffix("409 Conflict: name taken"))
+	})
+
+	It("should decode the configured error type", func() {
+		client.ErrorType = reflect.TypeOf(legacyError{})
+		reply = func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"reason":"upstream"}`))
+		}
+		resp, err := client.Send(get(), rest.NewRequestContext())
+		apiErr := err.(*rest.APIError)
+		Expect(apiErr.Detail).To(Equal(&legacyError{Reason: "upstream"}))
+		Expect(apiErr.Err).To(BeNil())
+		Expect(apiErr.Body).To(MatchJSON(`{"reason":"upstream"}`))
+
+		bts, err := resp.Bytes()
+		Expect(err).To(BeNil())
+		Expect(bts).To(Equal(apiErr.Body))
+	})
+
+	It("should only succeed with the configured success statuses", func() {
+		reply = func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"id":1}`))
+		}
+		result := new(order)
+		_, err := client.Fetch(get(), rest.NewRequestContext(), result)
+		Expect(err).To(BeNil())
+		Expect(result.Id).To(Equal(1))
+
+		client.SuccessStatuses = []int{http.StatusOK}
+		_, err = client.Fetch(get(), rest.NewRequestContext(), new(order))
+		Expect(err).To(BeAssignableToTypeOf(&rest.APIError{}))
+	})
+
+	It("should leave the result as it is when the reply is empty", func() {
+		reply = func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		result := &order{Note: "untouched"}
+		_, err := client.Fetch(get(), rest.NewRequestContext(), result)
+		Expect(err).To(BeNil())
+		Expect(result.Note).To(Equal("untouched"))
+	})
+
+	It("should be returned by Bytes", func() {
+		reply = func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_, err := rest.Bytes(client.Send(get(), rest.NewRequestContext()))
+		Expect(err).To(BeAssignableToTypeOf(&rest.APIError{}))
+		Expect(err.(*rest.APIError).StatusCode).To(Equal(http.StatusInternalServerError))
+	})
+})
@@ -0,0 +1,23 @@
+package rest
+
+import (
+	"github.com/fxamacker/cbor/v2"
+)
+
+// ContentTypeCbor is the content type of CBOR
+const ContentTypeCbor = "application/cbor"
+
+// CborCodec encodes CBOR, fields without a cbor tag use their json tag
+type CborCodec struct{}
+
+func (CborCodec) ContentType() string {
+	return ContentTypeCbor
+}
+
+func (CborCodec) Encode(v interface{}) ([]byte, error) {
+	return cbor.Marshal(v)
+}
+
+func (CborCodec) Decode(data []byte, v interface{}) error {
+	return cbor.Unmarshal(data, Target(v))
+}
@@ -0,0 +1,37 @@
+package rest_test
+
+import (
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CborCodec", func() {
+	codec := rest.CborCodec{}
+
+	It("should round trip, with the json tags as a fallback", func() {
+		bts, err := codec.Encode(&packedOrder{Id: 1, Note: "a", Total: 2})
+		Expect(err).To(BeNil())
+
+		var fields map[string]interface{}
+		Expect(cbor.Unmarshal(bts, &fields)).To(Succeed())
+		Expect(fields).To(HaveKey("id"))
+		Expect(fields).To(HaveKey("n"))
+		Expect(fields).ToNot(HaveKey("tags"))
+		Expect(fields).ToNot(HaveKey("Total"))
+
+		decoded := new(packedOrder)
+		Expect(codec.Decode(bts, decoded)).To(Succeed())
+		Expect(*decoded).To(Equal(packedOrder{Id: 1, Note: "a"}))
+	})
+
+	It("should decode through an interface holding a pointer", func() {
+		bts, _ := codec.Encode([]packedOrder{{Id: 1}, {Id: 2}})
+		var list []packedOrder
+		var v interface{} = &list
+		Expect(codec.Decode(bts, &v)).To(Succeed())
+		Expect(list).To(HaveLen(2))
+	})
+})
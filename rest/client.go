@@ -2,7 +2,6 @@ package rest
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -10,10 +9,12 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"reflect"
 	"strings"
 
 	"github.com/fatih/structs"
 	"github.com/gotgo/fw/tracing"
+	"github.com/gotgo/gokn/metering"
 )
 
 // Client executes REST calls
@@ -23,6 +24,23 @@ type Client struct {
 	Encoder   func(v interface{}) ([]byte, error)
 	Decoder   func(data []byte, v interface{}) error
 	Tracer    tracing.RequestTracer
+	// Metrics, when set, records RED metrics per ClientRequest.Definition. See NewClientMetrics
+	Metrics *metering.RED
+	// Propagator injects the trace context into outbound requests, nil uses the DefaultPropagator
+	Propagator Propagator
+	// Transport sends the requests, nil uses the http.DefaultTransport
+	Transport http.RoundTripper
+	// SuccessStatuses are the statuses of a reply that succeeded, any 2xx when empty.  Send
+	// returns an *APIError for the others
+	SuccessStatuses []int
+	// ErrorType, when set, is the type error bodies other than problem+json decode into, as
+	// the APIError's Detail
+	ErrorType reflect.Type
+	// ContentType, when set, is the content type of the request bodies and the one Accepted,
+	// replies decode with the Codec of their content type.  The Encoder and Decoder are used
+	// when it's empty
+	ContentType string
+	Codecs      *Codecs
 }
 
 type Sender interface {
@@ -31,9 +49,10 @@ type Sender interface {
 
 func NewClient() *Client {
 	client := &Client{
-		Encoder: json.Marshal,
-		Decoder: json.Unmarshal,
+		Encoder: JsonCodec{}.Encode,
+		Decoder: JsonCodec{}.Decode,
 		Tracer:  new(tracing.NopClientTracer),
+		Codecs:  NewCodecs(),
 	}
 	return client
 }
@@ -51,35 +70,54 @@ func (t *Client) SetHeader(h interface{}) error {
 	return nil
 }
 
-// Fetch makes it easier to Unmarshal the response from Do
+// Fetch makes it easier to Unmarshal the response from Do, only a reply of the
+// SuccessStatuses is unmarshaled, the others are an *APIError.  An empty reply, or a nil v,
+// leaves v as it is
 func (c *Client) Fetch(r *ClientRequest, ctx *RequestContext, v interface{}) (*EndpointResponse, error) {
 	if resp, err := c.Send(r, ctx); err != nil {
-		return nil, err
+		return resp, err
 	} else if bytes, err := resp.Bytes(); err != nil {
 		return nil, err
+	} else if v == nil || len(bytes) == 0 {
+		return resp, nil
 	} else {
-		err := c.unmarshal(bytes, &v)
+		err := c.decode(resp, bytes, v)
+		if err != nil {
+			c.decodeFailed(r)
+		}
 		return resp, err
 	}
 }
 
+// Send sends the request, a reply of a status other than the SuccessStatuses is returned along
+// with an *APIError of it
 func (c *Client) Send(r *ClientRequest, ctx *RequestContext) (*EndpointResponse, error) {
 	tracer := ctx.Trace.NewRequest(resourceName(r), getArgs(r), r.Headers)
 	tracer.Begin()
 	defer tracer.End()
 
-	client := new(http.Client)
+	client := &http.Client{Transport: c.Transport}
+	observation := c.beginObservation(r)
 
 	if req, err := c.NewHttpRequest(r); err != nil {
 		tracer.Annotate(tracing.FromError, "request", err)
+		observation.encodeFailed()
 		return nil, err
-	} else if resp, err := client.Do(req); err != nil {
+	} else if resp, err := client.Do(c.propagate(req, ctx)); err != nil {
 		tracer.Annotate(tracing.FromError, "request", err)
+		observation.end(0, req.ContentLength)
 		return nil, err
 	} else {
+		observation.end(resp.StatusCode, req.ContentLength)
+		resp.Body = observation.measure(resp.Body)
 		resp := &EndpointResponse{
 			HttpResponse: resp,
 		}
+		if !c.success(resp.HttpResponse.StatusCode) {
+			apiErr := c.apiError(req, resp)
+			tracer.Annotate(tracing.FromError, "response", apiErr)
+			return resp, apiErr
+		}
 		return resp, nil
 	}
 }
@@ -97,11 +135,28 @@ func (c *Client) NewHttpRequest(cr *ClientRequest) (*http.Request, error) {
 		bts = b
 	}
 
+	//client headers first, so the request headers override them
+	headers := make(http.Header)
+	for k, v := range c.Headers {
+		headers[k] = v
+	}
+	for k, v := range cr.Headers {
+		headers[k] = v
+	}
+	if c.codec() != nil {
+		if headers.Get("Content-Type") == "" && len(bts) > 0 {
+			headers.Set("Content-Type", c.ContentType)
+		}
+		if headers.Get("Accept") == "" {
+			headers.Set("Accept", c.ContentType)
+		}
+	}
+
 	bodyCloser := ioutil.NopCloser(bytes.NewBuffer(bts))
 	endpoint := c.endpoint() //TODO: retry on different endpoint if can't connect
 	req := &http.Request{
 		Method:        cr.Verb,
-		Header:        cr.Headers,
+		Header:        headers,
 		Body:          bodyCloser,
 		ContentLength: int64(len(bts)),
 		Proto:         "HTTP/1.1",
@@ -118,6 +173,28 @@ func (c *Client) NewHttpRequest(cr *ClientRequest) (*http.Request, error) {
 	return req, nil
 }
 
+// propagate injects a child of the context's span, or starts a new trace when the
+// context has none
+func (c *Client) propagate(req *http.Request, ctx *RequestContext) *http.Request {
+	if ctx != nil && ctx.RequestId != "" && req.Header.Get(RequestIdHeader) == "" {
+		req.Header.Set(RequestIdHeader, ctx.RequestId)
+	}
+
+	var tc *TraceContext
+	if ctx != nil && ctx.TraceContext != nil {
+		tc = ctx.TraceContext.Child()
+	} else {
+		tc = NewTraceContext()
+	}
+
+	propagator := c.Propagator
+	if propagator == nil {
+		propagator = DefaultPropagator
+	}
+	propagator.Inject(tc, req.Header)
+	return req
+}
+
 func splitQueryPath(r string) (path, query string) {
 	parts := strings.Split(r, "?")
 	path = parts[0]
@@ -135,7 +212,7 @@ func resourceName(r *ClientRequest) string {
 	if r.Definition == nil {
 		return fmt.Sprintf("%s - %s", r.Verb, r.Resource)
 	} else {
-		return fmt.Sprintf("%s - %s", r.Verb, r.Definition.ResourceT)
+		return fmt.Sprintf("%s - %s", r.Verb, r.Definition.ResourceT())
 	}
 }
 
@@ -158,24 +235,53 @@ func randInt(min int, max int) int {
 	return min + rand.Intn(max-min)
 }
 
-// marshal uses the Client.Encoder if it's not nil; otherwise,
-// uses json.Marshal
+// codec is the Codec of the Client's ContentType, or nil
+func (t *Client) codec() Codec {
+	if t.ContentType == "" {
+		return nil
+	}
+	return t.Codecs.Get(t.ContentType)
+}
+
+// marshal uses the Codec of the ContentType, or the Client.Encoder if it's not nil;
+// otherwise, uses the JsonCodec
 func (t *Client) marshal(v interface{}) ([]byte, error) {
 	e := t.Encoder
-	if e == nil {
-		e = json.Marshal
+	if codec := t.codec(); codec != nil {
+		e = codec.Encode
+	} else if e == nil {
+		e = JsonCodec{}.Encode
 	}
 	bytes, err := e(v)
 	return bytes, err
 }
 
 // unmarshal uses the Client.Decoder if it's not nil; otherwise,
-// uses json.Unmarsal
+// uses the JsonCodec
 func (t *Client) unmarshal(bytes []byte, v interface{}) error {
 	d := t.Decoder
 	if d == nil {
-		d = json.Unmarshal
+		d = JsonCodec{}.Decode
 	}
 	err := d(bytes, &v)
 	return err
 }
+
+// decode unmarshals a reply with the Codec of its content type, when the Client has a
+// ContentType.  Text in another charset is decoded to utf-8 first
+func (t *Client) decode(resp *EndpointResponse, bytes []byte, v interface{}) error {
+	if resp == nil || resp.HttpResponse == nil {
+		return t.unmarshal(bytes, v)
+	}
+	contentType := resp.HttpResponse.Header.Get("Content-Type")
+	bytes, err := DecodeCharset(bytes, contentType)
+	if err != nil {
+		return err
+	}
+	if t.codec() != nil {
+		if codec := t.Codecs.Get(contentType); codec != nil {
+			return codec.Decode(bytes, &v)
+		}
+	}
+	return t.unmarshal(bytes, v)
+}
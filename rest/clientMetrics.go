@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gotgo/gokn/metering"
+)
+
+// NewClientMetrics creates the RED metrics the Client records per ClientRequest.Definition
+func NewClientMetrics(registry *metering.Registry) *metering.RED {
+	return metering.NewRED(registry, metering.ClientPrefix)
+}
+
+// metricLabels are the method and the ResourceT, never the concrete Resource which
+// would give every id its own series
+func metricLabels(r *ClientRequest) (string, string) {
+	if r.Definition == nil {
+		return r.Verb, metering.Unknown
+	}
+	return r.Verb, r.Definition.ResourceT()
+}
+
+type clientObservation struct {
+	metrics  *metering.RED
+	verb     string
+	resource string
+	start    time.Time
+}
+
+func (c *Client) beginObservation(r *ClientRequest) *clientObservation {
+	if c.Metrics == nil {
+		return nil
+	}
+	verb, resource := metricLabels(r)
+	c.Metrics.InFlight.Inc(verb, resource)
+	return &clientObservation{
+		metrics:  c.Metrics,
+		verb:     verb,
+		resource: resource,
+		start:    time.Now(),
+	}
+}
+
+// end records the request, a statusCode of 0 is a transport failure
+func (o *clientObservation) end(statusCode int, requestSize int64) {
+	if o == nil {
+		return
+	}
+	class := metering.StatusClass(statusCode)
+	o.metrics.InFlight.Dec(o.verb, o.resource)
+	o.metrics.Requests.Inc(o.verb, o.resource, class)
+	o.metrics.Latency.Observe(time.Since(o.start).Seconds(), o.verb, o.resource, class)
+	if requestSize >= 0 {
+		o.metrics.RequestSize.Observe(float64(requestSize), o.verb, o.resource)
+	}
+}
+
+func (o *clientObservation) encodeFailed() {
+	if o == nil {
+		return
+	}
+	o.metrics.EncodeFailures.Inc(o.verb, o.resource)
+	o.end(0, -1)
+}
+
+// measure wraps the response body so its size is recorded once it has been read and closed
+func (o *clientObservation) measure(body io.ReadCloser) io.ReadCloser {
+	if o == nil || body == nil {
+		return body
+	}
+	return &countingBody{ReadCloser: body, observe: func(n int64) {
+		o.metrics.ResponseSize.Observe(float64(n), o.verb, o.resource)
+	}}
+}
+
+func (c *Client) decodeFailed(r *ClientRequest) {
+	if c.Metrics != nil {
+		verb, resource := metricLabels(r)
+		c.Metrics.DecodeFailures.Inc(verb, resource)
+	}
+}
+
+type countingBody struct {
+	io.ReadCloser
+	n       int64
+	once    sync.Once
+	observe func(int64)
+}
+
+func (cb *countingBody) Read(p []byte) (int, error) {
+	n, err := cb.ReadCloser.Read(p)
+	cb.n += int64(n)
+	return n, err
+}
+
+func (cb *countingBody) Close() error {
+	cb.once.Do(func() { cb.observe(cb.n) })
+	return cb.ReadCloser.Close()
+}
@@ -3,8 +3,11 @@ package rest_test
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"reflect"
 
 	"github.com/gotgo/fw/io"
+	"github.com/gotgo/gokn/metering"
 	"github.com/gotgo/gokn/rest"
 	"github.com/gotgo/gokn/testing"
 
@@ -203,4 +206,62 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Context("Client Metrics", func() {
+		It("should record per ResourceT", func() {
+			client.Metrics = rest.NewClientMetrics(metering.NewRegistry())
+			def := &rest.ResourceDef{
+				ResourceT:    "/{id}",
+				ResourceArgs: reflect.TypeOf(rest.IdIntArg{}),
+				Verb:         "GET",
+			}
+			//the echo service only answers on the root
+			req := &rest.ClientRequest{
+				Resource:   "",
+				Verb:       "GET",
+				Definition: rest.NewServerResource(def, nil, nil),
+			}
+
+			resp, err := client.Send(req, context)
+			Expect(err).To(BeNil())
+			_, err = resp.Bytes()
+			Expect(err).To(BeNil())
+
+			Expect(client.Metrics.Requests.Value("GET", "/{id}", "2xx")).To(Equal(1.0))
+			Expect(client.Metrics.ResponseSize.Count("GET", "/{id}")).To(Equal(uint64(1)))
+			Expect(client.Metrics.InFlight.Value("GET", "/{id}")).To(Equal(0.0))
+		})
+	})
+	Context("Client Propagation", func() {
+		It("should inject a child of the context's span", func() {
+			context.TraceContext = &rest.TraceContext{
+				TraceId: "4bf92f3577b34da6a3ce929d0e0e4736",
+				SpanId:  "00f067aa0ba902b7",
+				Sampled: true,
+			}
+			echoReq := &testing.EchoRequest{}
+			_, err := client.Fetch(&rest.ClientRequest{Verb: "GET"}, context, echoReq)
+			Expect(err).To(BeNil())
+
+			tc := (&rest.W3CPropagator{}).Extract(http.Header(echoReq.Headers))
+			Expect(tc).ToNot(BeNil())
+			Expect(tc.TraceId).To(Equal(context.TraceContext.TraceId))
+			Expect(tc.SpanId).ToNot(Equal(context.TraceContext.SpanId))
+			Expect(echoReq.Headers["Tr-Trace"]).To(Equal([]string{context.TraceContext.TraceId}))
+		})
+
+		It("should not modify the ClientRequest headers", func() {
+			req := &rest.ClientRequest{Verb: "GET", Headers: map[string][]string{"X-A": {"a"}}}
+			_, err := client.Send(req, context)
+			Expect(err).To(BeNil())
+			Expect(req.Headers).To(HaveLen(1))
+		})
+
+		It("should forward the context's request id", func() {
+			context.RequestId = "req-42"
+			echoReq := &testing.EchoRequest{}
+			_, err := client.Fetch(&rest.ClientRequest{Verb: "GET"}, context, echoReq)
+			Expect(err).To(BeNil())
+			Expect(echoReq.Headers["X-Request-Id"]).To(Equal([]string{"req-42"}))
+		})
+	})
 })
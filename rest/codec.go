@@ -0,0 +1,124 @@
+package rest
+
+import (
+	"encoding/json"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes and decodes the bodies of a content type
+type Codec interface {
+	ContentType() string
+	Encode(v interface{}) ([]byte, error)
+	Decode(data []byte, v interface{}) error
+}
+
+// StrictCodec is a Codec that can also decode strictly, failing what Decode ignores
+type StrictCodec interface {
+	Codec
+	DecodeStrict(data []byte, v interface{}) error
+}
+
+// Codecs are the codecs of the content types a Client sends and accepts
+type Codecs struct {
+	library map[string]Codec
+	types   []string
+}
+
+// NewCodecs has the json, xml, msgpack, cbor, protobuf, ndjson and csv codecs, and the codecs
+// given
+func NewCodecs(codecs ...Codec) *Codecs {
+	cs := &Codecs{library: make(map[string]Codec)}
+	cs.Set(JsonCodec{})
+	cs.Set(NewXmlCodec(), ContentTypeXml, ContentTypeTextXml)
+	cs.Set(MsgpackCodec{}, ContentTypeMsgpack, ContentTypeXMsgpack)
+	cs.Set(CborCodec{})
+	cs.Set(ProtobufCodec{})
+	cs.Set(NdjsonCodec{})
+	cs.Set(CsvCodec{})
+	for _, codec := range codecs {
+		cs.Set(codec)
+	}
+	return cs
+}
+
+// Set registers the codec for the content types, or its own ContentType when none are given
+func (cs *Codecs) Set(codec Codec, contentTypes ...string) {
+	if len(contentTypes) == 0 {
+		contentTypes = []string{codec.ContentType()}
+	}
+	for _, ct := range contentTypes {
+		ct = mediaType(ct)
+		if _, ok := cs.library[ct]; !ok {
+			cs.types = append(cs.types, ct)
+		}
+		cs.library[ct] = codec
+	}
+}
+
+// Get is the codec of a content type, or of the first of its Lookups that has one, or nil
+func (cs *Codecs) Get(contentType string) Codec {
+	if cs == nil {
+		return nil
+	}
+	key, _ := lookup(contentType, func(key string) bool {
+		return cs.library[key] != nil
+	})
+	return cs.library[key]
+}
+
+// ContentTypes are the registered content types, in the order they were Set
+func (cs *Codecs) ContentTypes() []string {
+	return cs.types
+}
+
+// Target is the pointer to decode into, the one a *interface{} holds, as the ContentTypeDecoders
+// and Client.Fetch pass them, or v
+func Target(v interface{}) interface{} {
+	for {
+		p, ok := v.(*interface{})
+		if !ok || p == nil || *p == nil {
+			return v
+		}
+		v = *p
+	}
+}
+
+// JsonCodec encodes with encoding/json, and proto.Messages with protojson
+type JsonCodec struct{}
+
+func (JsonCodec) ContentType() string {
+	return ContentTypeJson
+}
+
+func (JsonCodec) Encode(v interface{}) ([]byte, error) {
+	if m, ok := Target(v).(proto.Message); ok {
+		return protojson.Marshal(m)
+	}
+	return json.Marshal(v)
+}
+
+// Decode fails with a *DecodeError of where the json doesn't fit v
+func (JsonCodec) Decode(data []byte, v interface{}) error {
+	if m, ok := Target(v).(proto.Message); ok {
+		return protojson.Unmarshal(data, m)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return jsonDecodeError(data, v, err)
+	}
+	return nil
+}
+
+// DecodeStrict also fails the unknown fields and the duplicate keys, which Decode ignores.
+// protojson always fails unknown fields
+func (c JsonCodec) DecodeStrict(data []byte, v interface{}) error {
+	if _, ok := Target(v).(proto.Message); !ok {
+		if t := decodeType(v); t != nil {
+			if err := checkJson(data, t, true); err != nil {
+				return err
+			}
+		}
+	}
+	return c.Decode(data, v)
+}
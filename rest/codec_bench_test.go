@@ -0,0 +1,71 @@
+package rest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gotgo/gokn/rest"
+)
+
+type benchLine struct {
+	Sku      string  `json:"sku"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+type benchOrder struct {
+	Id      int64             `json:"id"`
+	Created time.Time         `json:"created"`
+	Status  string            `json:"status"`
+	Lines   []benchLine       `json:"lines"`
+	Labels  map[string]string `json:"labels"`
+}
+
+func newBenchOrder() *benchOrder {
+	order := &benchOrder{
+		Id:      42,
+		Created: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Status:  "open",
+		Labels:  map[string]string{"channel": "web", "region": "eu"},
+	}
+	for i := 0; i < 20; i++ {
+		order.Lines = append(order.Lines, benchLine{Sku: "sku-123456", Quantity: i, Price: 9.99})
+	}
+	return order
+}
+
+var benchCodecs = []rest.Codec{rest.JsonCodec{}, rest.MsgpackCodec{}, rest.CborCodec{}}
+
+func BenchmarkCodecEncode(b *testing.B) {
+	order := newBenchOrder()
+	for _, codec := range benchCodecs {
+		b.Run(codec.ContentType(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := codec.Encode(order); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCodecDecode(b *testing.B) {
+	order := newBenchOrder()
+	for _, codec := range benchCodecs {
+		bts, err := codec.Encode(order)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(codec.ContentType(), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(bts)))
+			for i := 0; i < b.N; i++ {
+				decoded := new(benchOrder)
+				if err := codec.Decode(bts, decoded); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package rest
+
+import "github.com/gotgo/fw/logging"
+
+// ContextLogger adds the same key values, eg trace & span ids, to every line it logs
+type ContextLogger struct {
+	logging.Logger
+	KV []*logging.KV
+}
+
+// NewContextLogger wraps log, a nil log discards everything
+func NewContextLogger(log logging.Logger, kv ...*logging.KV) *ContextLogger {
+	if log == nil {
+		log = new(logging.NoOpLogger)
+	}
+	return &ContextLogger{Logger: log, KV: kv}
+}
+
+// With is a new logger with more key values
+func (cl *ContextLogger) With(kv ...*logging.KV) *ContextLogger {
+	all := make([]*logging.KV, 0, len(cl.KV)+len(kv))
+	all = append(all, cl.KV...)
+	return &ContextLogger{Logger: cl.Logger, KV: append(all, kv...)}
+}
+
+func (cl *ContextLogger) kv(kv []*logging.KV) []*logging.KV {
+	if len(kv) == 0 {
+		return cl.KV
+	}
+	all := make([]*logging.KV, 0, len(cl.KV)+len(kv))
+	all = append(all, cl.KV...)
+	return append(all, kv...)
+}
+
+func (cl *ContextLogger) Inform(message string, kv ...*logging.KV) {
+	cl.Logger.Inform(message, cl.kv(kv)...)
+}
+
+func (cl *ContextLogger) Debug(message string, kv ...*logging.KV) {
+	cl.Logger.Debug(message, cl.kv(kv)...)
+}
+
+func (cl *ContextLogger) Warn(message string, kv ...*logging.KV) {
+	cl.Logger.Warn(message, cl.kv(kv)...)
+}
+
+func (cl *ContextLogger) Error(message string, err error, kv ...*logging.KV) {
+	cl.Logger.Error(message, err, cl.kv(kv)...)
+}
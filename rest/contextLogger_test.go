@@ -0,0 +1,61 @@
+package rest_test
+
+import (
+	"errors"
+
+	"github.com/gotgo/fw/logging"
+	. "github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type recordingLogger struct {
+	logging.NoOpLogger
+	messages []string
+	kvs      [][]*logging.KV
+}
+
+func (rl *recordingLogger) Inform(message string, kv ...*logging.KV) {
+	rl.messages = append(rl.messages, message)
+	rl.kvs = append(rl.kvs, kv)
+}
+
+func (rl *recordingLogger) Error(message string, err error, kv ...*logging.KV) {
+	rl.Inform(message, kv...)
+}
+
+var _ = Describe("ContextLogger", func() {
+
+	var (
+		inner *recordingLogger
+		log   *ContextLogger
+	)
+
+	BeforeEach(func() {
+		inner = new(recordingLogger)
+		log = NewContextLogger(inner, &logging.KV{Key: "traceId", Value: "t1"})
+	})
+
+	It("should add its key values to every line", func() {
+		log.Inform("one")
+		log.Error("two", errors.New("e"), &logging.KV{Key: "k", Value: "v"})
+
+		Expect(inner.messages).To(Equal([]string{"one", "two"}))
+		Expect(inner.kvs[0]).To(Equal([]*logging.KV{{Key: "traceId", Value: "t1"}}))
+		Expect(inner.kvs[1]).To(Equal([]*logging.KV{{Key: "traceId", Value: "t1"}, {Key: "k", Value: "v"}}))
+	})
+
+	It("should not change the parent With more key values", func() {
+		child := log.With(&logging.KV{Key: "spanId", Value: "s1"})
+		child.Inform("child")
+		log.Inform("parent")
+
+		Expect(inner.kvs[0]).To(HaveLen(2))
+		Expect(inner.kvs[1]).To(HaveLen(1))
+	})
+
+	It("should discard lines without a logger", func() {
+		Expect(func() { NewContextLogger(nil).Inform("x") }).ToNot(Panic())
+	})
+})
@@ -0,0 +1,314 @@
+package rest
+
+import (
+	"bytes"
+	stdencoding "encoding"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ContentTypeCsv is the content type of comma separated values
+const ContentTypeCsv = "text/csv"
+
+// CsvCodec encodes lists of structs as csv, a row per item after a header row of the column
+// names.  The names are the csv tags, else the json tags or the field names, the fields tagged
+// "-" are left out.  Cells are decoded by column name, so columns may come in any order
+//
+//	Example:
+//
+//		type Order struct {
+//			Id    int     `json:"id"`
+//			Total float64 `csv:"total_usd"`
+//		}
+//
+//		id,total_usd
+//		1,9.5
+type CsvCodec struct{}
+
+func (CsvCodec) ContentType() string {
+	return ContentTypeCsv
+}
+
+func (c CsvCodec) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.EncodeStream(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c CsvCodec) Decode(data []byte, v interface{}) error {
+	return decodeItems(c.NewDecoder(bytes.NewReader(data)), v)
+}
+
+func (CsvCodec) EncodeStream(w io.Writer, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	columns, err := csvColumns(itemType(v))
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		row[i] = column.name
+	}
+	if err := writeRow(writer, row); err != nil {
+		return err
+	}
+
+	return eachItem(v, func(item reflect.Value) error {
+		item = indirect(item)
+		for i, column := range columns {
+			row[i] = ""
+			if !item.IsValid() {
+				continue
+			}
+			field, ok := fieldByIndex(item, column.index)
+			if !ok {
+				continue
+			}
+			cell, err := formatCell(field)
+			if err != nil {
+				return fmt.Errorf("csv column %s: %w", column.name, err)
+			}
+			row[i] = cell
+		}
+		return writeRow(writer, row)
+	})
+}
+
+func (CsvCodec) NewDecoder(r io.Reader) ItemDecoder {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	return &csvDecoder{reader: reader}
+}
+
+// writeRow writes a row through to the writer, so every row is sent as it's encoded
+func writeRow(writer *csv.Writer, row []string) error {
+	if err := writer.Write(row); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+// csvColumns are the columns of the exported fields of a struct, with those of exported
+// embedded structs in their place
+func csvColumns(t reflect.Type) ([]csvColumn, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("csv encodes lists of structs, not %v", t)
+	}
+
+	columns := []csvColumn{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := csvName(field)
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		if field.Anonymous && name == "" && indirectType(field.Type).Kind() == reflect.Struct {
+			embedded, _ := csvColumns(field.Type)
+			for _, column := range embedded {
+				column.index = append([]int{i}, column.index...)
+				columns = append(columns, column)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{name: name, index: []int{i}})
+	}
+	return columns, nil
+}
+
+// csvName is the name of the csv tag, else of the json tag
+func csvName(field reflect.StructField) string {
+	tag, ok := field.Tag.Lookup("csv")
+	if !ok {
+		tag = field.Tag.Get("json")
+	}
+	return strings.Split(tag, ",")[0]
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// indirect is the value behind the pointers and interfaces of v, or invalid when one is nil
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// fieldByIndex is the field, false when an embedded pointer on the way is nil
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		if v = indirect(v); !v.IsValid() {
+			return v, false
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
+func formatCell(v reflect.Value) (string, error) {
+	if v = indirect(v); !v.IsValid() {
+		return "", nil
+	}
+	if m, ok := v.Interface().(stdencoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return fmt.Sprint(v.Interface()), nil
+}
+
+type csvDecoder struct {
+	reader  *csv.Reader
+	columns map[string]csvColumn
+	names   []string
+}
+
+// Decode decodes the next row, the first call reads the header row
+func (d *csvDecoder) Decode(v interface{}) error {
+	target := reflect.ValueOf(Target(v))
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("csv decodes into a pointer, not %T", v)
+	}
+	for target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+	}
+
+	if d.names == nil {
+		if err := d.readHeader(target.Type()); err != nil {
+			return err
+		}
+	}
+	row, err := d.reader.Read()
+	if err != nil {
+		return err
+	}
+	for i, cell := range row {
+		column, ok := d.columns[d.names[i]]
+		if !ok || cell == "" {
+			continue
+		}
+		if err := parseCell(cell, fieldOf(target, column.index)); err != nil {
+			line, _ := d.reader.FieldPos(i)
+			return fmt.Errorf("csv line %d, column %s: %w", line, column.name, err)
+		}
+	}
+	return nil
+}
+
+func (d *csvDecoder) readHeader(t reflect.Type) error {
+	columns, err := csvColumns(t)
+	if err != nil {
+		return err
+	}
+	header, err := d.reader.Read()
+	if err != nil {
+		return err
+	}
+	d.names = append([]string{}, header...)
+	d.columns = make(map[string]csvColumn)
+	for _, column := range columns {
+		d.columns[column.name] = column
+	}
+	return nil
+}
+
+// fieldOf is the field, allocating the nil embedded pointers on the way
+func fieldOf(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+func parseCell(cell string, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if u, ok := v.Addr().Interface().(stdencoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(cell))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(cell)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(cell, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(cell, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(cell, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("can't decode a csv cell into a %s", v.Type())
+	}
+	return nil
+}
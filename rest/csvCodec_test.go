@@ -0,0 +1,104 @@
+package rest_test
+
+import (
+	"time"
+
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type Audit struct {
+	By string `json:"by"`
+}
+
+type reportRow struct {
+	Audit
+	Id      int        `json:"id"`
+	Total   float64    `csv:"total_usd" json:"total"`
+	Note    *string    `json:"note,omitempty"`
+	Shipped bool       `json:"-"`
+	At      time.Time  `json:"at"`
+	Paid    *time.Time `csv:"paid"`
+	secret  string
+}
+
+var _ = Describe("CsvCodec", func() {
+	codec := rest.CsvCodec{}
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	note := "a, \"quoted\" note"
+	rows := []*reportRow{
+		{Audit: Audit{By: "ann"}, Id: 1, Total: 9.5, Note: &note, At: at},
+		{Id: 2, Total: 10, At: at, Paid: &at, secret: "s"},
+	}
+	encoded := "by,id,total_usd,note,at,paid\n" +
+		"ann,1,9.5,\"a, \"\"quoted\"\" note\",2020-01-02T03:04:05Z,\n" +
+		",2,10,,2020-01-02T03:04:05Z,2020-01-02T03:04:05Z\n"
+
+	It("should encode a header row from the tags and a row per item", func() {
+		bts, err := codec.Encode(rows)
+		Expect(err).To(BeNil())
+		Expect(string(bts)).To(Equal(encoded))
+	})
+
+	It("should encode the header of an empty list", func() {
+		bts, err := codec.Encode([]reportRow{})
+		Expect(err).To(BeNil())
+		Expect(string(bts)).To(Equal("by,id,total_usd,note,at,paid\n"))
+	})
+
+	It("should encode channels and iterators", func() {
+		ch := make(chan *reportRow, 2)
+		ch <- rows[0]
+		ch <- rows[1]
+		close(ch)
+		bts, err := codec.Encode(ch)
+		Expect(err).To(BeNil())
+		Expect(string(bts)).To(Equal(encoded))
+
+		seq := func(yield func(*reportRow) bool) {
+			for _, row := range rows {
+				if !yield(row) {
+					return
+				}
+			}
+		}
+		bts, err = codec.Encode(seq)
+		Expect(err).To(BeNil())
+		Expect(string(bts)).To(Equal(encoded))
+	})
+
+	It("should decode by column name", func() {
+		var decoded []reportRow
+		data := "paid,id,unknown,by,note,total_usd\n,1,x,ann,n,9.5\n2020-01-02T03:04:05Z,2,,,,\n"
+		Expect(codec.Decode([]byte(data), &decoded)).To(Succeed())
+		Expect(decoded).To(HaveLen(2))
+		Expect(decoded[0].By).To(Equal("ann"))
+		Expect(*decoded[0].Note).To(Equal("n"))
+		Expect(decoded[0].Total).To(Equal(9.5))
+		Expect(decoded[0].Paid).To(BeNil())
+		Expect(decoded[1].Id).To(Equal(2))
+		Expect(decoded[1].Note).To(BeNil())
+		Expect(decoded[1].Paid.Equal(at)).To(BeTrue())
+	})
+
+	It("should round trip", func() {
+		bts, _ := codec.Encode(rows)
+		var decoded []*reportRow
+		Expect(codec.Decode(bts, &decoded)).To(Succeed())
+		rows[1].secret = ""
+		Expect(decoded).To(Equal(rows))
+	})
+
+	It("should fail a cell of the wrong type, with its line and column", func() {
+		var decoded []reportRow
+		err := codec.Decode([]byte("id\n1\nx\n"), &decoded)
+		Expect(err).To(MatchError(ContainSubstring("csv line 3, column id")))
+	})
+
+	It("should fail what isn't a list of structs", func() {
+		_, err := codec.Encode([]string{"a"})
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,140 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// The Codes of a DecodeError
+const (
+	DecodeSyntax       = "syntax"
+	DecodeType         = "type"
+	DecodeUnknownField = "unknown_field"
+	DecodeDuplicateKey = "duplicate_key"
+	DecodeTrailingData = "trailing_data"
+	DecodeInvalid      = "invalid"
+)
+
+// DecodeError is a body that failed to decode, where and why, for the caller to fix it
+//
+//	Example:
+//
+//		{"lines": [{"sku": "a", "quantity": "two"}]}
+//
+//		DecodeError{Code: "type", Offset: 36, Field: "lines[0].quantity", Expected: "integer"}
+type DecodeError struct {
+	Code string
+	// Offset is the byte offset into the body
+	Offset int64
+	// Field is the path of the field, "" for the body itself
+	Field string
+	// Expected is the json type the Field decodes from, for type errors
+	Expected string
+	Err      error
+}
+
+func (e *DecodeError) Error() string {
+	var what string
+	switch e.Code {
+	case DecodeSyntax:
+		what = "malformed body"
+	case DecodeType:
+		if e.Field == "" {
+			what = "the body should be " + article(e.Expected)
+		} else {
+			what = fmt.Sprintf("field %s should be %s", e.Field, article(e.Expected))
+		}
+	case DecodeUnknownField:
+		what = "unknown field " + e.Field
+	case DecodeDuplicateKey:
+		what = "duplicate key " + e.Field
+	case DecodeTrailingData:
+		what = "trailing data after the body"
+	default:
+		what = "invalid body"
+		if e.Field != "" {
+			what = "invalid field " + e.Field
+		}
+	}
+	message := fmt.Sprintf("%s at offset %d", what, e.Offset)
+	if e.Err != nil && (e.Code == DecodeSyntax || e.Code == DecodeInvalid) {
+		message += ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func article(jsonType string) string {
+	switch jsonType {
+	case "array", "integer", "object":
+		return "an " + jsonType
+	}
+	return "a " + jsonType
+}
+
+// jsonDecodeError locates where json.Unmarshal failed, as a *DecodeError
+func jsonDecodeError(data []byte, v interface{}, err error) error {
+	if t := decodeType(v); t != nil {
+		if located := checkJson(data, t, false); located != nil {
+			located.Err = err
+			return located
+		}
+	}
+
+	var syntax *json.SyntaxError
+	var mismatch *json.UnmarshalTypeError
+	if errors.As(err, &syntax) {
+		return &DecodeError{Code: DecodeSyntax, Offset: syntax.Offset, Err: err}
+	} else if errors.As(err, &mismatch) {
+		return &DecodeError{Code: DecodeType, Offset: mismatch.Offset, Field: mismatch.Field,
+			Expected: jsonType(mismatch.Type, false), Err: err}
+	}
+	return &DecodeError{Code: DecodeInvalid, Err: err}
+}
+
+// decodeType is the type v points to, nil when it isn't a pointer
+func decodeType(v interface{}) reflect.Type {
+	t := reflect.TypeOf(Target(v))
+	if t == nil || t.Kind() != reflect.Ptr {
+		return nil
+	}
+	return t.Elem()
+}
+
+// jsonType is the json type a Go type decodes from, a string when it's quoted by the ,string
+// option
+func jsonType(t reflect.Type, quoted bool) string {
+	if quoted {
+		return "string"
+	}
+	t = indirectType(t)
+	if decodesText(t) {
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "base64 string"
+		}
+		return "array"
+	case reflect.Array:
+		return "array"
+	}
+	return "value"
+}
@@ -0,0 +1,178 @@
+package rest
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// None is the Args, Body or Response type of an Endpoint that has none
+type None struct{}
+
+var noneType = reflect.TypeOf(None{})
+
+// Endpoint is a ResourceDef typed by its Args, request Body and Response.  The ResourceDef
+// can be Used by a ResourceSpec like any other, so typed and untyped endpoints mix in one api.
+//
+//	Example:
+//
+//		var GetOrder = rest.NewEndpoint[OrderArgs, rest.None, Order]("GET", "/orders/{id}")
+//
+//		handling.BindEndpoint(root, router, GetOrder, func(ctx *rest.RequestContext, args OrderArgs, _ rest.None) (Order, error) {
+//			...
+//		}, "/v1")
+//
+//		order, err := rest.Call(client, ctx, GetOrder, OrderArgs{Id: 42}, rest.None{})
+type Endpoint[A, B, R any] struct {
+	*ResourceDef
+}
+
+// NewEndpoint defines an endpoint of json content, the ResourceDef's types are those of A, B
+// and R, or nil when None
+func NewEndpoint[A, B, R any](verb, resourceT string) *Endpoint[A, B, R] {
+	return &Endpoint[A, B, R]{
+		ResourceDef: &ResourceDef{
+			ResourceT:            resourceT,
+			Verb:                 verb,
+			ResourceArgs:         typeOf[A](),
+			RequestBody:          typeOf[B](),
+			ResponseBody:         typeOf[R](),
+			RequestContentTypes:  []string{ContentTypeJson},
+			ResponseContentTypes: []string{ContentTypeJson},
+		},
+	}
+}
+
+// EndpointOf types an existing ResourceDef, its types must be those of A, B and R
+func EndpointOf[A, B, R any](def *ResourceDef) (*Endpoint[A, B, R], error) {
+	check := func(name string, defined, typed reflect.Type) error {
+		if defined != typed {
+			return fmt.Errorf("%s %s: %s is %v, not %v", def.Verb, def.ResourceT, name, defined, typed)
+		}
+		return nil
+	}
+	if err := check("ResourceArgs", def.ResourceArgs, typeOf[A]()); err != nil {
+		return nil, err
+	}
+	if err := check("RequestBody", def.RequestBody, typeOf[B]()); err != nil {
+		return nil, err
+	}
+	if err := check("ResponseBody", def.ResponseBody, typeOf[R]()); err != nil {
+		return nil, err
+	}
+	return &Endpoint[A, B, R]{ResourceDef: def}, nil
+}
+
+// typeOf is the type a ResourceDef has for T, nil for None and the element of a pointer
+func typeOf[T any]() reflect.Type {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t == noneType {
+		return nil
+	}
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+// valueOf is the T of a value made by a ServerResource, which is a pointer to T, or the
+// zero T
+func valueOf[T any](v interface{}) T {
+	switch t := v.(type) {
+	case T:
+		return t
+	case *T:
+		if t != nil {
+			return *t
+		}
+	}
+	var zero T
+	return zero
+}
+
+func isNone[T any]() bool {
+	return reflect.TypeOf((*T)(nil)).Elem() == noneType
+}
+
+func isNil(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return true
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// Resource is the ServerResource of the endpoint, with its content types or json
+func (e *Endpoint[A, B, R]) Resource() ServerResource {
+	req, resp := e.RequestContentTypes, e.ResponseContentTypes
+	if len(req) == 0 {
+		req = []string{ContentTypeJson}
+	}
+	if len(resp) == 0 {
+		resp = []string{ContentTypeJson}
+	}
+	return NewServerResource(e.ResourceDef, req, resp)
+}
+
+// Handle adapts a typed handler function to a HandlerFunc of the endpoint.  The result is
+// the response body, unless R is None, and an error replies with its status, see ReplyError
+func (e *Endpoint[A, B, R]) Handle(fn func(*RequestContext, A, B) (R, error)) HandlerFunc {
+	return func(req *Request, resp Responder) {
+		result, err := fn(req.Context, valueOf[A](req.Args), valueOf[B](req.Body))
+		if err != nil {
+			ReplyError(resp, err)
+			return
+		}
+		if !isNone[R]() {
+			resp.SetBody(result)
+		}
+	}
+}
+
+// Request is the ClientRequest of the endpoint
+func (e *Endpoint[A, B, R]) Request(args A, body B) *ClientRequest {
+	var a, b interface{}
+	if !isNone[A]() && !isNil(args) {
+		a = args
+	}
+	if !isNone[B]() && !isNil(body) {
+		b = body
+	}
+	req := &ClientRequest{
+		Resource:   e.GetPath(a),
+		Verb:       e.Verb,
+		Body:       b,
+		Definition: e.Resource(),
+	}
+	attachArgs(req, a)
+	return req
+}
+
+// Call sends the typed request of the endpoint and decodes its response, a reply other than
+// the Client's SuccessStatuses is an *APIError
+func Call[A, B, R any](c *Client, ctx *RequestContext, e *Endpoint[A, B, R], args A, body B) (R, error) {
+	var result R
+	if ctx == nil {
+		ctx = NewRequestContext()
+	}
+	r := e.Request(args, body)
+	resp, err := c.Send(r, ctx)
+	if err != nil {
+		return result, err
+	}
+	bts, err := resp.Bytes()
+	if err != nil {
+		return result, err
+	}
+	if isNone[R]() || len(bts) == 0 {
+		return result, nil
+	}
+	if err := c.decode(resp, bts, &result); err != nil {
+		c.decodeFailed(r)
+		return result, err
+	}
+	return result, nil
+}
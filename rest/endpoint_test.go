@@ -0,0 +1,114 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type orderArgs struct {
+	Id int `json:"id,string" structs:"id"`
+}
+
+type order struct {
+	Id   int    `json:"id"`
+	Note string `json:"note"`
+}
+
+var _ = Describe("Endpoint", func() {
+
+	It("should define the ResourceDef's types", func() {
+		endpoint := rest.NewEndpoint[orderArgs, *order, []order]("PUT", "/orders/{id}")
+		Expect(endpoint.Verb).To(Equal("PUT"))
+		Expect(endpoint.ResourceArgs).To(Equal(reflect.TypeOf(orderArgs{})))
+		Expect(endpoint.RequestBody).To(Equal(reflect.TypeOf(order{})))
+		Expect(endpoint.ResponseBody).To(Equal(reflect.TypeOf([]order{})))
+
+		none := rest.NewEndpoint[rest.None, rest.None, rest.None]("DELETE", "/orders")
+		Expect(none.ResourceArgs).To(BeNil())
+		Expect(none.RequestBody).To(BeNil())
+		Expect(none.ResponseBody).To(BeNil())
+	})
+
+	It("should be Used by a ResourceSpec", func() {
+		endpoint := rest.NewEndpoint[orderArgs, rest.None, order]("GET", "/orders/{id}")
+		spec := rest.NewResourceSpec(rest.ContentTypeJson).Use(endpoint.ResourceDef)
+		Expect(spec.Get(&orderArgs{Id: 3}).Resource).To(Equal("/orders/3"))
+		Expect(endpoint.Request(orderArgs{Id: 3}, rest.None{}).Resource).To(Equal("/orders/3"))
+	})
+
+	It("should type an existing ResourceDef", func() {
+		def := &rest.ResourceDef{ResourceT: "/orders/{id}", Verb: "GET", ResourceArgs: reflect.TypeOf(orderArgs{}), ResponseBody: reflect.TypeOf(order{})}
+		endpoint, err := rest.EndpointOf[orderArgs, rest.None, order](def)
+		Expect(err).To(BeNil())
+		Expect(endpoint.ResourceDef).To(Equal(def))
+
+		_, err = rest.EndpointOf[orderArgs, order, order](def)
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("should adapt a typed handler", func() {
+		endpoint := rest.NewEndpoint[orderArgs, order, order]("POST", "/orders/{id}")
+		fn := endpoint.Handle(func(ctx *rest.RequestContext, args orderArgs, body order) (order, error) {
+			return order{Id: args.Id, Note: body.Note}, nil
+		})
+		req := rest.NewRequest(nil, rest.NewRequestContext(), endpoint.Resource())
+		req.Args = &orderArgs{Id: 5}
+		req.Body = &order{Note: "new"}
+		resp := rest.NewResponse()
+		fn(req, resp)
+		Expect(resp.Status).To(Equal(http.StatusOK))
+		Expect(resp.Body).To(Equal(order{Id: 5, Note: "new"}))
+	})
+
+	Context("Call", func() {
+		var (
+			server *httptest.Server
+			client *rest.Client
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/orders/404" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				var in order
+				bts, _ := ioutil.ReadAll(r.Body)
+				json.Unmarshal(bts, &in)
+				in.Id = 1
+				json.NewEncoder(w).Encode(&in)
+			}))
+			client = rest.NewClient()
+			client.Endpoints = []*rest.ResourceEndpoint{{Scheme: "http", Host: strings.TrimPrefix(server.URL, "http://")}}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should send the typed request and decode the response", func() {
+			endpoint := rest.NewEndpoint[orderArgs, *order, order]("PUT", "/orders/{id}")
+			result, err := rest.Call(client, nil, endpoint, orderArgs{Id: 1}, &order{Note: "note"})
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(order{Id: 1, Note: "note"}))
+		})
+
+		It("should fail a reply other than 2xx", func() {
+			endpoint := rest.NewEndpoint[orderArgs, rest.None, *order]("GET", "/orders/{id}")
+			result, err := rest.Call(client, nil, endpoint, orderArgs{Id: 404}, rest.None{})
+			Expect(err).To(MatchError(ContainSubstring("GET " + server.URL + "/orders/404: 404 Not Found")))
+			Expect(errors.Is(err, rest.NotFound)).To(BeTrue())
+			Expect(result).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,170 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrorKind classifies an Error, each kind replies with its own status.  A kind is itself an
+// error, so errors.Is(err, rest.NotFound) is true of any Error of that kind, however wrapped
+type ErrorKind string
+
+const (
+	NotFound     ErrorKind = "not_found"
+	Conflict     ErrorKind = "conflict"
+	Unauthorized ErrorKind = "unauthorized"
+	Forbidden    ErrorKind = "forbidden"
+	Validation   ErrorKind = "validation"
+	RateLimited  ErrorKind = "rate_limited"
+	Unavailable  ErrorKind = "unavailable"
+	// Malformed is a request that can't be decoded
+	Malformed ErrorKind = "malformed"
+)
+
+var kindStatus = map[ErrorKind]int{
+	NotFound:     http.StatusNotFound,
+	Conflict:     http.StatusConflict,
+	Unauthorized: http.StatusUnauthorized,
+	Forbidden:    http.StatusForbidden,
+	Validation:   http.StatusUnprocessableEntity,
+	RateLimited:  http.StatusTooManyRequests,
+	Unavailable:  http.StatusServiceUnavailable,
+	Malformed:    http.StatusBadRequest,
+}
+
+func (k ErrorKind) Error() string {
+	return string(k)
+}
+
+// Status is the http status of the kind, 500 when unknown
+func (k ErrorKind) Status() int {
+	if status, ok := kindStatus[k]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// KindOf is the kind replied with an http status, or "" when none is
+func KindOf(status int) ErrorKind {
+	for kind, s := range kindStatus {
+		if s == status {
+			return kind
+		}
+	}
+	return ""
+}
+
+// Error is a domain error a handler replies with, the RootHandler maps it to the status of
+// its Kind and sends it as the response body.  The wrapped Err is the cause, it's logged and
+// traced but never sent.
+//
+//	Example:
+//
+//		order, err := orders.Find(args.Id)
+//		if err == sql.ErrNoRows {
+//			return nil, rest.NewError(rest.NotFound, "order_missing", "no such order").
+//				WithDetail("id", args.Id).
+//				Wrap(err)
+//		}
+type Error struct {
+	Kind ErrorKind `json:"kind" xml:"kind"`
+	// Code is finer than the Kind, for the caller to act on
+	Code    string `json:"code,omitempty" xml:"code,omitempty"`
+	Message string `json:"message" xml:"message"`
+	// Details aren't sent as xml, which has no maps
+	Details map[string]interface{} `json:"details,omitempty" xml:"-"`
+	Err     error                  `json:"-" xml:"-"`
+}
+
+func NewError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// WithDetail is a Fluent Method that adds a detail to the error
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[key] = value
+	return e
+}
+
+// Wrap is a Fluent Method that sets the cause of the error
+func (e *Error) Wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	if message == "" {
+		message = string(e.Kind)
+	}
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the error's kind
+func (e *Error) Is(target error) bool {
+	kind, ok := target.(ErrorKind)
+	return ok && kind == e.Kind
+}
+
+// Status is the http status of the error's kind
+func (e *Error) Status() int {
+	return e.Kind.Status()
+}
+
+// AsError is the Error an error wraps, or an Error of the ErrorKind it wraps with the kind's
+// status text as the message
+func AsError(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	var kind ErrorKind
+	if errors.As(err, &kind) {
+		return &Error{Kind: kind, Message: http.StatusText(kind.Status()), Err: err}, true
+	}
+	return nil, false
+}
+
+// StatusOf is the http status of an error, that of the Error or ErrorKind it wraps, or 500
+func StatusOf(err error) int {
+	if e, ok := AsError(err); ok {
+		return e.Status()
+	}
+	return http.StatusInternalServerError
+}
+
+// ReplyError sets the status of the error on the response, with the Error it wraps as the
+// body, see AsError
+func ReplyError(resp Responder, err error) {
+	if e, ok := AsError(err); ok {
+		resp.SetStatus(e.Status(), e.Message, err)
+		resp.SetBody(e)
+		return
+	}
+	status := http.StatusInternalServerError
+	resp.SetStatus(status, http.StatusText(status), err)
+}
+
+// ResponseError reconstructs the Error of a reply other than 2xx from its body, or from its
+// status when the body isn't an Error.  Statuses of no kind are an error of the status.
+func (c *Client) ResponseError(resp *EndpointResponse, body []byte) error {
+	e := new(Error)
+	if err := c.decode(resp, body, e); err == nil && e.Kind != "" {
+		return e
+	}
+	status := resp.HttpResponse
+	if kind := KindOf(status.StatusCode); kind != "" {
+		return &Error{Kind: kind, Message: status.Status}
+	}
+	return errors.New(status.Status)
+}
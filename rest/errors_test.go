@@ -0,0 +1,85 @@
+package rest_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Error", func() {
+
+	It("should be its kind, however wrapped", func() {
+		cause := errors.New("no rows")
+		err := fmt.Errorf("finding order: %w", rest.NewError(rest.NotFound, "order_missing", "no such order").Wrap(cause))
+		Expect(errors.Is(err, rest.NotFound)).To(BeTrue())
+		Expect(errors.Is(err, rest.Conflict)).To(BeFalse())
+		Expect(errors.Is(err, cause)).To(BeTrue())
+
+		var e *rest.Error
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.Code).To(Equal("order_missing"))
+		Expect(e.Error()).To(Equal("no such order: no rows"))
+	})
+
+	It("should map kinds to statuses", func() {
+		Expect(rest.StatusOf(rest.NewError(rest.Unauthorized, "", ""))).To(Equal(http.StatusUnauthorized))
+		Expect(rest.StatusOf(fmt.Errorf("limited: %w", rest.RateLimited))).To(Equal(http.StatusTooManyRequests))
+		Expect(rest.StatusOf(errors.New("failed"))).To(Equal(http.StatusInternalServerError))
+		Expect(rest.StatusOf(rest.Malformed)).To(Equal(http.StatusBadRequest))
+		Expect(rest.KindOf(http.StatusForbidden)).To(Equal(rest.Forbidden))
+		Expect(rest.KindOf(http.StatusBadRequest)).To(Equal(rest.Malformed))
+		Expect(rest.KindOf(http.StatusTeapot)).To(BeEmpty())
+	})
+
+	It("should reply with the status and the error as the body", func() {
+		resp := rest.NewResponse()
+		rest.ReplyError(resp, rest.NewError(rest.Validation, "bad_sku", "unknown sku").WithDetail("sku", "x").Wrap(errors.New("internal")))
+		Expect(resp.Status).To(Equal(http.StatusUnprocessableEntity))
+		Expect(resp.Message).To(Equal("unknown sku"))
+		Expect(resp.Body).To(BeAssignableToTypeOf(&rest.Error{}))
+
+		resp = rest.NewResponse()
+		rest.ReplyError(resp, fmt.Errorf("down: %w", rest.Unavailable))
+		Expect(resp.Status).To(Equal(http.StatusServiceUnavailable))
+		Expect(resp.Body.(*rest.Error).Message).To(Equal("Service Unavailable"))
+
+		resp = rest.NewResponse()
+		rest.ReplyError(resp, errors.New("failed"))
+		Expect(resp.Status).To(Equal(http.StatusInternalServerError))
+		Expect(resp.Body).To(BeNil())
+	})
+
+	Context("reconstructed by the client", func() {
+		response := func(status int, body string) (*rest.EndpointResponse, []byte) {
+			return &rest.EndpointResponse{HttpResponse: &http.Response{
+				StatusCode: status,
+				Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}}, []byte(body)
+		}
+
+		It("should decode the error body", func() {
+			err := rest.NewClient().ResponseError(response(http.StatusConflict, `{"kind":"conflict","code":"taken","message":"name taken","details":{"name":"x"}}`))
+			Expect(errors.Is(err, rest.Conflict)).To(BeTrue())
+			e := err.(*rest.Error)
+			Expect(e.Code).To(Equal("taken"))
+			Expect(e.Details).To(HaveKeyWithValue("name", "x"))
+		})
+
+		It("should fall back to the status", func() {
+			err := rest.NewClient().ResponseError(response(http.StatusNotFound, "404 page not found"))
+			Expect(errors.Is(err, rest.NotFound)).To(BeTrue())
+			Expect(err).To(MatchError("404 Not Found"))
+
+			err = rest.NewClient().ResponseError(response(http.StatusInternalServerError, ""))
+			Expect(err).To(MatchError("500 Internal Server Error"))
+		})
+	})
+})
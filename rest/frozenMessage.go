@@ -1,12 +1,24 @@
 package rest
 
-import "encoding/json"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// Encodings of a frozen body that isn't json
+const (
+	BodyEncodingText   = "text"
+	BodyEncodingBase64 = "base64"
+)
 
 type FrozenRequest struct {
 	Headers  map[string][]string `json:"headers"`
 	Verb     string              `json:"verb"`
 	Resource string              `json:"resource"`
 	Body     json.RawMessage     `json:"body"`
+	// BodyEncoding is empty when the Body is json
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
 
 	ResourceT string        `json:"resourceT,omitempty"`
 	Requestor *FrozenSender `json:"requestor,omitempty"`
@@ -24,9 +36,44 @@ type FrozenResponse struct {
 	StatusCode string              `json:"statusCode"`
 	Status     string              `json:"status"`
 	Body       json.RawMessage     `json:"body"`
+	// BodyEncoding is empty when the Body is json
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
 }
 
 type FrozenCommunication struct {
 	Request  *FrozenRequest  `json:"request"`
 	Response *FrozenResponse `json:"response"`
 }
+
+// FreezeBody keeps a json body as is, other text as a json string and binary as base64
+func FreezeBody(body []byte) (json.RawMessage, string) {
+	if len(body) == 0 {
+		return nil, ""
+	} else if json.Valid(body) {
+		return json.RawMessage(body), ""
+	} else if utf8.Valid(body) {
+		bts, _ := json.Marshal(string(body))
+		return json.RawMessage(bts), BodyEncodingText
+	}
+	bts, _ := json.Marshal(base64.StdEncoding.EncodeToString(body))
+	return json.RawMessage(bts), BodyEncodingBase64
+}
+
+// ThawBody is the original body frozen by FreezeBody
+func ThawBody(body json.RawMessage, encoding string) ([]byte, error) {
+	if len(body) == 0 || string(body) == "null" {
+		return nil, nil
+	}
+	switch encoding {
+	case BodyEncodingText, BodyEncodingBase64:
+		var s string
+		if err := json.Unmarshal(body, &s); err != nil {
+			return nil, err
+		}
+		if encoding == BodyEncodingText {
+			return []byte(s), nil
+		}
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return []byte(body), nil
+}
@@ -0,0 +1,91 @@
+package rest
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// Items reads the items of a list reply one at a time, as they arrive, with the StreamCodec of
+// the reply's content type.  The whole body is never read at once
+//
+//	Example:
+//
+//		items, err := rest.FetchItems[Order](client, spec.Get(nil), ctx)
+//		if err != nil {
+//			return err
+//		}
+//		defer items.Close()
+//		for items.Next() {
+//			order := items.Item()
+//		}
+//		return items.Err()
+type Items[T any] struct {
+	resp    *EndpointResponse
+	decoder ItemDecoder
+	item    T
+	err     error
+}
+
+// FetchItems sends the request, Accepting the Client's ContentType when it's a StreamCodec's, else
+// ndjson or csv, and reads the items of the reply.  The Items must be closed
+func FetchItems[T any](c *Client, r *ClientRequest, ctx *RequestContext) (*Items[T], error) {
+	accept := ContentTypeNdjson + ", " + ContentTypeCsv + ";q=0.9"
+	if _, ok := c.codec().(StreamCodec); ok {
+		accept = c.ContentType
+	}
+	request := *r
+	request.Headers = map[string][]string{"Accept": {accept}}
+	for k, v := range r.Headers {
+		request.Headers[k] = v
+	}
+
+	resp, err := c.Send(&request, ctx)
+	if err != nil {
+		return nil, err
+	}
+	contentType := resp.HttpResponse.Header.Get("Content-Type")
+	codec, ok := c.Codecs.Get(contentType).(StreamCodec)
+	if !ok {
+		resp.HttpResponse.Body.Close()
+		return nil, fmt.Errorf("a %q reply can't be read item by item", contentType)
+	}
+	body, err := charsetReader(resp.HttpResponse.Body, contentType)
+	if err != nil {
+		resp.HttpResponse.Body.Close()
+		return nil, err
+	}
+	return &Items[T]{resp: resp, decoder: codec.NewDecoder(body)}, nil
+}
+
+// Next decodes the next item, false at the end of the reply or when decoding fails
+func (it *Items[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	item, into := newItem(reflect.TypeOf((*T)(nil)).Elem())
+	if err := it.decoder.Decode(into); err != nil {
+		it.err = err
+		return false
+	}
+	it.item = item.Elem().Interface().(T)
+	return true
+}
+
+// Item is the item Next decoded
+func (it *Items[T]) Item() T {
+	return it.item
+}
+
+// Err is the error that stopped Next, nil at the end of the reply
+func (it *Items[T]) Err() error {
+	if it.err == io.EOF {
+		return nil
+	}
+	return it.err
+}
+
+// Close closes the reply, the items that are left aren't read
+func (it *Items[T]) Close() error {
+	return it.resp.HttpResponse.Body.Close()
+}
@@ -0,0 +1,80 @@
+package rest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Items", func() {
+	var (
+		server      *httptest.Server
+		client      *rest.Client
+		accepted    string
+		contentType string
+		body        string
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			accepted = r.Header.Get("Accept")
+			w.Header().Set("Content-Type", contentType)
+			w.Write([]byte(body))
+		}))
+		client = rest.NewClient()
+		client.Endpoints = []*rest.ResourceEndpoint{{Scheme: "http", Host: strings.TrimPrefix(server.URL, "http://")}}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	get := &rest.ClientRequest{Verb: "GET", Resource: "/orders"}
+
+	It("should accept ndjson or csv, and read the items of either", func() {
+		contentType, body = "application/x-ndjson; charset=utf-8", "{\"id\":1}\n{\"id\":2}\n"
+		items, err := rest.FetchItems[*packedOrder](client, get, rest.NewRequestContext())
+		Expect(err).To(BeNil())
+		Expect(accepted).To(Equal("application/x-ndjson, text/csv;q=0.9"))
+		ids := []int{}
+		for items.Next() {
+			ids = append(ids, items.Item().Id)
+		}
+		Expect(items.Err()).To(BeNil())
+		Expect(ids).To(Equal([]int{1, 2}))
+		Expect(items.Close()).To(Succeed())
+	})
+
+	It("should accept the Client's ContentType", func() {
+		client.ContentType = rest.ContentTypeCsv
+		contentType, body = rest.ContentTypeCsv, "id\n1\n"
+		items, err := rest.FetchItems[packedOrder](client, get, rest.NewRequestContext())
+		Expect(err).To(BeNil())
+		defer items.Close()
+		Expect(accepted).To(Equal(rest.ContentTypeCsv))
+		Expect(items.Next()).To(BeTrue())
+		Expect(items.Item().Id).To(Equal(1))
+		Expect(items.Next()).To(BeFalse())
+	})
+
+	It("should stop at an item that fails to decode", func() {
+		contentType, body = rest.ContentTypeNdjson, "{\"id\":1}\n{\"id\":\"x\"}\n{\"id\":3}\n"
+		items, _ := rest.FetchItems[packedOrder](client, get, rest.NewRequestContext())
+		defer items.Close()
+		Expect(items.Next()).To(BeTrue())
+		Expect(items.Next()).To(BeFalse())
+		Expect(items.Err()).ToNot(BeNil())
+		Expect(items.Next()).To(BeFalse())
+	})
+
+	It("should fail a reply that can't be read item by item", func() {
+		contentType, body = rest.ContentTypeJson, "[]"
+		_, err := rest.FetchItems[packedOrder](client, get, rest.NewRequestContext())
+		Expect(err).To(MatchError(`a "application/json" reply can't be read item by item`))
+	})
+})
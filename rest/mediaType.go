@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	textencoding "golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// MediaType is a parsed content type, the Suffix is the structured syntax of the Subtype, if any
+//
+//	Example:
+//
+//		application/problem+json; charset=utf-8
+//
+//		MediaType{Type: "application", Subtype: "problem+json", Suffix: "json",
+//			Params: map[string]string{"charset": "utf-8"}}
+type MediaType struct {
+	Type    string
+	Subtype string
+	Suffix  string
+	Params  map[string]string
+}
+
+// ParseMediaType parses a content type or media range, the names are lower case
+func ParseMediaType(contentType string) (MediaType, error) {
+	essence, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return MediaType{}, err
+	}
+	parts := strings.SplitN(essence, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return MediaType{}, fmt.Errorf("media type %q has no subtype", contentType)
+	}
+	mt := MediaType{Type: parts[0], Subtype: parts[1], Params: params}
+	if i := strings.LastIndex(mt.Subtype, "+"); i >= 0 {
+		mt.Suffix = mt.Subtype[i+1:]
+	}
+	return mt, nil
+}
+
+// Essence is type/subtype, without the parameters
+func (mt MediaType) Essence() string {
+	return mt.Type + "/" + mt.Subtype
+}
+
+// Charset is the charset parameter, "" when there's none
+func (mt MediaType) Charset() string {
+	return strings.ToLower(mt.Params["charset"])
+}
+
+// Matches is true of the media types in the range, such as */*, text/* or application/json
+func (mt MediaType) Matches(other MediaType) bool {
+	if mt.Type == "*" {
+		return true
+	} else if mt.Type != other.Type {
+		return false
+	}
+	return mt.Subtype == "*" || mt.Subtype == other.Subtype
+}
+
+// Lookups are the registry keys of the media type, the most specific first: its essence, the
+// type of its suffix, so application/problem+json is application/json, then the wildcards.
+// They find the codec of a content type that's already chosen, they never choose one
+func (mt MediaType) Lookups() []string {
+	lookups := []string{mt.Essence()}
+	if mt.Suffix != "" {
+		lookups = append(lookups, "application/"+mt.Suffix)
+	}
+	return append(lookups, mt.Type+"/*", "*/*")
+}
+
+// lookup is the first of the keys of the content type that's in the registry, and if there's one
+func lookup(contentType string, has func(key string) bool) (string, bool) {
+	mt, err := ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	for _, key := range mt.Lookups() {
+		if has(key) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// mediaType is the essence of a content type, or the lower case content type before its
+// parameters when it can't be parsed
+func mediaType(contentType string) string {
+	if mt, err := ParseMediaType(contentType); err == nil {
+		return mt.Essence()
+	}
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+// DecodeCharset is the text in utf-8, from the charset of its content type
+func DecodeCharset(text []byte, contentType string) ([]byte, error) {
+	if decoder, err := charsetDecoder(contentType); err != nil || decoder == nil {
+		return text, err
+	} else {
+		return decoder.Bytes(text)
+	}
+}
+
+// charsetReader reads the text of r in utf-8, from the charset of its content type
+func charsetReader(r io.Reader, contentType string) (io.Reader, error) {
+	if decoder, err := charsetDecoder(contentType); err != nil || decoder == nil {
+		return r, err
+	} else {
+		return decoder.Reader(r), nil
+	}
+}
+
+// charsetDecoder decodes the charset of the content type, nil when it's utf-8 or has none
+func charsetDecoder(contentType string) (*textencoding.Decoder, error) {
+	mt, err := ParseMediaType(contentType)
+	if err != nil {
+		return nil, nil
+	}
+	switch charset := mt.Charset(); charset {
+	case "", "utf-8", "utf8", "us-ascii":
+		return nil, nil
+	default:
+		enc, err := htmlindex.Get(charset)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported charset %q: %w", charset, err)
+		}
+		return enc.NewDecoder(), nil
+	}
+}
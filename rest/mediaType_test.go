@@ -0,0 +1,57 @@
+package rest_test
+
+import (
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MediaType", func() {
+	It("should parse the type, subtype, suffix and parameters", func() {
+		mt, err := rest.ParseMediaType("Application/Problem+JSON; Charset=UTF-8; profile=x")
+		Expect(err).To(BeNil())
+		Expect(mt.Type).To(Equal("application"))
+		Expect(mt.Subtype).To(Equal("problem+json"))
+		Expect(mt.Suffix).To(Equal("json"))
+		Expect(mt.Essence()).To(Equal("application/problem+json"))
+		Expect(mt.Charset()).To(Equal("utf-8"))
+		Expect(mt.Params).To(HaveKeyWithValue("profile", "x"))
+	})
+
+	It("should fail what isn't a media type", func() {
+		for _, ct := range []string{"", "json", "application/", "a/b; =x"} {
+			_, err := rest.ParseMediaType(ct)
+			Expect(err).ToNot(BeNil(), ct)
+		}
+	})
+
+	It("should look up by the suffix and then the wildcards", func() {
+		mt, _ := rest.ParseMediaType("application/vnd.api+json")
+		Expect(mt.Lookups()).To(Equal([]string{"application/vnd.api+json", "application/json", "application/*", "*/*"}))
+	})
+
+	It("should match the media types of a range", func() {
+		all, _ := rest.ParseMediaType("*/*")
+		text, _ := rest.ParseMediaType("text/*")
+		csv, _ := rest.ParseMediaType("text/csv; header=present")
+		json, _ := rest.ParseMediaType("application/json")
+		Expect(all.Matches(json)).To(BeTrue())
+		Expect(text.Matches(csv)).To(BeTrue())
+		Expect(text.Matches(json)).To(BeFalse())
+		Expect(csv.Matches(csv)).To(BeTrue())
+		Expect(csv.Matches(text)).To(BeFalse())
+	})
+
+	It("should decode text of the charset to utf-8", func() {
+		text, err := rest.DecodeCharset([]byte("caf\xe9"), "text/plain; charset=iso-8859-1")
+		Expect(err).To(BeNil())
+		Expect(string(text)).To(Equal("café"))
+
+		text, _ = rest.DecodeCharset([]byte("café"), "text/plain")
+		Expect(string(text)).To(Equal("café"))
+
+		_, err = rest.DecodeCharset([]byte("x"), "text/plain; charset=klingon")
+		Expect(err).ToNot(BeNil())
+	})
+})
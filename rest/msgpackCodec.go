@@ -0,0 +1,37 @@
+package rest
+
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	// ContentTypeMsgpack is the content type of MessagePack
+	ContentTypeMsgpack = "application/msgpack"
+	// ContentTypeXMsgpack is the older name of MessagePack, that many clients still send
+	ContentTypeXMsgpack = "application/x-msgpack"
+)
+
+// MsgpackCodec encodes MessagePack, fields without a msgpack tag use their json tag
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) ContentType() string {
+	return ContentTypeMsgpack
+}
+
+func (MsgpackCodec) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (MsgpackCodec) Decode(data []byte, v interface{}) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(Target(v))
+}
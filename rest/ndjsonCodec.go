@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+)
+
+// ContentTypeNdjson is the content type of newline delimited json
+const ContentTypeNdjson = "application/x-ndjson"
+
+// NdjsonCodec encodes lists as newline delimited json, an item per line, with the JsonCodec
+//
+//	Example:
+//
+//		[]Order{{Id: 1}, {Id: 2}}
+//
+//		{"id":1}
+//		{"id":2}
+type NdjsonCodec struct{}
+
+func (NdjsonCodec) ContentType() string {
+	return ContentTypeNdjson
+}
+
+func (c NdjsonCodec) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.EncodeStream(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c NdjsonCodec) Decode(data []byte, v interface{}) error {
+	return decodeItems(c.NewDecoder(bytes.NewReader(data)), v)
+}
+
+func (NdjsonCodec) EncodeStream(w io.Writer, v interface{}) error {
+	return eachItem(v, func(item reflect.Value) error {
+		line, err := JsonCodec{}.Encode(item.Interface())
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(line, '\n'))
+		return err
+	})
+}
+
+func (NdjsonCodec) NewDecoder(r io.Reader) ItemDecoder {
+	return &ndjsonDecoder{reader: bufio.NewReader(r)}
+}
+
+type ndjsonDecoder struct {
+	reader *bufio.Reader
+}
+
+// Decode decodes the next line that isn't blank
+func (d *ndjsonDecoder) Decode(v interface{}) error {
+	for {
+		line, err := d.reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return JsonCodec{}.Decode(line, v)
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package rest_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NdjsonCodec", func() {
+	codec := rest.NdjsonCodec{}
+
+	It("should encode a line per item", func() {
+		ch := make(chan packedOrder, 2)
+		ch <- packedOrder{Id: 1}
+		ch <- packedOrder{Id: 2, Tags: []string{"a"}}
+		close(ch)
+		bts, err := codec.Encode(ch)
+		Expect(err).To(BeNil())
+		Expect(string(bts)).To(Equal("{\"id\":1,\"note\":\"\"}\n{\"id\":2,\"note\":\"\",\"tags\":[\"a\"]}\n"))
+	})
+
+	It("should decode a list, skipping blank lines", func() {
+		var list []*packedOrder
+		Expect(codec.Decode([]byte("{\"id\":1}\n\n{\"id\":2}"), &list)).To(Succeed())
+		Expect(list).To(HaveLen(2))
+		Expect(list[1].Id).To(Equal(2))
+	})
+
+	It("should decode an item at a time", func() {
+		decoder := codec.NewDecoder(strings.NewReader("{\"id\":1}\n{\"id\":2}\n"))
+		first := new(packedOrder)
+		Expect(decoder.Decode(first)).To(Succeed())
+		Expect(first.Id).To(Equal(1))
+		Expect(decoder.Decode(new(packedOrder))).To(Succeed())
+		Expect(decoder.Decode(new(packedOrder))).To(Equal(io.EOF))
+	})
+
+	It("should fail a broken line", func() {
+		var list []packedOrder
+		Expect(codec.Decode([]byte("{\"id\":1}\n{\"id\""), &list)).ToNot(Succeed())
+	})
+
+	It("should stop at the first failure of an iterator", func() {
+		failing := errors.New("failing")
+		calls := 0
+		seq := func(yield func(int) bool) {
+			for i := 0; i < 3 && yield(i); i++ {
+				calls++
+			}
+		}
+		err := codec.EncodeStream(writerFunc(func(p []byte) (int, error) { return 0, failing }), seq)
+		Expect(err).To(Equal(failing))
+		Expect(calls).To(Equal(0))
+	})
+
+	It("should not leave a channel's producer blocked when a write fails", func() {
+		ch, done := make(chan packedOrder), make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 5; i++ {
+				ch <- packedOrder{Id: i}
+			}
+			close(ch)
+		}()
+		writes := 0
+		disconnected := writerFunc(func(p []byte) (int, error) {
+			if writes++; writes > 1 {
+				return 0, io.ErrClosedPipe
+			}
+			return len(p), nil
+		})
+		Expect(codec.EncodeStream(disconnected, ch)).To(Equal(io.ErrClosedPipe))
+		Eventually(done).Should(BeClosed())
+	})
+})
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,319 @@
+package rest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+
+	B3Header             = "b3"
+	B3TraceIdHeader      = "X-B3-TraceId"
+	B3SpanIdHeader       = "X-B3-SpanId"
+	B3ParentSpanIdHeader = "X-B3-ParentSpanId"
+	B3SampledHeader      = "X-B3-Sampled"
+	B3FlagsHeader        = "X-B3-Flags"
+
+	LegacyTraceHeader = "tr-trace"
+	LegacySpanHeader  = "tr-span"
+)
+
+// DefaultPropagator is used by the RootHandler and the Client when they don't have their
+// own Propagator. Replace it once at startup to change propagation for the whole process.
+var DefaultPropagator Propagator = Propagators{
+	&W3CPropagator{},
+	&LegacyPropagator{TraceHeader: LegacyTraceHeader, SpanHeader: LegacySpanHeader},
+}
+
+// TraceContext identifies the span a request belongs to, as it crosses process boundaries
+type TraceContext struct {
+	TraceId      string
+	SpanId       string
+	ParentSpanId string
+	Sampled      bool
+	// Deferred is set when the caller left the sampling decision to this service
+	Deferred bool
+	// TraceState is the opaque W3C vendor state, passed along unchanged
+	TraceState string
+}
+
+// NewTraceContext starts a new, sampled, trace
+func NewTraceContext() *TraceContext {
+	return &TraceContext{
+		TraceId: newId(16),
+		SpanId:  newId(8),
+		Sampled: true,
+	}
+}
+
+// Child is a new span in the same trace, with this span as its parent
+func (tc *TraceContext) Child() *TraceContext {
+	return &TraceContext{
+		TraceId:      tc.TraceId,
+		SpanId:       newId(8),
+		ParentSpanId: tc.SpanId,
+		Sampled:      tc.Sampled,
+		TraceState:   tc.TraceState,
+	}
+}
+
+func newId(size int) string {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Propagator reads and writes a TraceContext in request headers
+type Propagator interface {
+	// Extract returns nil when the headers carry no valid context for this format
+	Extract(headers http.Header) *TraceContext
+	Inject(tc *TraceContext, headers http.Header)
+}
+
+// Propagators extracts with the first that finds a context and injects with all of them
+type Propagators []Propagator
+
+func (ps Propagators) Extract(headers http.Header) *TraceContext {
+	for _, p := range ps {
+		if tc := p.Extract(headers); tc != nil {
+			return tc
+		}
+	}
+	return nil
+}
+
+func (ps Propagators) Inject(tc *TraceContext, headers http.Header) {
+	for _, p := range ps {
+		p.Inject(tc, headers)
+	}
+}
+
+// W3CPropagator implements https://www.w3.org/TR/trace-context/
+type W3CPropagator struct{}
+
+func (w *W3CPropagator) Extract(headers http.Header) *TraceContext {
+	parts := strings.Split(strings.TrimSpace(headers.Get(TraceParentHeader)), "-")
+	if len(parts) < 4 {
+		return nil
+	}
+	version, traceId, spanId, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return nil
+	}
+	if !isHex(traceId, 32) || isZero(traceId) || !isHex(spanId, 16) || isZero(spanId) || !isHex(flags, 2) {
+		return nil
+	}
+
+	var flagBits byte
+	fmt.Sscanf(flags, "%02x", &flagBits)
+	return &TraceContext{
+		TraceId:    traceId,
+		SpanId:     spanId,
+		Sampled:    flagBits&1 == 1,
+		TraceState: strings.Join(headers[http.CanonicalHeaderKey(TraceStateHeader)], ","),
+	}
+}
+
+func (w *W3CPropagator) Inject(tc *TraceContext, headers http.Header) {
+	flags := "00"
+	if tc.Sampled {
+		flags = "01"
+	}
+	headers.Set(TraceParentHeader, fmt.Sprintf("00-%s-%s-%s", padId(tc.TraceId, 32), padId(tc.SpanId, 16), flags))
+	if tc.TraceState != "" {
+		headers.Set(TraceStateHeader, tc.TraceState)
+	}
+}
+
+// B3Propagator implements the Zipkin B3 single header, or with Multi, the X-B3-* headers.
+// Either form is accepted on Extract.
+type B3Propagator struct {
+	Multi bool
+}
+
+func (b *B3Propagator) Extract(headers http.Header) *TraceContext {
+	if single := headers.Get(B3Header); single != "" {
+		return extractB3Single(single)
+	}
+
+	traceId, spanId := headers.Get(B3TraceIdHeader), headers.Get(B3SpanIdHeader)
+	if !validB3Id(traceId, true) || !validB3Id(spanId, false) {
+		return nil
+	}
+	parentId := headers.Get(B3ParentSpanIdHeader)
+	if parentId != "" && !validB3Id(parentId, false) {
+		return nil
+	}
+	sampled, debug := headers.Get(B3SampledHeader), headers.Get(B3FlagsHeader) == "1"
+	return &TraceContext{
+		TraceId:      traceId,
+		SpanId:       spanId,
+		ParentSpanId: parentId,
+		Sampled:      sampled == "1" || sampled == "true" || debug,
+		Deferred:     sampled == "" && !debug,
+	}
+}
+
+// extractB3Single parses {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}, a lone sampling
+// state carries no ids and is ignored, a missing one defers the decision
+func extractB3Single(value string) *TraceContext {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 2 || len(parts) > 4 {
+		return nil
+	}
+	if !validB3Id(parts[0], true) || !validB3Id(parts[1], false) {
+		return nil
+	}
+	tc := &TraceContext{
+		TraceId:  parts[0],
+		SpanId:   parts[1],
+		Deferred: len(parts) == 2,
+	}
+	if len(parts) > 2 {
+		tc.Sampled = parts[2] == "1" || parts[2] == "d"
+	}
+	if len(parts) > 3 {
+		if !validB3Id(parts[3], false) {
+			return nil
+		}
+		tc.ParentSpanId = parts[3]
+	}
+	return tc
+}
+
+func (b *B3Propagator) Inject(tc *TraceContext, headers http.Header) {
+	sampled := "0"
+	if tc.Sampled {
+		sampled = "1"
+	}
+	traceId, spanId, parentId := b3Id(tc.TraceId, true), b3Id(tc.SpanId, false), ""
+	if tc.ParentSpanId != "" {
+		parentId = b3Id(tc.ParentSpanId, false)
+	}
+
+	if !b.Multi {
+		value := fmt.Sprintf("%s-%s", traceId, spanId)
+		if !tc.Deferred {
+			value += "-" + sampled
+		}
+		if parentId != "" {
+			value += "-" + parentId
+		}
+		headers.Set(B3Header, value)
+		return
+	}
+
+	headers.Set(B3TraceIdHeader, traceId)
+	headers.Set(B3SpanIdHeader, spanId)
+	if !tc.Deferred {
+		headers.Set(B3SampledHeader, sampled)
+	}
+	if parentId != "" {
+		headers.Set(B3ParentSpanIdHeader, parentId)
+	}
+}
+
+func b3Id(id string, trace bool) string {
+	if validB3Id(id, trace) {
+		return id
+	} else if trace {
+		return padId(id, 32)
+	}
+	return padId(id, 16)
+}
+
+// LegacyPropagator carries the trace and span ids as-is in two headers, tr-trace and
+// tr-span by default
+type LegacyPropagator struct {
+	TraceHeader string
+	SpanHeader  string
+}
+
+func (l *LegacyPropagator) Extract(headers http.Header) *TraceContext {
+	traceId := headers.Get(l.TraceHeader)
+	if traceId == "" {
+		return nil
+	}
+	return &TraceContext{
+		TraceId: traceId,
+		SpanId:  headers.Get(l.SpanHeader),
+		Sampled: true,
+	}
+}
+
+func (l *LegacyPropagator) Inject(tc *TraceContext, headers http.Header) {
+	headers.Set(l.TraceHeader, tc.TraceId)
+	headers.Set(l.SpanHeader, tc.SpanId)
+}
+
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+func validB3Id(s string, trace bool) bool {
+	if trace && len(s) == 32 {
+		return isHex(s, 32) && !isZero(s)
+	}
+	return isHex(s, 16) && !isZero(s)
+}
+
+// HexTraceId converts a trace id of any format to the 32 hex digits that W3C, Zipkin
+// and OTLP require
+func HexTraceId(id string) string {
+	return padId(id, 32)
+}
+
+// HexSpanId converts a span id of any format to 16 hex digits
+func HexSpanId(id string) string {
+	return padId(id, 16)
+}
+
+// padId left pads the shorter ids of other formats, eg 64 bit B3 trace ids, to the
+// width W3C requires. Ids that aren't hex are hashed into a valid W3C id.
+func padId(id string, length int) string {
+	if isHex(id, len(id)) && len(id) <= length && !isZero(id) {
+		return strings.Repeat("0", length-len(id)) + id
+	}
+	if length == 32 {
+		return newIdFrom(id, 16)
+	}
+	return newIdFrom(id, 8)
+}
+
+// newIdFrom derives a stable hex id from an arbitrary one, so a legacy trace keeps the
+// same W3C trace id at every hop
+func newIdFrom(id string, size int) string {
+	var h uint64 = 14695981039346656037
+	out := make([]byte, size)
+	for i := range out {
+		for j := 0; j < len(id); j++ {
+			h ^= uint64(id[j])
+			h *= 1099511628211
+		}
+		h ^= uint64(i)
+		out[i] = byte(h >> 56)
+	}
+	if isZero(hex.EncodeToString(out)) {
+		out[size-1] = 1
+	}
+	return hex.EncodeToString(out)
+}
@@ -0,0 +1,136 @@
+package rest_test
+
+import (
+	"net/http"
+
+	. "github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Propagation", func() {
+
+	const (
+		traceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanId  = "00f067aa0ba902b7"
+	)
+
+	var headers http.Header
+
+	BeforeEach(func() {
+		headers = make(http.Header)
+	})
+
+	Context("W3C", func() {
+		var w3c *W3CPropagator
+
+		BeforeEach(func() {
+			w3c = &W3CPropagator{}
+		})
+
+		It("should extract traceparent and tracestate", func() {
+			headers.Set("traceparent", "00-"+traceId+"-"+spanId+"-01")
+			headers.Set("tracestate", "congo=t61rcWkgMzE")
+			tc := w3c.Extract(headers)
+			Expect(tc).ToNot(BeNil())
+			Expect(tc.TraceId).To(Equal(traceId))
+			Expect(tc.SpanId).To(Equal(spanId))
+			Expect(tc.Sampled).To(BeTrue())
+			Expect(tc.TraceState).To(Equal("congo=t61rcWkgMzE"))
+		})
+
+		It("should reject invalid traceparents", func() {
+			for _, invalid := range []string{
+				"",
+				"00-" + traceId + "-" + spanId,
+				"ff-" + traceId + "-" + spanId + "-01",
+				"00-00000000000000000000000000000000-" + spanId + "-01",
+				"00-" + traceId + "-0000000000000000-01",
+				"00-4BF92F3577B34DA6A3CE929D0E0E4736-" + spanId + "-01",
+				"00-" + traceId + "-" + spanId + "-01-extra",
+			} {
+				headers.Set("traceparent", invalid)
+				Expect(w3c.Extract(headers)).To(BeNil(), invalid)
+			}
+		})
+
+		It("should round trip a child span", func() {
+			parent := &TraceContext{TraceId: traceId, SpanId: spanId, Sampled: true, TraceState: "a=b"}
+			w3c.Inject(parent.Child(), headers)
+			tc := w3c.Extract(headers)
+			Expect(tc.TraceId).To(Equal(traceId))
+			Expect(tc.SpanId).ToNot(Equal(spanId))
+			Expect(tc.TraceState).To(Equal("a=b"))
+		})
+
+		It("should inject a valid traceparent for a legacy trace id", func() {
+			w3c.Inject(&TraceContext{TraceId: "not-hex-uid", SpanId: "span", Sampled: false}, headers)
+			tc := w3c.Extract(headers)
+			Expect(tc).ToNot(BeNil())
+			Expect(tc.Sampled).To(BeFalse())
+		})
+	})
+
+	Context("B3", func() {
+		It("should extract the single header", func() {
+			headers.Set("b3", traceId+"-"+spanId+"-1-"+"05e3ac9a4f6e3b90")
+			tc := (&B3Propagator{}).Extract(headers)
+			Expect(tc.TraceId).To(Equal(traceId))
+			Expect(tc.SpanId).To(Equal(spanId))
+			Expect(tc.ParentSpanId).To(Equal("05e3ac9a4f6e3b90"))
+			Expect(tc.Sampled).To(BeTrue())
+		})
+
+		It("should ignore a single header that is only a sampling decision", func() {
+			headers.Set("b3", "0")
+			Expect((&B3Propagator{}).Extract(headers)).To(BeNil())
+		})
+
+		It("should round trip the multi headers", func() {
+			b3 := &B3Propagator{Multi: true}
+			b3.Inject(&TraceContext{TraceId: traceId[16:], SpanId: spanId, ParentSpanId: "05e3ac9a4f6e3b90", Sampled: true}, headers)
+			Expect(headers.Get("X-B3-TraceId")).To(Equal(traceId[16:]))
+			Expect(headers.Get("X-B3-Sampled")).To(Equal("1"))
+
+			tc := b3.Extract(headers)
+			Expect(tc.TraceId).To(Equal(traceId[16:]))
+			Expect(tc.ParentSpanId).To(Equal("05e3ac9a4f6e3b90"))
+			Expect(tc.Deferred).To(BeFalse())
+		})
+
+		It("should leave the decision unset without a sampling state", func() {
+			headers.Set("X-B3-TraceId", traceId)
+			headers.Set("X-B3-SpanId", spanId)
+			tc := (&B3Propagator{Multi: true}).Extract(headers)
+			Expect(tc.Sampled).To(BeFalse())
+			Expect(tc.Deferred).To(BeTrue())
+
+			out := http.Header{}
+			(&B3Propagator{Multi: true}).Inject(tc, out)
+			Expect(out).ToNot(HaveKey("X-B3-Sampled"))
+
+			single := http.Header{}
+			single.Set("b3", traceId+"-"+spanId)
+			Expect((&B3Propagator{}).Extract(single).Deferred).To(BeTrue())
+		})
+	})
+
+	Context("Propagators", func() {
+		It("should extract with the first match and inject with all", func() {
+			legacy := &LegacyPropagator{TraceHeader: "tr-trace", SpanHeader: "tr-span"}
+			ps := Propagators{&W3CPropagator{}, legacy}
+
+			headers.Set("tr-trace", "legacy-trace")
+			Expect(ps.Extract(headers).TraceId).To(Equal("legacy-trace"))
+
+			headers.Set("traceparent", "00-"+traceId+"-"+spanId+"-01")
+			Expect(ps.Extract(headers).TraceId).To(Equal(traceId))
+
+			out := make(http.Header)
+			ps.Inject(&TraceContext{TraceId: traceId, SpanId: spanId, Sampled: true}, out)
+			Expect(out.Get("traceparent")).To(Equal("00-" + traceId + "-" + spanId + "-01"))
+			Expect(out.Get("tr-trace")).To(Equal(traceId))
+		})
+	})
+})
@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentTypeProtobuf is the content type of the protobuf binary format
+const ContentTypeProtobuf = "application/x-protobuf"
+
+// ProtobufCodec encodes proto.Messages in the binary format.  The JsonCodec encodes them with
+// protojson, so a ResourceDef of generated messages serves both
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (ProtobufCodec) Encode(v interface{}) ([]byte, error) {
+	if m, err := protoMessage(Target(v)); err != nil {
+		return nil, err
+	} else {
+		return proto.Marshal(m)
+	}
+}
+
+func (ProtobufCodec) Decode(data []byte, v interface{}) error {
+	if m, err := protoMessage(Target(v)); err != nil {
+		return err
+	} else {
+		return proto.Unmarshal(data, m)
+	}
+}
+
+// protoMessage is v as a proto.Message, or an error when it isn't one
+func protoMessage(v interface{}) (proto.Message, error) {
+	if m, ok := v.(proto.Message); ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("%T is not a proto.Message", v)
+}
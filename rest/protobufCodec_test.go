@@ -0,0 +1,45 @@
+package rest_test
+
+import (
+	"time"
+
+	"github.com/gotgo/gokn/rest"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProtobufCodec", func() {
+	codec := rest.ProtobufCodec{}
+	stamp := timestamppb.New(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	It("should round trip a message", func() {
+		bts, err := codec.Encode(stamp)
+		Expect(err).To(BeNil())
+
+		decoded := new(timestamppb.Timestamp)
+		var v interface{} = decoded
+		Expect(codec.Decode(bts, &v)).To(Succeed())
+		Expect(proto.Equal(decoded, stamp)).To(BeTrue())
+	})
+
+	It("should fail what isn't a message", func() {
+		_, err := codec.Encode(&packedOrder{})
+		Expect(err).To(MatchError("*rest_test.packedOrder is not a proto.Message"))
+		Expect(codec.Decode([]byte{}, &packedOrder{})).ToNot(Succeed())
+	})
+
+	It("should encode messages with protojson as json", func() {
+		json := rest.JsonCodec{}
+		bts, err := json.Encode(stamp)
+		Expect(err).To(BeNil())
+		Expect(string(bts)).To(Equal(`"2020-01-02T03:04:05Z"`))
+
+		decoded := new(timestamppb.Timestamp)
+		var v interface{} = decoded
+		Expect(json.Decode(bts, &v)).To(Succeed())
+		Expect(proto.Equal(decoded, stamp)).To(BeTrue())
+	})
+})
@@ -0,0 +1,235 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// RedactedValue replaces sensitive values
+const RedactedValue = "[REDACTED]"
+
+// SensitiveTag marks a struct field whose value must never reach traces or logs
+//
+//	type Login struct {
+//		User     string `json:"user"`
+//		Password string `json:"password" sensitive:"true"`
+//	}
+const SensitiveTag = "sensitive"
+
+// DefaultRedactor is used when a component has no Redactor of its own
+var DefaultRedactor = NewRedactor("Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie")
+
+// Redactor removes sensitive header values and struct fields before data is traced or logged
+type Redactor struct {
+	headers map[string]bool
+}
+
+// NewRedactor redacts the named headers, names are case insensitive
+func NewRedactor(headers ...string) *Redactor {
+	r := &Redactor{headers: make(map[string]bool)}
+	for _, h := range headers {
+		r.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	return r
+}
+
+// SensitiveHeader reports whether the header value is redacted
+func (r *Redactor) SensitiveHeader(name string) bool {
+	return r.headers[http.CanonicalHeaderKey(name)]
+}
+
+// Headers returns a copy of the headers with the sensitive values redacted
+func (r *Redactor) Headers(headers map[string][]string) map[string][]string {
+	if headers == nil {
+		return nil
+	}
+	clean := make(map[string][]string, len(headers))
+	for k, v := range headers {
+		if r.SensitiveHeader(k) {
+			redacted := make([]string, len(v))
+			for i := range v {
+				redacted[i] = RedactedValue
+			}
+			clean[k] = redacted
+		} else {
+			clean[k] = v
+		}
+	}
+	return clean
+}
+
+// Value returns v as json compatible maps, slices and scalars with every field tagged
+// sensitive redacted.  Values that hold no sensitive fields are returned as is.
+func (r *Redactor) Value(v interface{}) interface{} {
+	if !r.Sensitive(v) {
+		return v
+	}
+	return redactValue(reflect.ValueOf(v))
+}
+
+// Sensitive reports whether the type of v may hold fields tagged sensitive
+func (r *Redactor) Sensitive(v interface{}) bool {
+	return v != nil && hasSensitive(reflect.TypeOf(v), make(map[reflect.Type]bool))
+}
+
+// Json is the redacted value as json, or the error text if it can't be marshaled
+func (r *Redactor) Json(v interface{}) string {
+	if bts, err := json.Marshal(r.Value(v)); err != nil {
+		return err.Error()
+	} else {
+		return string(bts)
+	}
+}
+
+func hasSensitive(t reflect.Type, seen map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if isSensitive(f) || hasSensitive(f.Type, seen) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return hasSensitive(t.Elem(), seen)
+	case reflect.Interface:
+		//can't know until the value is seen
+		return true
+	}
+	return false
+}
+
+func isSensitive(f reflect.StructField) bool {
+	tag := f.Tag.Get(SensitiveTag)
+	return tag == "true" || tag == "1"
+}
+
+func redactValue(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if _, ok := v.Interface().(json.Marshaler); ok {
+			return v.Interface()
+		}
+		out := make(map[string]interface{})
+		redactStruct(v, out)
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = redactValue(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		out := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			out[k.String()] = redactValue(v.MapIndex(k))
+		}
+		return out
+	default:
+		return v.Interface()
+	}
+}
+
+// redactStruct follows the encoding/json field naming so the output has the same shape
+// as the encoded struct
+func redactStruct(v reflect.Value, out map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue //unexported
+		}
+
+		name, omitEmpty, skip := jsonName(f)
+		if skip {
+			continue
+		}
+
+		fv := v.Field(i)
+		if f.Anonymous && name == "" {
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				redactStruct(fv, out)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+
+		if isSensitive(f) {
+			out[name] = RedactedValue
+		} else {
+			out[name] = redactValue(fv)
+		}
+	}
+}
+
+func jsonName(f reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return parts[0], omitEmpty, false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"net/http"
+
+	. "github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type Credentials struct {
+	Token string `json:"token" sensitive:"true"`
+}
+
+type Login struct {
+	User     string       `json:"user"`
+	Password string       `json:"password" sensitive:"true"`
+	Pin      int          `sensitive:"true"`
+	Hint     string       `json:"hint,omitempty"`
+	Ignored  string       `json:"-"`
+	Previous []*Login     `json:"previous,omitempty"`
+	Keys     *Credentials `json:"keys,omitempty"`
+	Credentials
+}
+
+type Plain struct {
+	Name string `json:"name"`
+}
+
+var _ = Describe("Redaction", func() {
+
+	var redactor *Redactor
+
+	BeforeEach(func() {
+		redactor = NewRedactor("authorization", "X-Api-Key")
+	})
+
+	Context("Headers", func() {
+		It("should redact the configured headers in any case", func() {
+			headers := http.Header{}
+			headers.Set("Authorization", "Bearer abc")
+			headers.Set("X-API-KEY", "key")
+			headers.Set("Accept", "application/json")
+
+			clean := redactor.Headers(headers)
+			Expect(clean["Authorization"]).To(Equal([]string{RedactedValue}))
+			Expect(clean["X-Api-Key"]).To(Equal([]string{RedactedValue}))
+			Expect(clean["Accept"]).To(Equal([]string{"application/json"}))
+		})
+
+		It("should not change the original headers", func() {
+			headers := http.Header{}
+			headers.Set("Authorization", "Bearer abc")
+			redactor.Headers(headers)
+			Expect(headers.Get("Authorization")).To(Equal("Bearer abc"))
+		})
+
+		It("should redact the usual credentials by default", func() {
+			Expect(DefaultRedactor.SensitiveHeader("cookie")).To(BeTrue())
+			Expect(DefaultRedactor.SensitiveHeader("Authorization")).To(BeTrue())
+			Expect(DefaultRedactor.SensitiveHeader("Accept")).To(BeFalse())
+		})
+	})
+
+	Context("Values", func() {
+		It("should redact fields tagged sensitive", func() {
+			login := &Login{
+				User:        "bob",
+				Password:    "hunter2",
+				Pin:         1234,
+				Ignored:     "x",
+				Previous:    []*Login{{User: "alice", Password: "swordfish"}},
+				Keys:        &Credentials{Token: "t1"},
+				Credentials: Credentials{Token: "t2"},
+			}
+
+			out := redactor.Json(login)
+			var m map[string]interface{}
+			Expect(json.Unmarshal([]byte(out), &m)).To(Succeed())
+
+			Expect(m["user"]).To(Equal("bob"))
+			Expect(m["password"]).To(Equal(RedactedValue))
+			Expect(m["Pin"]).To(Equal(RedactedValue))
+			Expect(m["token"]).To(Equal(RedactedValue))
+			Expect(m).ToNot(HaveKey("hint"))
+			Expect(m).ToNot(HaveKey("Ignored"))
+			Expect(m["keys"]).To(Equal(map[string]interface{}{"token": RedactedValue}))
+			Expect(out).ToNot(ContainSubstring("hunter2"))
+			Expect(out).ToNot(ContainSubstring("swordfish"))
+			Expect(out).To(ContainSubstring("alice"))
+		})
+
+		It("should leave values without sensitive fields alone", func() {
+			plain := &Plain{Name: "n"}
+			Expect(redactor.Value(plain)).To(BeIdenticalTo(plain))
+			Expect(redactor.Json(plain)).To(Equal(`{"name":"n"}`))
+		})
+
+		It("should redact inside maps and slices", func() {
+			v := map[string]interface{}{
+				"logins": []Login{{User: "u", Password: "p"}},
+			}
+			Expect(redactor.Json(v)).To(Equal(`{"logins":[{"Pin":"[REDACTED]","password":"[REDACTED]","token":"[REDACTED]","user":"u"}]}`))
+		})
+
+		It("should handle nil", func() {
+			var login *Login
+			Expect(redactor.Value(nil)).To(BeNil())
+			Expect(redactor.Json(login)).To(Equal("null"))
+		})
+	})
+})
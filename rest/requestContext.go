@@ -3,18 +3,29 @@ package rest
 import (
 	"fmt"
 
+	"github.com/gotgo/fw/logging"
 	"github.com/gotgo/fw/tracing"
 )
 
 type RequestContext struct {
 	user  map[string]interface{}
 	Trace tracing.Tracer
+	// TraceContext is the span of the current request, the Client propagates it to the
+	// requests it sends with this context
+	TraceContext *TraceContext
+	// RequestId identifies the request across services, the Client forwards it
+	RequestId string
+	// Log is scoped to the request, the RootHandler adds the trace and span ids to every line
+	Log logging.Logger
+	// Principal identifies who made the request, set by an authenticating BindingFunc
+	Principal string
 }
 
 func NewRequestContext() *RequestContext {
 	ctx := new(RequestContext)
 	ctx.user = make(map[string]interface{})
 	ctx.Trace = new(tracing.NopTracer)
+	ctx.Log = new(logging.NoOpLogger)
 	return ctx
 }
 
@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+)
+
+// RequestIdHeader carries the id of the request that started a chain of calls
+const RequestIdHeader = "X-Request-ID"
+
+// MaxRequestIdLength is the longest incoming request id accepted
+const MaxRequestIdLength = 128
+
+// NewRequestId is a UUIDv7, time ordered so ids sort by when the request arrived
+func NewRequestId() string {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		panic(err)
+	}
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(time.Now().UnixNano()/int64(time.Millisecond)))
+	copy(b[:6], ms[2:])
+	b[6] = 0x70 | b[6]&0x0f //version 7
+	b[8] = 0x80 | b[8]&0x3f //RFC 4122 variant
+
+	s := hex.EncodeToString(b[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// ValidRequestId accepts ids of the usual formats, UUIDs, ULIDs and the like.  Anything with
+// spaces, quotes or control characters is refused, so a caller can't forge log lines.
+func ValidRequestId(id string) bool {
+	if len(id) == 0 || len(id) > MaxRequestIdLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':' || c == '+' || c == '/' || c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package rest_test
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestId", func() {
+
+	It("should generate UUIDv7 ids", func() {
+		id := NewRequestId()
+		Expect(id).To(MatchRegexp(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`))
+		Expect(NewRequestId()).ToNot(Equal(id))
+	})
+
+	It("should order ids by time", func() {
+		first := NewRequestId()
+		time.Sleep(2 * time.Millisecond)
+		Expect(NewRequestId() > first).To(BeTrue())
+	})
+
+	It("should accept the usual id formats", func() {
+		Expect(ValidRequestId(NewRequestId())).To(BeTrue())
+		Expect(ValidRequestId("01ARZ3NDEKTSV4RRFFQ69G5FAV")).To(BeTrue())
+		Expect(ValidRequestId("Root=1-5759e988-bd862e3fe1be46a994272793")).To(BeTrue())
+	})
+
+	It("should refuse ids that could forge log lines", func() {
+		Expect(ValidRequestId("")).To(BeFalse())
+		Expect(ValidRequestId("a b")).To(BeFalse())
+		Expect(ValidRequestId("a\nb")).To(BeFalse())
+		Expect(ValidRequestId(`a"b`)).To(BeFalse())
+		Expect(ValidRequestId(strings.Repeat("a", MaxRequestIdLength+1))).To(BeFalse())
+	})
+})
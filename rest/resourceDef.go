@@ -13,9 +13,21 @@ type ResourceDef struct {
 	// where else would be put content type, if not here?
 	RequestContentTypes  []string
 	ResponseContentTypes []string
-	template             *UrlPath
+	// RequestExample and ResponseExample are sample bodies, for mocks & documentation
+	RequestExample  interface{}
+	ResponseExample interface{}
+	// Security names the schemes that authorize the endpoint, any one will do.  Nil is the
+	// api default and an empty list, Anonymous, needs none
+	Security []string
+	// StrictBody rejects request bodies with unknown fields, duplicate keys or trailing data,
+	// for the content types that have a strict decoder, such as json
+	StrictBody bool
+	template   *UrlPath
 }
 
+// Anonymous is the Security of an endpoint anyone may call
+var Anonymous = []string{}
+
 func (rd *ResourceDef) GetPath(args interface{}) string {
 	template := rd.template
 	if template == nil {
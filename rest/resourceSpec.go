@@ -40,33 +40,33 @@ func (r *ResourceSpec) Use(def *ResourceDef) *ResourceSpec {
 	return r
 }
 
+// WithContentTypes is a Fluent Method that adds content types the endpoints are also served in,
+// after the default one.  A reply is only ever sent in one of the endpoint's content types
+func (r *ResourceSpec) WithContentTypes(contentTypes ...string) *ResourceSpec {
+	r.defaultContentType = append(r.defaultContentType, contentTypes...)
+	return r
+}
+
 func (rs *ResourceSpec) ServeAll() ([]ServerResource, Handler) {
-	ct := rs.defaultContentType
 	all := make([]ServerResource, 0)
-	if rs.get != nil {
-		all = append(all, NewServerResource(rs.get, ct, ct))
-	}
-
-	if rs.post != nil {
-		all = append(all, NewServerResource(rs.post, ct, ct))
-	}
-
-	if rs.put != nil {
-		all = append(all, NewServerResource(rs.put, ct, ct))
+	for _, def := range []*ResourceDef{rs.get, rs.post, rs.put, rs.delete, rs.head, rs.patch} {
+		if def != nil {
+			all = append(all, rs.serve(def))
+		}
 	}
+	return all, rs.defaultHandler
+}
 
-	if rs.delete != nil {
-		all = append(all, NewServerResource(rs.delete, ct, ct))
+// serve is the ServerResource of a definition, with its own content types, or the spec's
+func (rs *ResourceSpec) serve(def *ResourceDef) ServerResource {
+	req, resp := def.RequestContentTypes, def.ResponseContentTypes
+	if len(req) == 0 {
+		req = rs.defaultContentType
 	}
-
-	if rs.head != nil {
-		all = append(all, NewServerResource(rs.head, ct, ct))
+	if len(resp) == 0 {
+		resp = rs.defaultContentType
 	}
-
-	if rs.patch != nil {
-		all = append(all, NewServerResource(rs.patch, ct, ct))
-	}
-	return all, rs.defaultHandler
+	return NewServerResource(def, req, resp)
 }
 
 // Client Behavior
@@ -158,7 +158,7 @@ func (rs *ResourceSpec) Head(args interface{}) *ClientRequest {
 	req := &ClientRequest{
 		Resource:   path,
 		Verb:       "HEAD",
-		Definition: NewServerResource(rs.delete, rs.defaultContentType, rs.defaultContentType),
+		Definition: NewServerResource(rs.head, rs.defaultContentType, rs.defaultContentType),
 	}
 	attachArgs(req, args)
 	return req
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"net/http"
 	"net/url"
 	"sort"
 
@@ -12,8 +11,10 @@ import (
 )
 
 const (
-	ContentTypeJson = "application/json"
-	ContentTypeText = "text/plain"
+	ContentTypeJson    = "application/json"
+	ContentTypeText    = "text/plain"
+	ContentTypeXml     = "application/xml"
+	ContentTypeTextXml = "text/xml"
 )
 
 //more work to support http://www.w3.org/Protocols/rfc2616/rfc2616-sec14.html
@@ -63,6 +64,28 @@ func FullApiWithHandlers(apiSpec interface{}) map[ServerResource]Handler {
 	return handlers
 }
 
+// FullApi is every endpoint of an api spec, a struct of *ResourceSpec fields, in field order.
+// Unlike FullApiWithHandlers the specs don't need handlers.
+func FullApi(apiSpec interface{}) []ServerResource {
+	fields, err := reflections.Fields(apiSpec)
+	if err != nil {
+		panic(err)
+	}
+
+	all := make([]ServerResource, 0)
+	for _, name := range fields {
+		v, err := reflections.GetField(apiSpec, name)
+		if err != nil {
+			continue //unexported
+		}
+		if target, ok := v.(*ResourceSpec); ok && target != nil {
+			verbs, _ := target.ServeAll()
+			all = append(all, verbs...)
+		}
+	}
+	return all
+}
+
 // Bytes, is a helper method to reduce the number of lines to get a byte array out of the
 // EndpointResponse
 func Bytes(resp *EndpointResponse, err error) ([]byte, error) {
@@ -77,15 +100,14 @@ func Bytes(resp *EndpointResponse, err error) ([]byte, error) {
 	if resp.HttpResponse == nil {
 		return nil, errors.New("No HttpResponse Response")
 	}
-	if resp.HttpResponse.StatusCode != http.StatusOK {
-		return nil, errors.New(resp.HttpResponse.Status)
-	}
-
-	if bytes, err := resp.Bytes(); err != nil {
+	bytes, err := resp.Bytes()
+	if err != nil {
 		return nil, err
-	} else {
-		return bytes, nil
 	}
+	if status := resp.HttpResponse.StatusCode; status < 200 || status > 299 {
+		return nil, newAPIError(resp.HttpResponse, bytes)
+	}
+	return bytes, nil
 }
 
 // Encode - Returns a URL that is encoded in a way that is actually used in practice, preseves as many special characters
@@ -1,6 +1,8 @@
 package rest_test
 
 import (
+	"reflect"
+
 	. "github.com/gotgo/gokn/rest"
 
 	. "github.com/onsi/ginkgo"
@@ -28,4 +30,19 @@ var _ = Describe("Rest", func() {
 		Expect(resp.Status).To(Equal(200))
 	})
 
+	It("should list every endpoint of an api spec without handlers", func() {
+		def := &ResourceDef{ResourceT: "/small", Verb: "GET", ResponseBody: reflect.TypeOf(SmallStruct{})}
+		api := &struct {
+			Small *ResourceSpec
+			Other *ResourceSpec
+		}{
+			Small: NewResourceSpec(ContentTypeJson).Use(def).Use(&ResourceDef{ResourceT: "/small", Verb: "POST"}),
+		}
+
+		endpoints := FullApi(api)
+		Expect(endpoints).To(HaveLen(2))
+		Expect(endpoints[0].Verb()).To(Equal("GET"))
+		Expect(DefinitionOf(endpoints[0])).To(Equal(def))
+	})
+
 })
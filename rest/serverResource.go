@@ -30,6 +30,14 @@ func NewServerResource(definition *ResourceDef, reqContentTypes []string, respCo
 	}
 }
 
+// DefinitionOf is the ResourceDef behind a ServerResource made by NewServerResource, or nil
+func DefinitionOf(sr ServerResource) *ResourceDef {
+	if spec, ok := sr.(*serverResourceSpec); ok {
+		return spec.Definition
+	}
+	return nil
+}
+
 type serverResourceSpec struct {
 	Definition           *ResourceDef
 	requestContentTypes  []string //TODO: Request & Response ContentTypes go on the spec or the definition?
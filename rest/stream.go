@@ -0,0 +1,142 @@
+package rest
+
+import (
+	"io"
+	"reflect"
+)
+
+// StreamCodec is a Codec of lists that also encodes them item by item, as the items of a slice,
+// channel or iterator come, and decodes the items of a reply one at a time
+type StreamCodec interface {
+	Codec
+	EncodeStream(w io.Writer, v interface{}) error
+	NewDecoder(r io.Reader) ItemDecoder
+}
+
+// ItemDecoder decodes the next item of a stream into v, io.EOF when there are no more
+type ItemDecoder interface {
+	Decode(v interface{}) error
+}
+
+// eachItem calls fn with the items of a slice, array, channel or iterator, a
+// func(yield func(T) bool), until fn fails.  Anything else is a single item.  A channel is
+// drained in the background once fn fails, so its producer isn't left blocked on a send, it
+// should still watch the request's context to stop producing early
+func eachItem(v interface{}, fn func(item reflect.Value) error) error {
+	rv := reflect.ValueOf(Target(v))
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() && isSequence(rv.Elem().Type()) {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+
+	switch {
+	case rv.Kind() == reflect.Chan:
+		for {
+			item, ok := rv.Recv()
+			if !ok {
+				return nil
+			}
+			if err := fn(item); err != nil {
+				go drain(rv)
+				return err
+			}
+		}
+	case rv.Kind() == reflect.Func:
+		var err error
+		yield := reflect.MakeFunc(rv.Type().In(0), func(args []reflect.Value) []reflect.Value {
+			err = fn(args[0])
+			return []reflect.Value{reflect.ValueOf(err == nil)}
+		})
+		rv.Call([]reflect.Value{yield})
+		return err
+	case isSequence(rv.Type()):
+		for i := 0; i < rv.Len(); i++ {
+			if err := fn(rv.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fn(rv)
+	}
+}
+
+// drain receives from the channel until it's closed
+func drain(ch reflect.Value) {
+	for {
+		if _, ok := ch.Recv(); !ok {
+			return
+		}
+	}
+}
+
+// itemType is the type of the items eachItem yields
+func itemType(v interface{}) reflect.Type {
+	t := reflect.TypeOf(Target(v))
+	for t != nil && t.Kind() == reflect.Ptr && isSequence(t.Elem()) {
+		t = t.Elem()
+	}
+	if t == nil || !isSequence(t) {
+		return t
+	} else if t.Kind() == reflect.Func {
+		return t.In(0).In(0)
+	}
+	return t.Elem()
+}
+
+// isSequence is true of lists, channels and iterators
+func isSequence(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return t.Elem().Kind() != reflect.Uint8
+	case reflect.Chan:
+		return t.ChanDir()&reflect.RecvDir != 0
+	case reflect.Func:
+		return isIterator(t)
+	}
+	return false
+}
+
+// isIterator is true of func(yield func(T) bool)
+func isIterator(t reflect.Type) bool {
+	if t.NumIn() != 1 || t.NumOut() != 0 {
+		return false
+	}
+	yield := t.In(0)
+	return yield.Kind() == reflect.Func && yield.NumIn() == 1 && yield.NumOut() == 1 &&
+		yield.Out(0).Kind() == reflect.Bool
+}
+
+// decodeItems sets the slice v points to to the items of the decoder, or decodes the first
+// item into v when it isn't a slice
+func decodeItems(decoder ItemDecoder, v interface{}) error {
+	target := decodeTarget(reflect.ValueOf(v))
+	if !target.IsValid() || !isList(target.Type()) {
+		return decoder.Decode(Target(v))
+	}
+
+	list := reflect.MakeSlice(target.Type(), 0, 0)
+	for {
+		item, into := newItem(target.Type().Elem())
+		if err := decoder.Decode(into); err == io.EOF {
+			target.Set(list)
+			return nil
+		} else if err != nil {
+			return err
+		}
+		list = reflect.Append(list, item.Elem())
+	}
+}
+
+// newItem is a pointer to a new t and the pointer to decode into, the one t is when it's a
+// pointer itself
+func newItem(t reflect.Type) (reflect.Value, interface{}) {
+	item := reflect.New(t)
+	if t.Kind() == reflect.Ptr {
+		item.Elem().Set(reflect.New(t.Elem()))
+		return item, item.Elem().Interface()
+	}
+	return item, item.Interface()
+}
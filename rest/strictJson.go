@@ -0,0 +1,313 @@
+package rest
+
+import (
+	"bytes"
+	stdencoding "encoding"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*stdencoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// jsonChecker walks the tokens of a json body along the type it decodes into, to find where it
+// doesn't fit and, when strict, its unknown fields, duplicate keys and trailing data
+type jsonChecker struct {
+	data    []byte
+	decoder *json.Decoder
+	strict  bool
+}
+
+// checkJson is where the json doesn't decode into t, nil when it does as far as the checker
+// can tell.  The types that decode themselves aren't checked
+func checkJson(data []byte, t reflect.Type, strict bool) *DecodeError {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	c := &jsonChecker{data: data, decoder: decoder, strict: strict}
+	if err := c.value(t, "", false); err != nil {
+		return err
+	}
+	offset := c.offset()
+	if _, err := decoder.Token(); err != io.EOF {
+		return &DecodeError{Code: DecodeTrailingData, Offset: offset}
+	}
+	return nil
+}
+
+// offset is where the next token starts
+func (c *jsonChecker) offset() int64 {
+	offset := c.decoder.InputOffset()
+	for offset < int64(len(c.data)) && strings.IndexByte(" \t\r\n,:", c.data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+func (c *jsonChecker) value(t reflect.Type, path string, quoted bool) *DecodeError {
+	offset := c.offset()
+	if decodesItself(t) {
+		return c.skip(offset)
+	}
+	token, err := c.decoder.Token()
+	if err != nil {
+		return &DecodeError{Code: DecodeSyntax, Offset: offset, Err: err}
+	}
+
+	t = indirectType(t)
+	any := t.Kind() == reflect.Interface
+	mismatch := &DecodeError{Code: DecodeType, Offset: offset, Field: path, Expected: jsonType(t, quoted)}
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '{' {
+			if quoted || !(any || t.Kind() == reflect.Struct || t.Kind() == reflect.Map) {
+				return mismatch
+			}
+			return c.object(t, path)
+		}
+		if quoted || !(any || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			return mismatch
+		}
+		return c.array(t, path)
+	case nil:
+		return nil
+	case string:
+		if any || quoted || t.Kind() == reflect.String || decodesText(t) ||
+			(t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8) {
+			return nil
+		}
+	case json.Number:
+		if !quoted && (any || fitsNumber(token, t)) {
+			return nil
+		}
+	case bool:
+		if !quoted && (any || t.Kind() == reflect.Bool) {
+			return nil
+		}
+	}
+	return mismatch
+}
+
+func (c *jsonChecker) object(t reflect.Type, path string) *DecodeError {
+	seen := make(map[string]bool)
+	for c.decoder.More() {
+		offset := c.offset()
+		token, err := c.decoder.Token()
+		if err != nil {
+			return &DecodeError{Code: DecodeSyntax, Offset: offset, Err: err}
+		}
+		key, _ := token.(string)
+		field := joinPath(path, key)
+		if c.strict && seen[key] {
+			return &DecodeError{Code: DecodeDuplicateKey, Offset: offset, Field: field}
+		}
+		seen[key] = true
+
+		switch t.Kind() {
+		case reflect.Struct:
+			if f, ok := jsonFieldOf(t, key); ok {
+				if err := c.value(f.Type, field, f.quoted); err != nil {
+					return err
+				}
+			} else if c.strict {
+				return &DecodeError{Code: DecodeUnknownField, Offset: offset, Field: field}
+			} else if err := c.skip(c.offset()); err != nil {
+				return err
+			}
+		case reflect.Map:
+			if err := c.value(t.Elem(), field, false); err != nil {
+				return err
+			}
+		default:
+			if err := c.value(t, field, false); err != nil {
+				return err
+			}
+		}
+	}
+	return c.end()
+}
+
+func (c *jsonChecker) array(t reflect.Type, path string) *DecodeError {
+	elem := t
+	if t.Kind() != reflect.Interface {
+		elem = t.Elem()
+	}
+	for i := 0; c.decoder.More(); i++ {
+		if err := c.value(elem, path+"["+strconv.Itoa(i)+"]", false); err != nil {
+			return err
+		}
+	}
+	return c.end()
+}
+
+// end reads the closing delimiter
+func (c *jsonChecker) end() *DecodeError {
+	offset := c.offset()
+	if _, err := c.decoder.Token(); err != nil {
+		return &DecodeError{Code: DecodeSyntax, Offset: offset, Err: err}
+	}
+	return nil
+}
+
+// skip reads the next value, whatever it is
+func (c *jsonChecker) skip(offset int64) *DecodeError {
+	var raw json.RawMessage
+	if err := c.decoder.Decode(&raw); err != nil {
+		return &DecodeError{Code: DecodeSyntax, Offset: offset, Err: err}
+	}
+	return nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// decodesItself is true of json.Unmarshalers
+func decodesItself(t reflect.Type) bool {
+	return t.Implements(jsonUnmarshalerType) || reflect.PtrTo(indirectType(t)).Implements(jsonUnmarshalerType)
+}
+
+// decodesText is true of encoding.TextUnmarshalers, which decode from strings
+func decodesText(t reflect.Type) bool {
+	return t.Kind() != reflect.Interface && reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// fitsNumber is true when the number decodes into t without overflow or truncation
+func fitsNumber(n json.Number, t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err := strconv.ParseInt(n.String(), 10, t.Bits())
+		return err == nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		_, err := strconv.ParseUint(n.String(), 10, t.Bits())
+		return err == nil
+	case reflect.Float32, reflect.Float64:
+		_, err := strconv.ParseFloat(n.String(), t.Bits())
+		return err == nil
+	}
+	return false
+}
+
+type jsonField struct {
+	reflect.StructField
+	quoted bool
+	tagged bool
+	depth  int
+}
+
+// jsonFieldOf is the field a key decodes into, matched as encoding/json does: the exact name
+// first, then ignoring case
+func jsonFieldOf(t reflect.Type, key string) (jsonField, bool) {
+	fields := jsonFields(t)
+	for _, f := range fields {
+		if f.Name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.Name, key) {
+			return f, true
+		}
+	}
+	return jsonField{}, false
+}
+
+// jsonFields are the fields of a struct by their json names, with those of embedded structs
+// promoted as encoding/json does: the shallowest field of a name wins, then the only tagged
+// one, and a name left ambiguous is dropped
+func jsonFields(t reflect.Type) []jsonField {
+	byName := map[string][]jsonField{}
+	names := []string{}
+	for _, f := range embeddedFields(t, 0, map[reflect.Type]bool{}) {
+		if _, ok := byName[f.Name]; !ok {
+			names = append(names, f.Name)
+		}
+		byName[f.Name] = append(byName[f.Name], f)
+	}
+	fields := []jsonField{}
+	for _, name := range names {
+		if f, ok := dominantField(byName[name]); ok {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// embeddedFields are the fields of a struct and of its embedded structs, at their depth
+func embeddedFields(t reflect.Type, depth int, visiting map[reflect.Type]bool) []jsonField {
+	if visiting[t] {
+		return nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	fields := []jsonField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		options := strings.Split(tag, ",")
+		name := options[0]
+		if field.Anonymous && name == "" && indirectType(field.Type).Kind() == reflect.Struct {
+			fields = append(fields, embeddedFields(indirectType(field.Type), depth+1, visiting)...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		f := jsonField{StructField: field, tagged: name != "", depth: depth}
+		if name != "" {
+			f.Name = name
+		}
+		for _, option := range options[1:] {
+			f.quoted = f.quoted || (option == "string" && quotable(field.Type))
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// dominantField is the one of the fields sharing a name that encoding/json decodes into
+func dominantField(fields []jsonField) (jsonField, bool) {
+	shallowest := []jsonField{}
+	for _, f := range fields {
+		if len(shallowest) == 0 || f.depth < shallowest[0].depth {
+			shallowest = []jsonField{f}
+		} else if f.depth == shallowest[0].depth {
+			shallowest = append(shallowest, f)
+		}
+	}
+	if len(shallowest) == 1 {
+		return shallowest[0], true
+	}
+	tagged := []jsonField{}
+	for _, f := range shallowest {
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return jsonField{}, false
+}
+
+// quotable is true of the types the ,string option applies to
+func quotable(t reflect.Type) bool {
+	switch indirectType(t).Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package rest_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type orderLine struct {
+	Sku      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+type Stamped struct {
+	At time.Time `json:"at"`
+}
+
+type Base struct {
+	ID int
+}
+
+type Labelled struct {
+	ID   int
+	Name string `json:"name"`
+}
+
+type Named struct {
+	Name string
+}
+
+type outer struct {
+	Base
+	ID string
+}
+
+type ambiguous struct {
+	Base
+	Labelled
+	Named
+}
+
+type strictOrder struct {
+	Stamped
+	Id     int64             `json:"id,string"`
+	Lines  []orderLine       `json:"lines"`
+	Labels map[string]string `json:"labels"`
+	Extra  interface{}       `json:"extra"`
+	Note   *string
+}
+
+var _ = Describe("JsonCodec decode errors", func() {
+	codec := rest.JsonCodec{}
+
+	decodeError := func(err error) *rest.DecodeError {
+		var de *rest.DecodeError
+		Expect(errors.As(err, &de)).To(BeTrue(), "%v", err)
+		return de
+	}
+
+	It("should decode what fits", func() {
+		order := new(strictOrder)
+		data := `{"id":"7","at":"2020-01-02T03:04:05Z","lines":[{"sku":"a","quantity":2}],` +
+			`"labels":{"a":"b"},"extra":{"x":[1,"y"]},"NOTE":"n","unknown":{"a":1}}`
+		Expect(codec.Decode([]byte(data), order)).To(Succeed())
+		Expect(order.Id).To(Equal(int64(7)))
+		Expect(*order.Note).To(Equal("n"))
+	})
+
+	It("should locate a type error by offset, field path and expected type", func() {
+		data := `{"lines": [{"sku": "a", "quantity": 1}, {"sku": "b", "quantity": "two"}]}`
+		de := decodeError(codec.Decode([]byte(data), new(strictOrder)))
+		Expect(de.Code).To(Equal(rest.DecodeType))
+		Expect(de.Field).To(Equal("lines[1].quantity"))
+		Expect(de.Expected).To(Equal("integer"))
+		Expect(de.Offset).To(Equal(int64(65)))
+		Expect(data[de.Offset:]).To(HavePrefix(`"two"`))
+		Expect(de.Error()).To(Equal("field lines[1].quantity should be an integer at offset 65"))
+	})
+
+	It("should locate the type errors of quoted numbers, overflows and the body itself", func() {
+		de := decodeError(codec.Decode([]byte(`{"id":7}`), new(strictOrder)))
+		Expect(de.Field).To(Equal("id"))
+		Expect(de.Expected).To(Equal("string"))
+
+		de = decodeError(codec.Decode([]byte(`[{"sku":"a","quantity":1e40}]`), &[]orderLine{}))
+		Expect(de.Field).To(Equal("[0].quantity"))
+
+		de = decodeError(codec.Decode([]byte(`[]`), new(strictOrder)))
+		Expect(de.Field).To(Equal(""))
+		Expect(de.Expected).To(Equal("object"))
+	})
+
+	It("should locate a syntax error", func() {
+		de := decodeError(codec.Decode([]byte(`{"lines": [{"sku": "a",}]}`), new(strictOrder)))
+		Expect(de.Code).To(Equal(rest.DecodeSyntax))
+		Expect(de.Offset).To(Equal(int64(23)))
+	})
+
+	It("should fail trailing data", func() {
+		de := decodeError(codec.Decode([]byte(`{"id":"1"} {"id":"2"}`), new(strictOrder)))
+		Expect(de.Code).To(Equal(rest.DecodeTrailingData))
+		Expect(de.Offset).To(Equal(int64(11)))
+	})
+
+	Context("strictly", func() {
+		It("should fail unknown fields", func() {
+			data := `{"lines": [{"sku": "a", "colour": "red"}]}`
+			de := decodeError(codec.DecodeStrict([]byte(data), new(strictOrder)))
+			Expect(de.Code).To(Equal(rest.DecodeUnknownField))
+			Expect(de.Field).To(Equal("lines[0].colour"))
+			Expect(data[de.Offset:]).To(HavePrefix(`"colour"`))
+		})
+
+		It("should fail duplicate keys, of maps and of what decodes into interfaces too", func() {
+			for _, data := range []string{`{"id":"1","id":"2"}`, `{"labels":{"a":"1","a":"2"}}`, `{"extra":{"x":{"y":1,"y":2}}}`} {
+				de := decodeError(codec.DecodeStrict([]byte(data), new(strictOrder)))
+				Expect(de.Code).To(Equal(rest.DecodeDuplicateKey), data)
+			}
+			de := decodeError(codec.DecodeStrict([]byte(`{"extra":{"x":{"y":1,"y":2}}}`), new(strictOrder)))
+			Expect(de.Field).To(Equal("extra.x.y"))
+		})
+
+		It("should promote embedded fields as encoding/json does", func() {
+			o := new(outer)
+			Expect(codec.DecodeStrict([]byte(`{"ID":"x"}`), o)).To(Succeed())
+			Expect(o.ID).To(Equal("x"))
+
+			a := new(ambiguous)
+			Expect(codec.DecodeStrict([]byte(`{"name":"n"}`), a)).To(Succeed())
+			Expect(a.Labelled.Name).To(Equal("n"))
+			de := decodeError(codec.DecodeStrict([]byte(`{"ID":1}`), a))
+			Expect(de.Code).To(Equal(rest.DecodeUnknownField))
+			Expect(de.Field).To(Equal("ID"))
+		})
+
+		It("should decode what has no unknown fields or duplicate keys", func() {
+			order := new(strictOrder)
+			data := `{"id":"7","at":"2020-01-02T03:04:05Z","lines":[{"sku":"a","quantity":2}],"Note":"n"}`
+			Expect(codec.DecodeStrict([]byte(data), order)).To(Succeed())
+			Expect(order.Lines).To(HaveLen(1))
+			Expect(order.At.Year()).To(Equal(2020))
+		})
+	})
+})
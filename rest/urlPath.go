@@ -6,16 +6,14 @@ import (
 	"html/template"
 	"net/url"
 	"regexp"
-	"strings"
 
 	"github.com/fatih/structs"
-	"github.com/google/go-querystring/query"
 )
 
 var paramRegex *regexp.Regexp
 
 func init() {
-	paramRegex, _ = regexp.Compile("\\{([a-zA-Z0-9]+)\\}")
+	paramRegex, _ = regexp.Compile("\\{([a-zA-Z0-9_]+)(?::[^}]*)?\\}")
 }
 
 type UrlPath struct {
@@ -38,8 +36,10 @@ func (up *UrlPath) Path(args interface{}) string {
 	up.compile()
 	toClean := structs.Map(args)
 	clean := make(map[string]string)
+	remaining := make(map[string]string)
 	for k, v := range toClean {
-		clean[k] = url.QueryEscape(fmt.Sprintf("%v", v))
+		remaining[k] = fmt.Sprintf("%v", v)
+		clean[k] = url.QueryEscape(remaining[k])
 	}
 	buff := bytes.NewBufferString("")
 	up.compiledTemplate.Execute(buff, clean)
@@ -47,10 +47,10 @@ func (up *UrlPath) Path(args interface{}) string {
 
 	//remove used keys
 	for _, k := range up.keys {
-		delete(clean, k)
+		delete(remaining, k)
 	}
 
-	return queryParams(path, clean)
+	return queryParams(path, remaining)
 }
 
 func queryParams(path string, args map[string]string) string {
@@ -58,11 +58,11 @@ func queryParams(path string, args map[string]string) string {
 		return path
 	}
 
-	if v, err := query.Values(args); err != nil {
-		panic(err)
-	} else {
-		return path + "?" + v.Encode()
+	values := make(url.Values)
+	for k, v := range args {
+		values.Set(k, v)
 	}
+	return path + "?" + values.Encode()
 }
 
 func (up *UrlPath) compile() {
@@ -70,7 +70,7 @@ func (up *UrlPath) compile() {
 		captures := paramRegex.FindAllStringSubmatch(up.resourceT, -1)
 		keys := make([]string, len(captures))
 		for i := range captures {
-			keys[i] = captures[i][0]
+			keys[i] = captures[i][1]
 		}
 		up.keys = keys
 		templateName := up.resourceT
@@ -80,11 +80,8 @@ func (up *UrlPath) compile() {
 	}
 }
 
-// prepare converts the typical url template /url/{param} to the html.templates of
-// /url/{{.param}}
+// prepare converts the typical url template /url/{param} or /url/{param:[0-9]+} to the
+// html.templates of /url/{{.param}}
 func (up *UrlPath) resourceAsTemplate() string {
-	urlT := up.resourceT
-	halfway := strings.Replace(urlT, "{", "{{.", -1)
-	final := strings.Replace(halfway, "}", "}}", -1)
-	return final
+	return paramRegex.ReplaceAllString(up.resourceT, "{{.$1}}")
 }
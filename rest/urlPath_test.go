@@ -0,0 +1,31 @@
+package rest_test
+
+import (
+	"github.com/gotgo/gokn/rest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type urlPathArgs struct {
+	OrderId int    `structs:"order_id"`
+	Status  string `structs:"status"`
+	Search  string `structs:"q"`
+}
+
+var _ = Describe("UrlPath", func() {
+
+	It("should fill the variables and send the remaining args as query params", func() {
+		path := rest.NewUrlPath("/orders/{order_id}").Path(&urlPathArgs{OrderId: 42, Status: "open", Search: "a b&c"})
+		Expect(path).To(Equal("/orders/42?q=a+b%26c&status=open"))
+	})
+
+	It("should fill variables with a pattern", func() {
+		path := rest.NewUrlPath("/orders/{order_id:[0-9]+}/items").Path(&urlPathArgs{OrderId: 7})
+		Expect(path).To(HavePrefix("/orders/7/items?"))
+	})
+
+	It("should escape the variables", func() {
+		path := rest.NewUrlPath("/search/{q}").Path(&urlPathArgs{Search: "a/b"})
+		Expect(path).To(HavePrefix("/search/a%2Fb?"))
+	})
+})
@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+)
+
+// XmlCodec encodes with encoding/xml, which honours the xml struct tags.  Xml has no element
+// for a list, so slices are the children of a RootElement.
+//
+//	Example:
+//
+//		[]Order{{Id: 1}, {Id: 2}}
+//
+//		<orders><Order><id>1</id></Order><Order><id>2</id></Order></orders>
+type XmlCodec struct {
+	// RootElement of slices, items when empty.  Any root element decodes
+	RootElement string
+}
+
+func NewXmlCodec() *XmlCodec {
+	return &XmlCodec{RootElement: "items"}
+}
+
+func (c *XmlCodec) ContentType() string {
+	return ContentTypeXml
+}
+
+func (c *XmlCodec) Encode(v interface{}) ([]byte, error) {
+	if v == nil {
+		return []byte{}, nil
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return xml.Marshal(v)
+		}
+		rv = rv.Elem()
+	}
+	if !isList(rv.Type()) {
+		return xml.Marshal(v)
+	}
+
+	var buf bytes.Buffer
+	encoder := xml.NewEncoder(&buf)
+	root := xml.StartElement{Name: xml.Name{Local: c.root()}}
+	if err := encoder.EncodeToken(root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Encode(rv.Interface()); err != nil {
+		return nil, err
+	}
+	if err := encoder.EncodeToken(root.End()); err != nil {
+		return nil, err
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *XmlCodec) Decode(data []byte, v interface{}) error {
+	target := decodeTarget(reflect.ValueOf(v))
+	if !target.IsValid() || !isList(target.Type()) {
+		return xml.Unmarshal(data, v)
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// the root element
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if _, ok := token.(xml.StartElement); ok {
+			break
+		}
+	}
+
+	list := reflect.MakeSlice(target.Type(), 0, 0)
+	elemType := target.Type().Elem()
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			elem := reflect.New(elemType)
+			if elemType.Kind() == reflect.Ptr {
+				elem.Elem().Set(reflect.New(elemType.Elem()))
+			}
+			if err := decoder.DecodeElement(elem.Interface(), &t); err != nil {
+				return err
+			}
+			list = reflect.Append(list, elem.Elem())
+		case xml.EndElement:
+			target.Set(list)
+			return nil
+		}
+	}
+}
+
+func (c *XmlCodec) root() string {
+	if c.RootElement == "" {
+		return "items"
+	}
+	return c.RootElement
+}
+
+// isList is true of slices other than []byte
+func isList(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+// decodeTarget is the value a pointer points to, through the pointers and interfaces of a
+// *interface{} holding a pointer, or invalid when v isn't a pointer
+func decodeTarget(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		elem := rv.Elem()
+		switch {
+		case elem.Kind() == reflect.Interface && !elem.IsNil():
+			rv = elem.Elem()
+		case elem.Kind() == reflect.Ptr && !elem.IsNil():
+			rv = elem
+		default:
+			return elem
+		}
+	}
+	return reflect.Value{}
+}
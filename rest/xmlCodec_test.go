@@ -0,0 +1,86 @@
+package rest_test
+
+import (
+	"encoding/xml"
+
+	"github.com/gotgo/gokn/rest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type xmlOrder struct {
+	XMLName xml.Name `xml:"order"`
+	Id      int      `xml:"id,attr"`
+	Note    string   `xml:"note"`
+}
+
+var _ = Describe("XmlCodec", func() {
+	var codec *rest.XmlCodec
+
+	BeforeEach(func() {
+		codec = rest.NewXmlCodec()
+	})
+
+	It("should honour the xml tags", func() {
+		bts, err := codec.Encode(&xmlOrder{Id: 1, Note: "a"})
+		Expect(err).To(BeNil())
+		Expect(string(bts)).To(Equal(`<order id="1"><note>a</note></order>`))
+
+		decoded := new(xmlOrder)
+		Expect(codec.Decode(bts, decoded)).To(Succeed())
+		Expect(decoded.Id).To(Equal(1))
+		Expect(decoded.Note).To(Equal("a"))
+	})
+
+	It("should wrap slices in the root element", func() {
+		codec.RootElement = "orders"
+		bts, err := codec.Encode([]xmlOrder{{Id: 1}, {Id: 2}})
+		Expect(err).To(BeNil())
+		Expect(string(bts)).To(Equal(`<orders><order id="1"><note></note></order><order id="2"><note></note></order></orders>`))
+
+		var values []xmlOrder
+		Expect(codec.Decode(bts, &values)).To(Succeed())
+		Expect(values).To(HaveLen(2))
+		Expect(values[1].Id).To(Equal(2))
+
+		var pointers []*xmlOrder
+		Expect(codec.Decode(bts, &pointers)).To(Succeed())
+		Expect(pointers).To(HaveLen(2))
+		Expect(pointers[0].Id).To(Equal(1))
+	})
+
+	It("should decode through an interface holding a pointer", func() {
+		var list []*xmlOrder
+		var v interface{} = &list
+		Expect(codec.Decode([]byte(`<items><order id="3"></order></items>`), &v)).To(Succeed())
+		Expect(list).To(HaveLen(1))
+		Expect(list[0].Id).To(Equal(3))
+	})
+
+	It("should fail an unterminated list", func() {
+		var list []xmlOrder
+		Expect(codec.Decode([]byte(`<items><order id="3"></order>`), &list)).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("Codecs", func() {
+	It("should fall back to a wildcard codec", func() {
+		codecs := rest.NewCodecs()
+		codecs.Set(rest.JsonCodec{}, "text/*")
+		Expect(codecs.Get("text/markdown")).To(Equal(rest.JsonCodec{}))
+		Expect(codecs.Get("text/xml")).To(BeAssignableToTypeOf(&rest.XmlCodec{}))
+		Expect(codecs.Get("image/png")).To(BeNil())
+	})
+
+	It("should find a codec by media type", func() {
+		codecs := rest.NewCodecs()
+		Expect(codecs.Get("application/json; charset=utf-8")).To(Equal(rest.JsonCodec{}))
+		Expect(codecs.Get("Text/XML")).To(BeAssignableToTypeOf(&rest.XmlCodec{}))
+		Expect(codecs.Get("application/x-msgpack")).To(Equal(rest.MsgpackCodec{}))
+		Expect(codecs.Get("application/yaml")).To(BeNil())
+		Expect(codecs.Get("application/problem+json")).To(Equal(rest.JsonCodec{}))
+		Expect(codecs.Get("application/atom+xml")).To(BeAssignableToTypeOf(&rest.XmlCodec{}))
+		Expect(codecs.ContentTypes()).To(Equal([]string{"application/json", "application/xml", "text/xml", "application/msgpack", "application/x-msgpack", "application/cbor", "application/x-protobuf", "application/x-ndjson", "text/csv"}))
+	})
+})